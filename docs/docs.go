@@ -19,84 +19,76 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/find_free_time": {
+        "/api/v1/meetings": {
             "get": {
-                "description": "get the closest free time for all required users and the specified period",
+                "description": "get meeting for given id or list of the meetings.\nThe list is sorted and may be cut into pages, the Link header refers to the next page.",
                 "consumes": [
                     "application/x-www-form-urlencoded"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "find closest free time",
+                "summary": "get meetings",
                 "parameters": [
                     {
-                        "type": "array",
-                        "items": {
-                            "type": "integer"
-                        },
-                        "description": "User ID",
+                        "type": "string",
+                        "description": "Meeting ID",
                         "name": "id",
-                        "in": "path",
-                        "required": true
+                        "in": "path"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "List the meetings created by the user",
+                        "name": "creator_id",
+                        "in": "path"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "List the meetings the user is a member of",
+                        "name": "member_id",
+                        "in": "path"
+                    },
+                    {
+                        "enum": [
+                            "lib.Period"
+                        ],
+                        "type": "string",
+                        "description": "List the meetings repeating with the period",
+                        "name": "period",
+                        "in": "path"
                     },
                     {
                         "type": "string",
-                        "description": "Search period start time in RFC3339. If not specified, the app uses now.",
+                        "description": "List the meetings which occurrences end after the time in RFC3339",
                         "name": "start_at",
                         "in": "path"
                     },
                     {
                         "type": "string",
-                        "description": "Search period duration in format '1h2m3s'. Any of values may be ommited.",
-                        "name": "duration",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "Meeting ID",
-                        "schema": {
-                            "type": "integer"
-                        }
+                        "description": "List the meetings which occurrences start before the time in RFC3339",
+                        "name": "end_at",
+                        "in": "path"
                     },
-                    "400": {
-                        "description": "empty",
-                        "schema": {
-                            "type": "string"
-                        }
+                    {
+                        "enum": [
+                            "id",
+                            "start_at"
+                        ],
+                        "type": "string",
+                        "description": "List order, by id by default. The meetings of the same first occurrence time are ordered by id.",
+                        "name": "sort",
+                        "in": "path"
                     },
-                    "404": {
-                        "description": "empty",
-                        "schema": {
-                            "type": "string"
-                        }
+                    {
+                        "type": "integer",
+                        "description": "Page length, all the meetings by default. Up to 1000.",
+                        "name": "limit",
+                        "in": "path"
                     },
-                    "500": {
-                        "description": "empty",
-                        "schema": {
-                            "type": "string"
-                        }
-                    }
-                }
-            }
-        },
-        "/meeting": {
-            "get": {
-                "description": "get meeting for given id or list with all meetings",
-                "consumes": [
-                    "application/x-www-form-urlencoded"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "summary": "get meetings",
-                "parameters": [
                     {
                         "type": "string",
-                        "description": "Meeting ID",
-                        "name": "id",
+                        "description": "Position the page starts after, given in the Link header of the previous page",
+                        "name": "cursor",
                         "in": "path"
                     }
                 ],
@@ -108,21 +100,21 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "empty",
+                        "description": "error description",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/lib.Problem"
                         }
                     },
                     "404": {
-                        "description": "empty",
+                        "description": "error description",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/lib.Problem"
                         }
                     },
                     "500": {
-                        "description": "empty",
+                        "description": "error description",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/lib.Problem"
                         }
                     }
                 }
@@ -130,7 +122,8 @@ const docTemplate = `{
             "post": {
                 "description": "add new meeting",
                 "consumes": [
-                    "application/x-www-form-urlencoded"
+                    "application/x-www-form-urlencoded",
+                    "application/json"
                 ],
                 "produces": [
                     "application/json"
@@ -154,6 +147,15 @@ const docTemplate = `{
                         "in": "path",
                         "required": true
                     },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "integer"
+                        },
+                        "description": "ID list of optional members separated with a comma (',')",
+                        "name": "optional_member_ids",
+                        "in": "path"
+                    },
                     {
                         "type": "string",
                         "description": "Meeting start time in RFC3339",
@@ -176,195 +178,2803 @@ const docTemplate = `{
                         "description": "string enums",
                         "name": "period",
                         "in": "path"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Recurrence rule in RFC 5545 RRULE format, e.g. 'FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH'. Period must be omitted or Custom.",
+                        "name": "rrule",
+                        "in": "path"
+                    },
+                    {
+                        "type": "string",
+                        "description": "IANA time zone, e.g. 'Europe/Berlin'. The meeting repeats at the same local time in it. UTC by default.",
+                        "name": "time_zone",
+                        "in": "path"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "integer"
+                        },
+                        "description": "ID list of the resources to book separated with a comma (',')",
+                        "name": "resource_ids",
+                        "in": "path"
+                    },
+                    {
+                        "enum": [
+                            "allow",
+                            "warn",
+                            "reject"
+                        ],
+                        "type": "string",
+                        "description": "Overlapping meetings of the members are not checked (default), reported or make the meeting rejected",
+                        "name": "conflict_policy",
+                        "in": "path"
+                    },
+                    {
+                        "description": "Meeting in the JSON body instead of the parameters. Members have roles, their status can't be set.",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/lib.MeetingRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Meeting ID",
+                        "description": "Meeting ID and the conflicts if the policy is warn",
+                        "schema": {
+                            "type": "integer"
+                        }
+                    },
+                    "201": {
+                        "description": "Meeting ID, the versioned API answers with the meeting Location",
                         "schema": {
                             "type": "integer"
                         }
                     },
                     "400": {
-                        "description": "empty",
+                        "description": "error description",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflicts if the policy is reject or a resource is booked",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
                         }
                     },
                     "500": {
-                        "description": "empty",
+                        "description": "error description",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/lib.Problem"
                         }
                     }
                 }
             }
         },
-        "/response": {
-            "put": {
-                "description": "send presence responce",
+        "/api/v1/meetings/{id}": {
+            "get": {
+                "description": "get meeting for given id or list of the meetings.\nThe list is sorted and may be cut into pages, the Link header refers to the next page.",
                 "consumes": [
                     "application/x-www-form-urlencoded"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "send presence response",
+                "summary": "get meetings",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Meeting ID",
+                        "name": "id",
+                        "in": "path"
+                    },
                     {
                         "type": "integer",
-                        "description": "User ID",
-                        "name": "user_id",
-                        "in": "path",
-                        "required": true
+                        "description": "List the meetings created by the user",
+                        "name": "creator_id",
+                        "in": "path"
                     },
                     {
                         "type": "integer",
-                        "description": "Meeting ID",
-                        "name": "meeting_id",
-                        "in": "path",
-                        "required": true
+                        "description": "List the meetings the user is a member of",
+                        "name": "member_id",
+                        "in": "path"
                     },
                     {
                         "enum": [
-                            "lib.Presence"
+                            "lib.Period"
                         ],
                         "type": "string",
-                        "description": "string enums",
-                        "name": "presence",
-                        "in": "path",
-                        "required": true
+                        "description": "List the meetings repeating with the period",
+                        "name": "period",
+                        "in": "path"
+                    },
+                    {
+                        "type": "string",
+                        "description": "List the meetings which occurrences end after the time in RFC3339",
+                        "name": "start_at",
+                        "in": "path"
+                    },
+                    {
+                        "type": "string",
+                        "description": "List the meetings which occurrences start before the time in RFC3339",
+                        "name": "end_at",
+                        "in": "path"
+                    },
+                    {
+                        "enum": [
+                            "id",
+                            "start_at"
+                        ],
+                        "type": "string",
+                        "description": "List order, by id by default. The meetings of the same first occurrence time are ordered by id.",
+                        "name": "sort",
+                        "in": "path"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page length, all the meetings by default. Up to 1000.",
+                        "name": "limit",
+                        "in": "path"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Position the page starts after, given in the Link header of the previous page",
+                        "name": "cursor",
+                        "in": "path"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Meeting ID",
+                        "description": "Meeting information",
                         "schema": {
-                            "type": "integer"
+                            "$ref": "#/definitions/lib.Meeting"
                         }
                     },
                     "400": {
-                        "description": "empty",
+                        "description": "error description",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/lib.Problem"
                         }
                     },
                     "404": {
-                        "description": "empty",
+                        "description": "error description",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/lib.Problem"
                         }
                     },
                     "500": {
-                        "description": "empty",
+                        "description": "error description",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/lib.Problem"
                         }
                     }
                 }
-            }
-        },
-        "/user": {
-            "get": {
-                "description": "returns user information for given id or list with information about all users",
+            },
+            "delete": {
+                "description": "remove the meeting and notify its members. Only the meeting creator may cancel it.",
                 "consumes": [
                     "application/x-www-form-urlencoded"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "get user information",
+                "summary": "cancel meeting",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "User ID",
+                        "description": "Meeting ID",
                         "name": "id",
-                        "in": "path"
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of the user cancelling the meeting",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "User information",
+                        "description": "empty",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "type": "array",
-                                "items": {
-                                    "$ref": "#/definitions/lib.User"
-                                }
-                            }
+                            "type": "string"
                         }
                     },
-                    "400": {
-                        "description": "empty",
+                    "204": {
+                        "description": "empty, the versioned API answers with No Content",
                         "schema": {
                             "type": "string"
                         }
                     },
+                    "400": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
                     "404": {
-                        "description": "empty",
+                        "description": "error description",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/lib.Problem"
                         }
                     },
                     "500": {
-                        "description": "empty",
+                        "description": "error description",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/lib.Problem"
                         }
                     }
                 }
             },
-            "post": {
-                "description": "add new user",
+            "patch": {
+                "description": "change meeting time, period or members. Only the meeting creator may change it.\nIf the time is changed, the members' responses are reset to Unknown.",
                 "consumes": [
                     "application/x-www-form-urlencoded"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "add new user",
+                "summary": "change meeting",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "User name",
-                        "name": "name",
+                        "type": "integer",
+                        "description": "Meeting ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of the user changing the meeting",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "integer"
+                        },
+                        "description": "ID list of required members to add separated with a comma (',')",
+                        "name": "add_member_ids",
+                        "in": "path"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "integer"
+                        },
+                        "description": "ID list of optional members to add separated with a comma (','). Members already in the meeting change their role.",
+                        "name": "add_optional_member_ids",
+                        "in": "path"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "integer"
+                        },
+                        "description": "ID list of members to remove separated with a comma (',')",
+                        "name": "remove_member_ids",
+                        "in": "path"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Meeting start time in RFC3339",
+                        "name": "start_at",
+                        "in": "path"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Meeting duration in format '1h2m3s'. Any of values may be ommited.",
+                        "name": "duration",
+                        "in": "path"
+                    },
+                    {
+                        "enum": [
+                            "lib.Period"
+                        ],
+                        "type": "string",
+                        "description": "string enums",
+                        "name": "period",
+                        "in": "path"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Recurrence rule in RFC 5545 RRULE format. Period must be omitted or Custom.",
+                        "name": "rrule",
+                        "in": "path"
+                    },
+                    {
+                        "type": "string",
+                        "description": "IANA time zone the meeting repeats in",
+                        "name": "time_zone",
+                        "in": "path"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "integer"
+                        },
+                        "description": "ID list of the resources to book separated with a comma (',')",
+                        "name": "add_resource_ids",
+                        "in": "path"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "integer"
+                        },
+                        "description": "ID list of the resources to release separated with a comma (',')",
+                        "name": "remove_resource_ids",
+                        "in": "path"
+                    },
+                    {
+                        "enum": [
+                            "allow",
+                            "warn",
+                            "reject"
+                        ],
+                        "type": "string",
+                        "description": "Overlapping meetings of the members are not checked (default), reported or make the change rejected",
+                        "name": "conflict_policy",
+                        "in": "path"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Changed meeting and the conflicts if the policy is warn",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Meeting"
+                        }
+                    },
+                    "400": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflicts if the policy is reject or a resource is booked",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/meetings/{meeting_id}/participants/{user_id}/response": {
+            "put": {
+                "description": "send presence responce",
+                "consumes": [
+                    "application/x-www-form-urlencoded",
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "send presence response",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Meeting ID",
+                        "name": "meeting_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "lib.Presence"
+                        ],
+                        "type": "string",
+                        "description": "string enums",
+                        "name": "presence",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Response in the JSON body instead of the parameters",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/lib.PresenceRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Meeting ID",
+                        "schema": {
+                            "type": "integer"
+                        }
+                    },
+                    "204": {
+                        "description": "empty, the versioned API answers with No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users": {
+            "get": {
+                "description": "returns user information for given id or list with information about the users.\nThe list is sorted and may be cut into pages, the Link header refers to the next page.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "get user information",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path"
+                    },
+                    {
+                        "type": "string",
+                        "description": "List the users which name contains the value ignoring the case",
+                        "name": "name",
+                        "in": "path"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "List the deactivated or the active users only",
+                        "name": "deactivated",
+                        "in": "path"
+                    },
+                    {
+                        "enum": [
+                            "id",
+                            "name"
+                        ],
+                        "type": "string",
+                        "description": "List order, by id by default. The users of the same name are ordered by id.",
+                        "name": "sort",
+                        "in": "path"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page length, all the users by default. Up to 1000.",
+                        "name": "limit",
+                        "in": "path"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Position the page starts after, given in the Link header of the previous page",
+                        "name": "cursor",
+                        "in": "path"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User information",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/lib.User"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "add new user",
+                "consumes": [
+                    "application/x-www-form-urlencoded",
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "add new user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User e-mail",
+                        "name": "email",
+                        "in": "path"
+                    },
+                    {
+                        "description": "User in the JSON body instead of the parameters",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/lib.UserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User ID",
+                        "schema": {
+                            "type": "integer"
+                        }
+                    },
+                    "201": {
+                        "description": "User ID, the versioned API answers with the user Location",
+                        "schema": {
+                            "type": "integer"
+                        }
+                    },
+                    "400": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/{id}": {
+            "get": {
+                "description": "returns user information for given id or list with information about the users.\nThe list is sorted and may be cut into pages, the Link header refers to the next page.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "get user information",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path"
+                    },
+                    {
+                        "type": "string",
+                        "description": "List the users which name contains the value ignoring the case",
+                        "name": "name",
+                        "in": "path"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "List the deactivated or the active users only",
+                        "name": "deactivated",
+                        "in": "path"
+                    },
+                    {
+                        "enum": [
+                            "id",
+                            "name"
+                        ],
+                        "type": "string",
+                        "description": "List order, by id by default. The users of the same name are ordered by id.",
+                        "name": "sort",
+                        "in": "path"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page length, all the users by default. Up to 1000.",
+                        "name": "limit",
+                        "in": "path"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Position the page starts after, given in the Link header of the previous page",
+                        "name": "cursor",
+                        "in": "path"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User information",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/lib.User"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "deactivate or delete the user. The user is removed from all meetings.\nMeetings created by the user are either passed to another member or cancelled.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "remove user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "'delete' (default) removes the user, 'deactivate' keeps the record",
+                        "name": "mode",
+                        "in": "path"
+                    },
+                    {
+                        "type": "string",
+                        "description": "'reassign' (default) passes meetings created by the user to another member, 'cancel' cancels them",
+                        "name": "created_meetings",
+                        "in": "path"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of the user to pass the meetings to. If not specified, the first remaining member is used.",
+                        "name": "reassign_to",
+                        "in": "path"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "empty",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "204": {
+                        "description": "empty, the versioned API answers with No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "change user profile",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "change user information",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User name",
+                        "name": "name",
+                        "in": "path"
+                    },
+                    {
+                        "type": "string",
+                        "description": "User e-mail",
+                        "name": "email",
+                        "in": "path"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Changed user information",
+                        "schema": {
+                            "$ref": "#/definitions/lib.User"
+                        }
+                    },
+                    "400": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/{id}/meetings": {
+            "get": {
+                "description": "get user meetings for specified period",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "get user meetings for specified period",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Search period start time in RFC3339",
+                        "name": "start_at",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Search period duration in format '1h2m3s'. Any of values may be ommited.",
+                        "name": "duration",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Return the list of lib.Cancellation for meetings cancelled in the period instead",
+                        "name": "cancelled",
+                        "in": "path"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Meeting ID",
+                        "schema": {
+                            "type": "integer"
+                        }
+                    },
+                    "400": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/calendar.ics": {
+            "get": {
+                "description": "renders meetings of the user in iCalendar format (RFC 5545). Every meeting is a VEVENT with a stable UID,\nits moved occurrences are the VEVENTs with RECURRENCE-ID and the cancelled meetings have CANCELLED status.\nThe feed is not changed until the meetings are, so it can be polled with If-None-Match.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "text/calendar"
+                ],
+                "summary": "get user calendar feed",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "iCalendar object",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "304": {
+                        "description": "empty",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "creates meetings from the events of the iCalendar object (RFC 5545) given in the body.\nAttendees and the organizer are matched to the users by name or email, rooms and resources to the resources by name.\nSimple RRULEs become the meeting period, the others the Custom rule. EXDATEs and the moved occurrences are kept.\nThe events which can't be represented, e.g. all-day ones or the ones with RDATE, are skipped and reported.",
+                "consumes": [
+                    "text/calendar"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "import calendar",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of the user creating the meetings which organizer doesn't match any user",
+                        "name": "creator_id",
+                        "in": "path"
+                    },
+                    {
+                        "description": "iCalendar object",
+                        "name": "calendar",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Imported and skipped events",
+                        "schema": {
+                            "$ref": "#/definitions/lib.ImportResult"
+                        }
+                    },
+                    "400": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/find_free_time": {
+            "get": {
+                "description": "get the closest free time for all required users and the specified period.\nMeetings rejected by the user don't block the user time.\nIf limit, optional_ids or a resource requirement is given, returns the list of up to limit (1 by default)\nnon-overlapping lib.Slot ordered by rank instead. Every slot lists the optional users who can't make it.\nIf resource_capacity or resource_attributes is given, every slot has a suitable free resource,\nthe smallest suitable one is suggested.\nThe score rank prefers earlier slots, slots without adjacent meetings and slots starting at round time.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "find closest free time",
+                "parameters": [
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "integer"
+                        },
+                        "description": "Required user ID list separated with a comma (',')",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "integer"
+                        },
+                        "description": "Optional user ID list separated with a comma (',')",
+                        "name": "optional_ids",
+                        "in": "path"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Minimum number of optional users who must be free, 0 by default",
+                        "name": "quorum",
+                        "in": "path"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "integer"
+                        },
+                        "description": "ID list of the resources which must be free separated with a comma (',')",
+                        "name": "resource_ids",
+                        "in": "path"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Minimum capacity of the suitable resource. The number of the users by default.",
+                        "name": "resource_capacity",
+                        "in": "path"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "description": "Attributes the suitable resource must have separated with a comma (','), e.g. 'room,projector'",
+                        "name": "resource_attributes",
+                        "in": "path"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Search period start time in RFC3339. If not specified, the app uses now.",
+                        "name": "start_at",
+                        "in": "path"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Search period duration in format '1h2m3s'. Any of values may be ommited.",
+                        "name": "duration",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Search period end time in RFC3339, at most 366 days after the start. A year after the start by default.",
+                        "name": "end_at",
+                        "in": "path"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Search period length in format '1h2m3s' instead of end_at, at most 366 days",
+                        "name": "horizon",
+                        "in": "path"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Allowed week days separated with a comma, e.g. 'MO,TU,WE,TH,FR'",
+                        "name": "weekdays",
+                        "in": "path"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Allowed periods of the day separated with a comma, e.g. '09:00-12:00,13:00-17:00'",
+                        "name": "window",
+                        "in": "path"
+                    },
+                    {
+                        "type": "string",
+                        "description": "IANA time zone of the week days, the day periods and the granularity. UTC by default.",
+                        "name": "time_zone",
+                        "in": "path"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Slots start at multiples of it from the midnight, e.g. '15m' or '30m'",
+                        "name": "granularity",
+                        "in": "path"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of slots to return",
+                        "name": "limit",
+                        "in": "path"
+                    },
+                    {
+                        "enum": [
+                            "earliest",
+                            "score"
+                        ],
+                        "type": "string",
+                        "description": "Slot ranking: earliest (default) or score",
+                        "name": "rank",
+                        "in": "path"
+                    },
+                    {
+                        "enum": [
+                            "busy",
+                            "tentative"
+                        ],
+                        "type": "string",
+                        "description": "Meetings without response are busy (default) or tentative",
+                        "name": "unknown_presence",
+                        "in": "path"
+                    },
+                    {
+                        "enum": [
+                            "busy",
+                            "free"
+                        ],
+                        "type": "string",
+                        "description": "Tentative meetings are busy (default) or free",
+                        "name": "tentative",
+                        "in": "path"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Free slots. Only the start time of the closest one in RFC3339 if none of limit, optional_ids and resource requirements is given.",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/lib.Slot"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/freebusy": {
+            "get": {
+                "description": "returns merged busy periods of every user in the period without the meeting details.\nRecurring meetings are expanded, rejected meetings are skipped.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "get busy time of users",
+                "parameters": [
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "integer"
+                        },
+                        "description": "User ID list separated with a comma (',')",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Period start time in RFC3339",
+                        "name": "start_at",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Period end time in RFC3339, at most 366 days after the start",
+                        "name": "end_at",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "busy",
+                            "tentative"
+                        ],
+                        "type": "string",
+                        "description": "Meetings without response are busy (default) or tentative",
+                        "name": "unknown_presence",
+                        "in": "path"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Busy periods of the users",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/lib.FreeBusy"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/meeting": {
+            "get": {
+                "description": "get meeting for given id or list of the meetings.\nThe list is sorted and may be cut into pages, the Link header refers to the next page.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "get meetings",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Meeting ID",
+                        "name": "id",
+                        "in": "path"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "List the meetings created by the user",
+                        "name": "creator_id",
+                        "in": "path"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "List the meetings the user is a member of",
+                        "name": "member_id",
+                        "in": "path"
+                    },
+                    {
+                        "enum": [
+                            "lib.Period"
+                        ],
+                        "type": "string",
+                        "description": "List the meetings repeating with the period",
+                        "name": "period",
+                        "in": "path"
+                    },
+                    {
+                        "type": "string",
+                        "description": "List the meetings which occurrences end after the time in RFC3339",
+                        "name": "start_at",
+                        "in": "path"
+                    },
+                    {
+                        "type": "string",
+                        "description": "List the meetings which occurrences start before the time in RFC3339",
+                        "name": "end_at",
+                        "in": "path"
+                    },
+                    {
+                        "enum": [
+                            "id",
+                            "start_at"
+                        ],
+                        "type": "string",
+                        "description": "List order, by id by default. The meetings of the same first occurrence time are ordered by id.",
+                        "name": "sort",
+                        "in": "path"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page length, all the meetings by default. Up to 1000.",
+                        "name": "limit",
+                        "in": "path"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Position the page starts after, given in the Link header of the previous page",
+                        "name": "cursor",
+                        "in": "path"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Meeting information",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Meeting"
+                        }
+                    },
+                    "400": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "add new meeting",
+                "consumes": [
+                    "application/x-www-form-urlencoded",
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "add new meeting",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Organizator ID",
+                        "name": "creator_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "integer"
+                        },
+                        "description": "Member ID list separated with a comma (',')",
+                        "name": "member_ids",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "integer"
+                        },
+                        "description": "ID list of optional members separated with a comma (',')",
+                        "name": "optional_member_ids",
+                        "in": "path"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Meeting start time in RFC3339",
+                        "name": "start_at",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Meeting duration in format '1h2m3s'. Any of values may be ommited.",
+                        "name": "duration",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "lib.Period"
+                        ],
+                        "type": "string",
+                        "description": "string enums",
+                        "name": "period",
+                        "in": "path"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Recurrence rule in RFC 5545 RRULE format, e.g. 'FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH'. Period must be omitted or Custom.",
+                        "name": "rrule",
+                        "in": "path"
+                    },
+                    {
+                        "type": "string",
+                        "description": "IANA time zone, e.g. 'Europe/Berlin'. The meeting repeats at the same local time in it. UTC by default.",
+                        "name": "time_zone",
+                        "in": "path"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "integer"
+                        },
+                        "description": "ID list of the resources to book separated with a comma (',')",
+                        "name": "resource_ids",
+                        "in": "path"
+                    },
+                    {
+                        "enum": [
+                            "allow",
+                            "warn",
+                            "reject"
+                        ],
+                        "type": "string",
+                        "description": "Overlapping meetings of the members are not checked (default), reported or make the meeting rejected",
+                        "name": "conflict_policy",
+                        "in": "path"
+                    },
+                    {
+                        "description": "Meeting in the JSON body instead of the parameters. Members have roles, their status can't be set.",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/lib.MeetingRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Meeting ID and the conflicts if the policy is warn",
+                        "schema": {
+                            "type": "integer"
+                        }
+                    },
+                    "201": {
+                        "description": "Meeting ID, the versioned API answers with the meeting Location",
+                        "schema": {
+                            "type": "integer"
+                        }
+                    },
+                    "400": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflicts if the policy is reject or a resource is booked",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "remove the meeting and notify its members. Only the meeting creator may cancel it.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "cancel meeting",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Meeting ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of the user cancelling the meeting",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "empty",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "204": {
+                        "description": "empty, the versioned API answers with No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "change meeting time, period or members. Only the meeting creator may change it.\nIf the time is changed, the members' responses are reset to Unknown.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "change meeting",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Meeting ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of the user changing the meeting",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "integer"
+                        },
+                        "description": "ID list of required members to add separated with a comma (',')",
+                        "name": "add_member_ids",
+                        "in": "path"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "integer"
+                        },
+                        "description": "ID list of optional members to add separated with a comma (','). Members already in the meeting change their role.",
+                        "name": "add_optional_member_ids",
+                        "in": "path"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "integer"
+                        },
+                        "description": "ID list of members to remove separated with a comma (',')",
+                        "name": "remove_member_ids",
+                        "in": "path"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Meeting start time in RFC3339",
+                        "name": "start_at",
+                        "in": "path"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Meeting duration in format '1h2m3s'. Any of values may be ommited.",
+                        "name": "duration",
+                        "in": "path"
+                    },
+                    {
+                        "enum": [
+                            "lib.Period"
+                        ],
+                        "type": "string",
+                        "description": "string enums",
+                        "name": "period",
+                        "in": "path"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Recurrence rule in RFC 5545 RRULE format. Period must be omitted or Custom.",
+                        "name": "rrule",
+                        "in": "path"
+                    },
+                    {
+                        "type": "string",
+                        "description": "IANA time zone the meeting repeats in",
+                        "name": "time_zone",
+                        "in": "path"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "integer"
+                        },
+                        "description": "ID list of the resources to book separated with a comma (',')",
+                        "name": "add_resource_ids",
+                        "in": "path"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "integer"
+                        },
+                        "description": "ID list of the resources to release separated with a comma (',')",
+                        "name": "remove_resource_ids",
+                        "in": "path"
+                    },
+                    {
+                        "enum": [
+                            "allow",
+                            "warn",
+                            "reject"
+                        ],
+                        "type": "string",
+                        "description": "Overlapping meetings of the members are not checked (default), reported or make the change rejected",
+                        "name": "conflict_policy",
+                        "in": "path"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Changed meeting and the conflicts if the policy is warn",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Meeting"
+                        }
+                    },
+                    "400": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflicts if the policy is reject or a resource is booked",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/meeting_exception": {
+            "put": {
+                "description": "cancel or move a single occurrence of the recurring meeting. Only the meeting creator may change it.\nChanging the series start time or recurrence rule drops all the exceptions.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "change single occurrence",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Meeting ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of the user changing the meeting",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Original start time of the occurrence in RFC3339",
+                        "name": "occurrence",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Cancel the occurrence",
+                        "name": "cancelled",
+                        "in": "path"
+                    },
+                    {
+                        "type": "string",
+                        "description": "New start time of the occurrence in RFC3339",
+                        "name": "start_at",
+                        "in": "path"
+                    },
+                    {
+                        "type": "string",
+                        "description": "New duration of the occurrence in format '1h2m3s'. Any of values may be ommited.",
+                        "name": "duration",
+                        "in": "path"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Changed meeting",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Meeting"
+                        }
+                    },
+                    "400": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "remove the cancellation or the change of the single occurrence. Only the meeting creator may restore it.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "restore single occurrence",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Meeting ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of the user changing the meeting",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Original start time of the occurrence in RFC3339",
+                        "name": "occurrence",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Changed meeting",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Meeting"
+                        }
+                    },
+                    "400": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/occurrences": {
+            "get": {
+                "description": "expands every meeting of the user into occurrences overlapping the period sorted by start time",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "get user meeting occurrences for specified period",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Period start time in RFC3339",
+                        "name": "start_at",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Period end time in RFC3339, at most 366 days after the start",
+                        "name": "end_at",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Occurrences",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/lib.Occurrence"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/resource": {
+            "get": {
+                "description": "returns the room or the equipment for given id or list of all resources ordered by id",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "get resource information",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Resource ID",
+                        "name": "id",
+                        "in": "path"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Resource information",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/lib.Resource"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "change name, capacity or attributes of the resource. The meetings already booked are not checked.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "change resource information",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Resource ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Resource name",
+                        "name": "name",
+                        "in": "path"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of people the resource holds, 0 if it is not limited",
+                        "name": "capacity",
+                        "in": "path"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "description": "Features of the resource separated with a comma (','). Replaces the current ones, empty value removes them.",
+                        "name": "attributes",
+                        "in": "path"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Changed resource information",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Resource"
+                        }
+                    },
+                    "400": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "add a room or a piece of equipment which can be booked for meetings",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "add new resource",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Resource name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of people the resource holds, not limited by default",
+                        "name": "capacity",
+                        "in": "path"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "description": "Features of the resource separated with a comma (','), e.g. 'room,projector'",
+                        "name": "attributes",
+                        "in": "path"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Resource ID",
+                        "schema": {
+                            "type": "integer"
+                        }
+                    },
+                    "400": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "remove the resource. The resource booked by a meeting can't be removed.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "remove resource",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Resource ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "empty",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/response": {
+            "put": {
+                "description": "send presence responce",
+                "consumes": [
+                    "application/x-www-form-urlencoded",
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "send presence response",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Meeting ID",
+                        "name": "meeting_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "lib.Presence"
+                        ],
+                        "type": "string",
+                        "description": "string enums",
+                        "name": "presence",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Response in the JSON body instead of the parameters",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/lib.PresenceRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Meeting ID",
+                        "schema": {
+                            "type": "integer"
+                        }
+                    },
+                    "204": {
+                        "description": "empty, the versioned API answers with No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/token": {
+            "post": {
+                "description": "issue new access token of the user replacing the previous one. The token is passed in the 'Authorization: Bearer' header.\nOnly the user or the admin may issue it.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "issue access token",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Access token",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "revoke access token of the user. Only the user or the admin may revoke it.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "revoke access token",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "empty",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/user": {
+            "get": {
+                "description": "returns user information for given id or list with information about the users.\nThe list is sorted and may be cut into pages, the Link header refers to the next page.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "get user information",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path"
+                    },
+                    {
+                        "type": "string",
+                        "description": "List the users which name contains the value ignoring the case",
+                        "name": "name",
+                        "in": "path"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "List the deactivated or the active users only",
+                        "name": "deactivated",
+                        "in": "path"
+                    },
+                    {
+                        "enum": [
+                            "id",
+                            "name"
+                        ],
+                        "type": "string",
+                        "description": "List order, by id by default. The users of the same name are ordered by id.",
+                        "name": "sort",
+                        "in": "path"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page length, all the users by default. Up to 1000.",
+                        "name": "limit",
+                        "in": "path"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Position the page starts after, given in the Link header of the previous page",
+                        "name": "cursor",
+                        "in": "path"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User information",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/lib.User"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "change user profile",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "change user information",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User name",
+                        "name": "name",
+                        "in": "path"
+                    },
+                    {
+                        "type": "string",
+                        "description": "User e-mail",
+                        "name": "email",
+                        "in": "path"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Changed user information",
+                        "schema": {
+                            "$ref": "#/definitions/lib.User"
+                        }
+                    },
+                    "400": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "add new user",
+                "consumes": [
+                    "application/x-www-form-urlencoded",
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "add new user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User e-mail",
+                        "name": "email",
+                        "in": "path"
+                    },
+                    {
+                        "description": "User in the JSON body instead of the parameters",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/lib.UserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User ID",
+                        "schema": {
+                            "type": "integer"
+                        }
+                    },
+                    "201": {
+                        "description": "User ID, the versioned API answers with the user Location",
+                        "schema": {
+                            "type": "integer"
+                        }
+                    },
+                    "400": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "deactivate or delete the user. The user is removed from all meetings.\nMeetings created by the user are either passed to another member or cancelled.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "remove user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "'delete' (default) removes the user, 'deactivate' keeps the record",
+                        "name": "mode",
+                        "in": "path"
+                    },
+                    {
+                        "type": "string",
+                        "description": "'reassign' (default) passes meetings created by the user to another member, 'cancel' cancels them",
+                        "name": "created_meetings",
+                        "in": "path"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of the user to pass the meetings to. If not specified, the first remaining member is used.",
+                        "name": "reassign_to",
+                        "in": "path"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "empty",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "204": {
+                        "description": "empty, the versioned API answers with No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/user_meetings": {
+            "get": {
+                "description": "get user meetings for specified period",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "get user meetings for specified period",
+                "parameters": [
+                    {
+                        "type": "integer",
                         "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Search period start time in RFC3339",
+                        "name": "start_at",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Search period duration in format '1h2m3s'. Any of values may be ommited.",
+                        "name": "duration",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Return the list of lib.Cancellation for meetings cancelled in the period instead",
+                        "name": "cancelled",
+                        "in": "path"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Meeting ID",
                         "schema": {
                             "type": "integer"
                         }
                     },
                     "400": {
-                        "description": "empty",
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/working_hours": {
+            "put": {
+                "description": "replace working hours of the user. The free time search proposes slots in working hours of every user only.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "set working hours",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "IANA time zone of the working hours. UTC by default.",
+                        "name": "time_zone",
+                        "in": "path"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "description": "Working hours of the week days, e.g. 'MO,TU,WE,TH,FR 09:00-12:00,13:00-17:00'",
+                        "name": "weekly",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "description": "Working hours of the date replacing the weekly ones, e.g. '2023-12-24 09:00-12:00' or '2023-12-25' for a day off",
+                        "name": "exception",
+                        "in": "path"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Changed user information",
+                        "schema": {
+                            "$ref": "#/definitions/lib.User"
+                        }
+                    },
+                    "400": {
+                        "description": "error description",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/lib.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "error description",
+                        "schema": {
+                            "$ref": "#/definitions/lib.Problem"
                         }
                     },
                     "500": {
-                        "description": "empty",
+                        "description": "error description",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/lib.Problem"
                         }
                     }
                 }
-            }
-        },
-        "/user_meetings": {
-            "get": {
-                "description": "get user meetings for specified period",
+            },
+            "delete": {
+                "description": "remove working hours of the user, so the user may take part in meetings any time",
                 "consumes": [
                     "application/x-www-form-urlencoded"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "get user meetings for specified period",
+                "summary": "remove working hours",
                 "parameters": [
                     {
                         "type": "integer",
@@ -372,45 +2982,31 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Search period start time in RFC3339",
-                        "name": "start_at",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Search period duration in format '1h2m3s'. Any of values may be ommited.",
-                        "name": "duration",
-                        "in": "path",
-                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Meeting ID",
+                        "description": "Changed user information",
                         "schema": {
-                            "type": "integer"
+                            "$ref": "#/definitions/lib.User"
                         }
                     },
                     "400": {
-                        "description": "empty",
+                        "description": "error description",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/lib.Problem"
                         }
                     },
                     "404": {
-                        "description": "empty",
+                        "description": "error description",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/lib.Problem"
                         }
                     },
                     "500": {
-                        "description": "empty",
+                        "description": "error description",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/lib.Problem"
                         }
                     }
                 }
@@ -418,6 +3014,39 @@ const docTemplate = `{
         }
     },
     "definitions": {
+        "lib.BusyInterval": {
+            "type": "object",
+            "properties": {
+                "endAt": {
+                    "type": "string"
+                },
+                "startAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "lib.Conflict": {
+            "type": "object",
+            "properties": {
+                "endAt": {
+                    "type": "string"
+                },
+                "meetingId": {
+                    "type": "integer"
+                },
+                "occurrence": {
+                    "description": "start time of the occurrence of the checked meeting",
+                    "type": "string"
+                },
+                "startAt": {
+                    "description": "the overlapping occurrence of the other meeting",
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
         "lib.Duration": {
             "type": "object",
             "properties": {
@@ -426,6 +3055,65 @@ const docTemplate = `{
                 }
             }
         },
+        "lib.FreeBusy": {
+            "type": "object",
+            "properties": {
+                "busy": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/lib.BusyInterval"
+                    }
+                },
+                "tentative": {
+                    "description": "periods of tentative meetings not covered by the busy ones",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/lib.BusyInterval"
+                    }
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "lib.ImportResult": {
+            "type": "object",
+            "properties": {
+                "imported": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/lib.ImportedEvent"
+                    }
+                },
+                "skipped": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/lib.SkippedEvent"
+                    }
+                }
+            }
+        },
+        "lib.ImportedEvent": {
+            "type": "object",
+            "properties": {
+                "meetingId": {
+                    "type": "integer"
+                },
+                "summary": {
+                    "type": "string"
+                },
+                "uid": {
+                    "type": "string"
+                },
+                "warnings": {
+                    "description": "parts of the event which are dropped, e.g. the attendees not matching any user",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
         "lib.Meeting": {
             "type": "object",
             "properties": {
@@ -438,6 +3126,13 @@ const docTemplate = `{
                 "duration": {
                     "$ref": "#/definitions/lib.Duration"
                 },
+                "exDates": {
+                    "description": "original start times of the cancelled occurrences (EXDATE)",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "firstOccurence": {
                     "type": "string"
                 },
@@ -447,14 +3142,121 @@ const docTemplate = `{
                         "$ref": "#/definitions/lib.Participant"
                     }
                 },
+                "overrides": {
+                    "description": "occurrences taking place at another time (RECURRENCE-ID)",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/lib.Override"
+                    }
+                },
+                "repeat": {
+                    "type": "integer"
+                },
+                "resources": {
+                    "description": "rooms and equipment booked for the meeting",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "rule": {
+                    "description": "recurrence rule of the Custom period",
+                    "type": "string",
+                    "example": "FREQ=WEEKLY;BYDAY=TU,TH"
+                },
+                "timeZone": {
+                    "description": "IANA time zone the recurrence is expanded in keeping the local time, UTC if empty",
+                    "type": "string",
+                    "example": "Europe/Berlin"
+                },
+                "updatedAt": {
+                    "description": "time the meeting or a response to it was last saved",
+                    "type": "string"
+                }
+            }
+        },
+        "lib.MeetingRequest": {
+            "type": "object",
+            "properties": {
+                "conflictPolicy": {
+                    "type": "string",
+                    "example": "warn"
+                },
+                "creatorId": {
+                    "type": "integer"
+                },
+                "duration": {
+                    "type": "string",
+                    "example": "1h30m"
+                },
+                "firstOccurence": {
+                    "type": "string",
+                    "example": "2023-09-11T10:00:00Z"
+                },
+                "members": {
+                    "description": "required and optional members, the responses can't be given",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/lib.Participant"
+                    }
+                },
                 "repeat": {
+                    "type": "string",
+                    "example": "EveryWeek"
+                },
+                "resources": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "rule": {
+                    "type": "string",
+                    "example": "FREQ=WEEKLY;BYDAY=TU,TH"
+                },
+                "timeZone": {
+                    "type": "string",
+                    "example": "Europe/Berlin"
+                }
+            }
+        },
+        "lib.Occurrence": {
+            "type": "object",
+            "properties": {
+                "endAt": {
+                    "type": "string"
+                },
+                "meetingId": {
+                    "type": "integer"
+                },
+                "presence": {
                     "type": "integer"
+                },
+                "startAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "lib.Override": {
+            "type": "object",
+            "properties": {
+                "duration": {
+                    "$ref": "#/definitions/lib.Duration"
+                },
+                "recurrenceId": {
+                    "type": "string"
+                },
+                "startAt": {
+                    "type": "string"
                 }
             }
         },
         "lib.Participant": {
             "type": "object",
             "properties": {
+                "role": {
+                    "type": "integer"
+                },
                 "status": {
                     "type": "integer"
                 },
@@ -463,14 +3265,173 @@ const docTemplate = `{
                 }
             }
         },
+        "lib.PresenceRequest": {
+            "type": "object",
+            "properties": {
+                "meetingId": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "Accepted"
+                },
+                "userId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "lib.Problem": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "description": "stable error code: invalid_parameter, not_found, already_exists,\nconflict, forbidden, unauthorized, not_implemented, method_not_allowed or internal_error",
+                    "type": "string",
+                    "example": "not_found"
+                },
+                "conflicts": {
+                    "description": "meetings of the members overlapping the rejected one",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/lib.Conflict"
+                    }
+                },
+                "detail": {
+                    "type": "string",
+                    "example": "user 5: does not exist"
+                },
+                "param": {
+                    "description": "the request parameter which caused the error",
+                    "type": "string",
+                    "example": "id"
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 404
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Not Found"
+                }
+            }
+        },
+        "lib.Resource": {
+            "type": "object",
+            "properties": {
+                "ResourceId": {
+                    "type": "integer"
+                },
+                "attributes": {
+                    "description": "features of the resource, e.g. \"room\" or \"projector\"",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "capacity": {
+                    "description": "number of people the resource holds, 0 if it is not limited",
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "lib.SkippedEvent": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "type": "string"
+                },
+                "summary": {
+                    "type": "string"
+                },
+                "uid": {
+                    "type": "string"
+                }
+            }
+        },
+        "lib.Slot": {
+            "type": "object",
+            "properties": {
+                "endAt": {
+                    "type": "string"
+                },
+                "resourceId": {
+                    "description": "suitable resource free in the slot if the search asks for one",
+                    "type": "integer"
+                },
+                "startAt": {
+                    "type": "string"
+                },
+                "unavailable": {
+                    "description": "optional attendees who can't make it",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
         "lib.User": {
             "type": "object",
             "properties": {
                 "UserId": {
                     "type": "integer"
                 },
+                "deactivated": {
+                    "description": "deactivated users are kept for the history but can't take part in meetings",
+                    "type": "boolean"
+                },
+                "email": {
+                    "type": "string"
+                },
                 "name": {
                     "type": "string"
+                },
+                "workingHours": {
+                    "description": "the user is available any time if there are no working hours",
+                    "$ref": "#/definitions/lib.WorkingHours"
+                }
+            }
+        },
+        "lib.UserRequest": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "john@example.com"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "John"
+                }
+            }
+        },
+        "lib.WorkingHours": {
+            "type": "object",
+            "properties": {
+                "exceptions": {
+                    "description": "hours of the specific dates replacing the weekly ones",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "2023-12-25"
+                    ]
+                },
+                "timeZone": {
+                    "type": "string",
+                    "example": "Europe/Berlin"
+                },
+                "weekly": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "MO 09:00-17:00"
+                    ]
                 }
             }
         }
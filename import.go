@@ -0,0 +1,70 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+
+	schedule "github.com/lev69/schedule/lib"
+)
+
+// runs "schedule import" command creating meetings from the iCalendar files.
+// The service must not run on the same data directory meanwhile.
+func importCommand(args []string) {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	dataDir := flags.String("data", "", "Import into the data directory of the service")
+	var creator schedule.UID
+	flags.Func("creator", "`ID` of the user creating the meetings which organizer doesn't match any user", func(v string) error {
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return err
+		}
+		creator = schedule.UID(id)
+		return nil
+	})
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s import -data <dir> [-creator <id>] <file.ics>...\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if *dataDir == "" || flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	storage, err := schedule.NewFileStorage(*dataDir)
+	if err != nil {
+		log.Fatalf("open storage %q: %v", *dataDir, err)
+	}
+	defer storage.(io.Closer).Close()
+
+	service := schedule.NewService(storage)
+	for _, name := range flags.Args() {
+		f, err := os.Open(name)
+		if err != nil {
+			log.Fatal(err)
+		}
+		res, err := service.ImportCalendar(f, creator)
+		f.Close()
+		if err != nil {
+			log.Fatalf("import %q: %v", name, err)
+		}
+		printImportResult(os.Stdout, name, res)
+	}
+}
+
+func printImportResult(w io.Writer, name string, res schedule.ImportResult) {
+	for _, e := range res.Imported {
+		fmt.Fprintf(w, "%s: %q imported as meeting %d\n", name, e.Uid, e.MeetingId)
+		for _, warning := range e.Warnings {
+			fmt.Fprintf(w, "\t%s\n", warning)
+		}
+	}
+	for _, e := range res.Skipped {
+		fmt.Fprintf(w, "%s: %q skipped: %s\n", name, e.Uid, e.Reason)
+	}
+	fmt.Fprintf(w, "%s: %d imported, %d skipped\n", name, len(res.Imported), len(res.Skipped))
+}
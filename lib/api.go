@@ -0,0 +1,156 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+const (
+	// ApiPrefix is the root of the versioned API paths
+	ApiPrefix      = "/api/v1/"
+	allowTag       = "Allow"
+	locationTag    = "Location"
+	deprecationTag = "Deprecation"
+	linkTag        = "Link"
+)
+
+// the context key marking the request to the versioned API
+type apiRequestKey struct{}
+
+type apiHandler func(s *Service, w http.ResponseWriter, r *http.Request)
+
+// apiRoute maps the resource path to the handlers of its methods.
+// The path segments in braces are passed to the handlers
+// as the request parameters of the same name.
+type apiRoute struct {
+	path     string
+	handlers map[string]apiHandler
+}
+
+var apiRoutes = []apiRoute{
+	{"users", map[string]apiHandler{
+		http.MethodGet:  (*Service).userGetHandler,
+		http.MethodPost: (*Service).userPostHandler,
+	}},
+	{"users/{id}", map[string]apiHandler{
+		http.MethodGet:    (*Service).userGetHandler,
+		http.MethodPatch:  (*Service).userPutHandler,
+		http.MethodDelete: (*Service).userDeleteHandler,
+	}},
+	{"users/{id}/meetings", map[string]apiHandler{
+		http.MethodGet: (*Service).userMeetingsGetHandler,
+	}},
+	{"meetings", map[string]apiHandler{
+		http.MethodGet:  (*Service).meetingGetHandler,
+		http.MethodPost: (*Service).meetingPostHandler,
+	}},
+	{"meetings/{id}", map[string]apiHandler{
+		http.MethodGet:    (*Service).meetingGetHandler,
+		http.MethodPatch:  (*Service).meetingPatchHandler,
+		http.MethodDelete: (*Service).meetingDeleteHandler,
+	}},
+	{"meetings/{meeting_id}/participants/{user_id}/response", map[string]apiHandler{
+		http.MethodPut: (*Service).responsePutHandler,
+	}},
+}
+
+// general handler for /api/v1/ tree. The resources are served by the handlers
+// of the deprecated paths, the successful requests which have no response body
+// are answered with 204 No Content and the created resources with 201 Created.
+func (s *Service) ApiHandler(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, ApiPrefix), "/")
+	for _, route := range apiRoutes {
+		values, ok := route.match(path)
+		if !ok {
+			continue
+		}
+		handler, ok := route.handlers[r.Method]
+		if !ok {
+			w.Header().Set(allowTag, route.allow())
+			writeError(w, r, fmt.Errorf("%s %s: %w", r.Method, r.URL.Path, errMethodNotAllowed))
+			return
+		}
+
+		query := r.URL.Query()
+		for name, value := range values {
+			query.Set(name, value)
+		}
+		r = r.Clone(context.WithValue(r.Context(), apiRequestKey{}, true))
+		r.URL.RawQuery = query.Encode()
+
+		aw := &apiWriter{ResponseWriter: w}
+		handler(s, aw, r)
+		if !aw.written {
+			w.WriteHeader(http.StatusNoContent)
+		}
+		return
+	}
+	writeError(w, r, fmt.Errorf("%s: %w", r.URL.Path, ErrNotExist))
+}
+
+// returns the values of the path parameters if the path matches the route
+func (route apiRoute) match(path string) (map[string]string, bool) {
+	segments := strings.Split(path, "/")
+	pattern := strings.Split(route.path, "/")
+	if len(segments) != len(pattern) {
+		return nil, false
+	}
+	values := make(map[string]string)
+	for i, p := range pattern {
+		if strings.HasPrefix(p, "{") && strings.HasSuffix(p, "}") && segments[i] != "" {
+			values[p[1:len(p)-1]] = segments[i]
+		} else if p != segments[i] {
+			return nil, false
+		}
+	}
+	return values, true
+}
+
+// returns the value of Allow header listing the methods of the route
+func (route apiRoute) allow() string {
+	methods := make([]string, 0, len(route.handlers))
+	for m := range route.handlers {
+		methods = append(methods, m)
+	}
+	sort.Strings(methods)
+	return strings.Join(methods, ", ")
+}
+
+// apiWriter tells whether the handler has written the response
+type apiWriter struct {
+	http.ResponseWriter
+	written bool
+}
+
+func (w *apiWriter) WriteHeader(code int) {
+	w.written = true
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *apiWriter) Write(b []byte) (int, error) {
+	w.written = true
+	return w.ResponseWriter.Write(b)
+}
+
+// answers 201 Created with the location of the resource created by the API request.
+// The deprecated paths keep answering 200 OK.
+func writeCreated(w http.ResponseWriter, r *http.Request, path string) {
+	if r.Context().Value(apiRequestKey{}) == nil {
+		return
+	}
+	w.Header().Set(locationTag, ApiPrefix+path)
+	w.WriteHeader(http.StatusCreated)
+}
+
+// Deprecated wraps the handler of the path replaced by the versioned API
+// to point the clients to the successor path.
+func Deprecated(successor string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(deprecationTag, "true")
+		w.Header().Set(linkTag, fmt.Sprintf("<%s>; rel=\"successor-version\"", successor))
+		next(w, r)
+	}
+}
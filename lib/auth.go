@@ -0,0 +1,231 @@
+package lib
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	authorizationTag = "Authorization"
+	authenticateTag  = "WWW-Authenticate"
+	bearerScheme     = "Bearer"
+	accessTokenTag   = "access_token"
+	tokenBytes       = 32
+)
+
+// caller is the authenticated client of the request
+type caller struct {
+	admin bool
+	user  UID
+}
+
+// the context key of the request caller
+type callerKey struct{}
+
+// EnableAuth makes the service require the bearer token in every request.
+// The admin token grants all permissions, the users get their tokens from POST /token.
+func (s *Service) EnableAuth(adminToken string) {
+	s.adminHash = hashToken(adminToken)
+}
+
+// Authenticate wraps the handler to look up the caller by the bearer token.
+// The token is taken from the Authorization header or, for the clients
+// which can't set it like calendar apps, from the access_token query parameter.
+// While the authentication is not enabled the requests are not checked.
+func (s *Service) Authenticate(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.adminHash == "" {
+			next(w, r)
+			return
+		}
+		r, token := bearerToken(r)
+		c, err := s.authenticate(token)
+		if err != nil {
+			if errors.Is(err, ErrUnauthorized) {
+				w.Header().Set(authenticateTag, bearerScheme)
+			}
+			writeError(w, r, err)
+			return
+		}
+		next(w, r.WithContext(context.WithValue(r.Context(), callerKey{}, c)))
+	}
+}
+
+// returns the bearer token of the request and the request without the token query parameter
+func bearerToken(r *http.Request) (*http.Request, string) {
+	if header := r.Header.Get(authorizationTag); header != "" {
+		scheme, token, _ := strings.Cut(header, " ")
+		if !strings.EqualFold(scheme, bearerScheme) {
+			return r, ""
+		}
+		return r, strings.TrimSpace(token)
+	}
+	query := r.URL.Query()
+	token := query.Get(accessTokenTag)
+	if token == "" {
+		return r, ""
+	}
+	query.Del(accessTokenTag)
+	r = r.Clone(r.Context())
+	r.URL.RawQuery = query.Encode()
+	return r, token
+}
+
+func (s *Service) authenticate(token string) (caller, error) {
+	if token == "" {
+		return caller{}, fmt.Errorf("bearer token required: %w", ErrUnauthorized)
+	}
+	hash := hashToken(token)
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(s.adminHash)) == 1 {
+		return caller{admin: true}, nil
+	}
+	usr, err := s.storage.UserFindByToken(hash)
+	if errors.Is(err, ErrNotExist) || err == nil && usr.Deactivated {
+		return caller{}, fmt.Errorf("invalid token: %w", ErrUnauthorized)
+	}
+	if err != nil {
+		return caller{}, err
+	}
+	return caller{user: usr.Id}, nil
+}
+
+// returns ErrForbidden of the parameter unless the request is made by the user with given id or the admin
+func (s *Service) authorize(r *http.Request, param string, id UID) error {
+	if s.adminHash == "" {
+		return nil
+	}
+	c, _ := r.Context().Value(callerKey{}).(caller)
+	if c.admin || c.user != 0 && c.user == id {
+		return nil
+	}
+	return &ParamError{Param: param, Err: fmt.Errorf("user %d can't act on behalf of user %d: %w", c.user, id, ErrForbidden)}
+}
+
+// returns ErrForbidden unless the request is made by the admin
+func (s *Service) authorizeAdmin(r *http.Request) error {
+	if s.adminHash == "" {
+		return nil
+	}
+	if c, _ := r.Context().Value(callerKey{}).(caller); c.admin {
+		return nil
+	}
+	return fmt.Errorf("admin token required: %w", ErrForbidden)
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func newToken() (string, error) {
+	b := make([]byte, tokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// general handler for /token path
+func (s *Service) TokenHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		s.tokenPostHandler(w, r)
+	case http.MethodDelete:
+		s.tokenDeleteHandler(w, r)
+	default:
+		writeError(w, r, fmt.Errorf("%s: %w", r.Method, errNotImplemented))
+	}
+}
+
+// @Summary     issue access token
+// @Description issue new access token of the user replacing the previous one. The token is passed in the 'Authorization: Bearer' header.
+// @Description Only the user or the admin may issue it.
+// @Accept      application/x-www-form-urlencoded
+// @Produce     application/json
+// @Param       user_id path     uint32      true "User ID"
+// @Success     200     {object} string      "Access token"
+// @Failure     400     {object} lib.Problem "error description"
+// @Failure     401     {object} lib.Problem "error description"
+// @Failure     403     {object} lib.Problem "error description"
+// @Failure     404     {object} lib.Problem "error description"
+// @Failure     500     {object} lib.Problem "error description"
+// @Router      /token [post]
+func (s *Service) tokenPostHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.tokenUser(w, r)
+	if !ok {
+		return
+	}
+	if _, err := s.findActiveUser(id); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	token, err := newToken()
+	if err != nil {
+		writeError(w, r, fmt.Errorf("generate token: %w", err))
+		return
+	}
+	if err := s.storage.UserSetToken(id, hashToken(token)); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(struct{ Token string }{token})
+}
+
+// @Summary     revoke access token
+// @Description revoke access token of the user. Only the user or the admin may revoke it.
+// @Accept      application/x-www-form-urlencoded
+// @Produce     application/json
+// @Param       user_id path     uint32      true "User ID"
+// @Success     200     {string} string      "empty"
+// @Failure     400     {object} lib.Problem "error description"
+// @Failure     401     {object} lib.Problem "error description"
+// @Failure     403     {object} lib.Problem "error description"
+// @Failure     404     {object} lib.Problem "error description"
+// @Failure     500     {object} lib.Problem "error description"
+// @Router      /token [delete]
+func (s *Service) tokenDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.tokenUser(w, r)
+	if !ok {
+		return
+	}
+	if err := s.storage.UserSetToken(id, ""); err != nil {
+		writeError(w, r, err)
+		return
+	}
+}
+
+// returns the user whose token is changed by the request.
+// Writes the error response and returns false on failure.
+func (s *Service) tokenUser(w http.ResponseWriter, r *http.Request) (UID, bool) {
+	if err := parseForm(r); err != nil {
+		writeError(w, r, err)
+		return 0, false
+	}
+	params := parameters{userIdTag: singleValue | parameterRequired}
+	if err := checkArgs(&r.Form, params); err != nil {
+		writeError(w, r, err)
+		return 0, false
+	}
+
+	id, err := strconv.ParseUint(r.FormValue(userIdTag), 10, 32)
+	if err != nil {
+		writeError(w, r, paramError(userIdTag, err))
+		return 0, false
+	}
+	if err := s.authorize(r, userIdTag, UID(id)); err != nil {
+		writeError(w, r, err)
+		return 0, false
+	}
+	return UID(id), true
+}
@@ -0,0 +1,373 @@
+package lib
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	mimeCalendar    = "text/calendar; charset=utf-8"
+	etagTag         = "ETag"
+	ifNoneMatchTag  = "If-None-Match"
+	calendarProdId  = "-//lev69//schedule//EN"
+	icsDateTime     = "20060102T150405"
+	icsUtcDateTime  = "20060102T150405Z"
+	icsMaxLineBytes = 75
+	// VTIMEZONE lists the offset changes up to this number of years after the current one
+	zoneYearsAhead = 2
+)
+
+var presenceToPartStat = map[Presence]string{
+	Unknown:   "NEEDS-ACTION",
+	Accepted:  "ACCEPTED",
+	Rejected:  "DECLINED",
+	Tentative: "TENTATIVE",
+}
+
+var roleToIcs = map[Role]string{
+	Required: "REQ-PARTICIPANT",
+	Optional: "OPT-PARTICIPANT",
+}
+
+// general handler for /calendar.ics path
+func (s *Service) CalendarHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.calendarGetHandler(w, r)
+	case http.MethodPost:
+		s.calendarPostHandler(w, r)
+	default:
+		writeError(w, r, fmt.Errorf("%s: %w", r.Method, errNotImplemented))
+	}
+}
+
+// @Summary     get user calendar feed
+// @Description renders meetings of the user in iCalendar format (RFC 5545). Every meeting is a VEVENT with a stable UID,
+// @Description its moved occurrences are the VEVENTs with RECURRENCE-ID and the cancelled meetings have CANCELLED status.
+// @Description The feed is not changed until the meetings are, so it can be polled with If-None-Match.
+// @Accept      application/x-www-form-urlencoded
+// @Produce     text/calendar
+// @Param       user_id path     uint32      true "User ID"
+// @Success     200     {string} string      "iCalendar object"
+// @Success     304     {string} string      "empty"
+// @Failure     400     {object} lib.Problem "error description"
+// @Failure     403     {object} lib.Problem "error description"
+// @Failure     404     {object} lib.Problem "error description"
+// @Failure     500     {object} lib.Problem "error description"
+// @Router      /calendar.ics [get]
+func (s *Service) calendarGetHandler(w http.ResponseWriter, r *http.Request) {
+	if err := parseForm(r); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	params := parameters{userIdTag: singleValue | parameterRequired}
+	if err := checkArgs(&r.Form, params); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	id, err := strconv.ParseUint(r.FormValue(userIdTag), 10, 32)
+	if err != nil {
+		writeError(w, r, paramError(userIdTag, err))
+		return
+	}
+	if err := s.authorize(r, userIdTag, UID(id)); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	usr, err := s.storage.UserFindById(UID(id))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	meets, err := s.storage.UserMeetings(usr.Id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	cancellations, err := s.storage.UserCancellations(usr.Id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	cal := calendar{
+		storage:   s.storage,
+		user:      usr,
+		meetings:  meets,
+		cancelled: cancellations,
+		lastYear:  time.Now().UTC().Year() + zoneYearsAhead,
+		users:     make(map[UID]*User),
+	}
+	data := cal.render()
+
+	sum := sha256.Sum256(data)
+	etag := strconv.Quote(hex.EncodeToString(sum[:16]))
+	w.Header().Set(etagTag, etag)
+	if match := r.Header.Get(ifNoneMatchTag); match != "" && etagMatches(match, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set(contentTypeTag, mimeCalendar)
+	w.Write(data)
+}
+
+// reports whether the If-None-Match header value lists the entity tag
+func etagMatches(header, etag string) bool {
+	for _, v := range strings.Split(header, ",") {
+		v = strings.TrimPrefix(strings.TrimSpace(v), "W/")
+		if v == "*" || v == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// calendar renders meetings of the user as the iCalendar object
+type calendar struct {
+	storage   Storage
+	user      User
+	meetings  []Meeting
+	cancelled []Cancellation
+	// the time zones are described up to the beginning of this year
+	lastYear int
+	// names of the users by id, looked up once
+	users map[UID]*User
+	b     strings.Builder
+}
+
+func (c *calendar) render() []byte {
+	sort.Slice(c.meetings, func(i, j int) bool { return c.meetings[i].Id < c.meetings[j].Id })
+	sort.Slice(c.cancelled, func(i, j int) bool { return c.cancelled[i].Id < c.cancelled[j].Id })
+
+	c.line("BEGIN:VCALENDAR")
+	c.line("VERSION:2.0")
+	c.line("PRODID:" + calendarProdId)
+	c.line("CALSCALE:GREGORIAN")
+	c.line("X-WR-CALNAME:" + escapeText(c.user.Name))
+	c.timeZones()
+	for _, m := range c.meetings {
+		c.event(m, "CONFIRMED", m.UpdatedAt)
+	}
+	for _, m := range c.cancelled {
+		c.event(m.Meeting, "CANCELLED", m.CancelledAt)
+	}
+	c.line("END:VCALENDAR")
+	return []byte(c.b.String())
+}
+
+// writes VTIMEZONE of every zone the meetings repeat in
+func (c *calendar) timeZones() {
+	firstYear := make(map[string]int)
+	for _, m := range c.allMeetings() {
+		if m.TimeZone == "" {
+			continue
+		}
+		year := m.FirstOccurence.In(m.location()).Year()
+		if y, ok := firstYear[m.TimeZone]; !ok || year < y {
+			firstYear[m.TimeZone] = year
+		}
+	}
+	names := make([]string, 0, len(firstYear))
+	for name := range firstYear {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		loc, err := loadLocation(name)
+		if err != nil {
+			continue
+		}
+		from := time.Date(firstYear[name], time.January, 1, 0, 0, 0, 0, loc)
+		to := time.Date(c.lastYear, time.January, 1, 0, 0, 0, 0, loc)
+		c.timeZone(name, from, to)
+	}
+}
+
+func (c *calendar) allMeetings() []Meeting {
+	meets := make([]Meeting, 0, len(c.meetings)+len(c.cancelled))
+	meets = append(meets, c.meetings...)
+	for _, m := range c.cancelled {
+		meets = append(meets, m.Meeting)
+	}
+	return meets
+}
+
+// writes VTIMEZONE with the offset at from and all its changes till to
+func (c *calendar) timeZone(name string, from, to time.Time) {
+	c.line("BEGIN:VTIMEZONE")
+	c.line("TZID:" + name)
+	_, offset := from.Zone()
+	c.zoneObservance(from, offset)
+	for _, t := range zoneTransitions(from, to) {
+		_, prev := t.Add(-time.Second).Zone()
+		c.zoneObservance(t, prev)
+	}
+	c.line("END:VTIMEZONE")
+}
+
+// writes STANDARD or DAYLIGHT component of the zone offset starting at t
+func (c *calendar) zoneObservance(t time.Time, offsetFrom int) {
+	abbr, offsetTo := t.Zone()
+	kind := "STANDARD"
+	if t.IsDST() {
+		kind = "DAYLIGHT"
+	}
+	c.line("BEGIN:" + kind)
+	// the onset is given in the local time before the change
+	c.line("DTSTART:" + t.In(time.FixedZone("", offsetFrom)).Format(icsDateTime))
+	c.line("TZOFFSETFROM:" + formatOffset(offsetFrom))
+	c.line("TZOFFSETTO:" + formatOffset(offsetTo))
+	c.line("TZNAME:" + escapeText(abbr))
+	c.line("END:" + kind)
+}
+
+// returns moments the zone offset of the location of from changes in (from, to]
+func zoneTransitions(from, to time.Time) []time.Time {
+	result := make([]time.Time, 0)
+	_, offset := from.Zone()
+	for t := from; t.Before(to); {
+		next := t.Add(24 * time.Hour)
+		if _, o := next.Zone(); o != offset {
+			// the offset changes once a day at most
+			lo, hi := t, next
+			for hi.Sub(lo) > time.Second {
+				mid := lo.Add(hi.Sub(lo) / 2).Truncate(time.Second)
+				if _, o := mid.Zone(); o == offset {
+					lo = mid
+				} else {
+					hi = mid
+				}
+			}
+			result = append(result, hi)
+			offset = o
+		}
+		t = next
+	}
+	return result
+}
+
+func formatOffset(seconds int) string {
+	sign := '+'
+	if seconds < 0 {
+		sign = '-'
+		seconds = -seconds
+	}
+	s := fmt.Sprintf("%c%02d%02d", sign, seconds/3600, seconds/60%60)
+	if seconds%60 != 0 {
+		s += fmt.Sprintf("%02d", seconds%60)
+	}
+	return s
+}
+
+// writes VEVENT of the meeting and of every its moved occurrence
+func (c *calendar) event(m Meeting, status string, stamp time.Time) {
+	if stamp.IsZero() {
+		// the meetings saved before the change time was kept
+		stamp = m.FirstOccurence
+	}
+	c.eventHeader(m, status, stamp)
+	c.line("DTSTART" + c.dateTime(m, m.FirstOccurence))
+	c.line("DTEND" + c.dateTime(m, m.FirstOccurence.Add(m.Duration.Duration)))
+	if rule := m.recurrence(); rule != nil {
+		c.line("RRULE:" + rule.String())
+		exDates := append([]time.Time(nil), m.ExDates...)
+		sort.Slice(exDates, func(i, j int) bool { return exDates[i].Before(exDates[j]) })
+		for _, t := range exDates {
+			c.line("EXDATE" + c.dateTime(m, t))
+		}
+	}
+	c.line("END:VEVENT")
+
+	overrides := append([]Override(nil), m.Overrides...)
+	sort.Slice(overrides, func(i, j int) bool { return overrides[i].RecurrenceId.Before(overrides[j].RecurrenceId) })
+	for _, o := range overrides {
+		c.eventHeader(m, status, stamp)
+		c.line("RECURRENCE-ID" + c.dateTime(m, o.RecurrenceId))
+		c.line("DTSTART" + c.dateTime(m, o.StartAt))
+		c.line("DTEND" + c.dateTime(m, o.StartAt.Add(o.Duration.Duration)))
+		c.line("END:VEVENT")
+	}
+}
+
+// writes the beginning of VEVENT common for the meeting and its moved occurrences
+func (c *calendar) eventHeader(m Meeting, status string, stamp time.Time) {
+	c.line("BEGIN:VEVENT")
+	c.line(fmt.Sprintf("UID:meeting-%d@schedule", m.Id))
+	c.line("DTSTAMP:" + stamp.UTC().Format(icsUtcDateTime))
+	c.line(fmt.Sprintf("SUMMARY:Meeting %d", m.Id))
+	c.line("STATUS:" + status)
+	c.line("ORGANIZER" + c.address(m.CreatorId))
+	for _, p := range m.Members {
+		c.line(fmt.Sprintf("ATTENDEE;ROLE=%s;PARTSTAT=%s", roleToIcs[p.Role], presenceToPartStat[p.Status]) + c.address(p.UserId))
+	}
+}
+
+// returns the value of DTSTART-like property with its parameters,
+// the time is local to the meeting time zone if it has one
+func (c *calendar) dateTime(m Meeting, t time.Time) string {
+	if m.TimeZone == "" {
+		return ":" + t.UTC().Format(icsUtcDateTime)
+	}
+	return ";TZID=" + m.TimeZone + ":" + t.In(m.location()).Format(icsDateTime)
+}
+
+// returns CN parameter and the calendar address of the user
+func (c *calendar) address(id UID) string {
+	usr, ok := c.users[id]
+	if !ok {
+		if u, err := c.storage.UserFindById(id); err == nil {
+			usr = &u
+		}
+		c.users[id] = usr
+	}
+	if usr == nil {
+		return fmt.Sprintf(":urn:schedule:user:%d", id)
+	}
+	address := fmt.Sprintf("urn:schedule:user:%d", id)
+	if usr.Email != "" {
+		address = "mailto:" + usr.Email
+	}
+	return ";CN=" + quoteParam(usr.Name) + ":" + address
+}
+
+// writes the content line folded to 75 octets,
+// the continuation lines start with a space
+func (c *calendar) line(s string) {
+	max := icsMaxLineBytes
+	for len(s) > max {
+		n := max
+		// don't split UTF-8 sequences
+		for n > 0 && s[n]&0xC0 == 0x80 {
+			n--
+		}
+		c.b.WriteString(s[:n])
+		c.b.WriteString("\r\n ")
+		s = s[n:]
+		max = icsMaxLineBytes - 1
+	}
+	c.b.WriteString(s)
+	c.b.WriteString("\r\n")
+}
+
+// escapes TEXT property value
+func escapeText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// returns property parameter value, quoted if it contains special characters.
+// Double quotes can't be escaped and are removed.
+func quoteParam(s string) string {
+	s = strings.NewReplacer(`"`, "", "\r", "", "\n", " ").Replace(s)
+	if strings.ContainsAny(s, ":;,") {
+		return `"` + s + `"`
+	}
+	return s
+}
@@ -0,0 +1,65 @@
+package lib
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestZoneTransitions(t *testing.T) {
+	loc, _ := loadLocation("Europe/Berlin")
+	from := time.Date(2023, time.January, 1, 0, 0, 0, 0, loc)
+	to := time.Date(2024, time.January, 1, 0, 0, 0, 0, loc)
+	actual := zoneTransitions(from, to)
+	expected := []time.Time{getTestTime("2023-03-26T01:00:00Z"), getTestTime("2023-10-29T01:00:00Z")}
+	if fmt.Sprint(actual) != fmt.Sprint(inZone(expected, loc)) {
+		t.Errorf("transitions: expected: %v, actual: %v", expected, actual)
+	}
+
+	tokyo, _ := loadLocation("Asia/Tokyo")
+	if actual := zoneTransitions(from.In(tokyo), to.In(tokyo)); len(actual) != 0 {
+		t.Errorf("transitions of the zone without DST: %v", actual)
+	}
+}
+
+func inZone(times []time.Time, loc *time.Location) []time.Time {
+	result := make([]time.Time, 0, len(times))
+	for _, t := range times {
+		result = append(result, t.In(loc))
+	}
+	return result
+}
+
+func TestCalendarLineFolding(t *testing.T) {
+	var c calendar
+	value := "DESCRIPTION:" + strings.Repeat("x", 70) + strings.Repeat("ü", 50)
+	c.line(value)
+	lines := strings.Split(strings.TrimSuffix(c.b.String(), "\r\n"), "\r\n")
+	if len(lines) < 3 {
+		t.Fatalf("lines: expected at least 3, actual: %q", lines)
+	}
+	unfolded := lines[0]
+	for _, l := range lines {
+		if len(l) > icsMaxLineBytes {
+			t.Errorf("line longer than %d octets: %q", icsMaxLineBytes, l)
+		}
+		if !strings.HasPrefix(l, " ") && l != lines[0] {
+			t.Errorf("continuation line without leading space: %q", l)
+		}
+		if l != lines[0] {
+			unfolded += l[1:]
+		}
+	}
+	if unfolded != value {
+		t.Errorf("unfolded: expected: %q, actual: %q", value, unfolded)
+	}
+}
+
+func TestFormatOffset(t *testing.T) {
+	for seconds, expected := range map[int]string{0: "+0000", 3600: "+0100", -16200: "-0430", 20700: "+0545", 3661: "+010101"} {
+		if actual := formatOffset(seconds); actual != expected {
+			t.Errorf("offset %d: expected: %q, actual: %q", seconds, expected, actual)
+		}
+	}
+}
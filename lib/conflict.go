@@ -0,0 +1,132 @@
+package lib
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+)
+
+const conflictPolicyTag = "conflict_policy"
+
+// maximum number of conflicts reported for the meeting
+const maxConflicts = 100
+
+type conflictPolicy int
+
+const (
+	// the meeting is stored without the check
+	policyAllow conflictPolicy = iota
+	// the meeting is stored and the conflicts are reported
+	policyWarn
+	// the meeting is not stored if there are conflicts
+	policyReject
+)
+
+var namesToPolicy = map[string]conflictPolicy{
+	"allow":  policyAllow,
+	"warn":   policyWarn,
+	"reject": policyReject,
+}
+
+func parseConflictPolicy(s string) (conflictPolicy, error) {
+	policy, ok := namesToPolicy[s]
+	if !ok {
+		return policy, fmt.Errorf("unknown conflict policy: %s: %w", s, ErrParse)
+	}
+	return policy, nil
+}
+
+// checks the meeting according to the policy and returns the conflicts found.
+// If the policy rejects the meeting, writes the problem listing the conflicts
+// and reports false, the meeting must not be stored then.
+func (s *Service) checkConflicts(w http.ResponseWriter, r *http.Request, m Meeting, policy conflictPolicy) ([]Conflict, bool) {
+	if policy == policyAllow {
+		return nil, true
+	}
+	conflicts, err := s.findConflicts(m)
+	if err != nil {
+		writeError(w, r, fmt.Errorf("check conflicts: %w", err))
+		return nil, false
+	}
+	if policy == policyReject && len(conflicts) != 0 {
+		err := &ParamError{Param: conflictPolicyTag, Err: fmt.Errorf("%d conflicts with meetings of the members: %w", len(conflicts), ErrConflict)}
+		log.Printf("error: %s %s: %v\n", r.Method, r.URL.Path, err)
+		p := newProblem(err)
+		p.Conflicts = conflicts
+		writeProblem(w, p)
+		return nil, false
+	}
+	return conflicts, true
+}
+
+// returns occurrences of the meeting overlapping other meetings of its members
+// ordered by the occurrence time. Meetings rejected by the member are skipped.
+// The meetings are compared within their bookingPeriod, at most maxConflicts are returned.
+func (s *Service) findConflicts(m Meeting) ([]Conflict, error) {
+	now := time.Now().UTC()
+	from, to := m.span()
+
+	conflicts := make([]Conflict, 0)
+	for _, member := range m.Members {
+		meets, err := s.storage.UserMeetingsBetween(member.UserId, from, to)
+		if err != nil {
+			return nil, err
+		}
+		for _, other := range meets {
+			if other.Id == m.Id || other.memberStatus(member.UserId) == Rejected {
+				continue
+			}
+			start, end := bookingPeriod(now, m, other)
+			overlapping(m.occurrencesBetween(start, end), other.occurrencesBetween(start, end), func(o, busy occurrence) {
+				conflicts = append(conflicts, Conflict{
+					UserId:     member.UserId,
+					MeetingId:  other.Id,
+					Occurrence: o.start,
+					StartAt:    busy.start,
+					EndAt:      busy.end(),
+				})
+			})
+		}
+	}
+
+	sort.Slice(conflicts, func(i, j int) bool {
+		a, b := conflicts[i], conflicts[j]
+		switch {
+		case !a.Occurrence.Equal(b.Occurrence):
+			return a.Occurrence.Before(b.Occurrence)
+		case a.UserId != b.UserId:
+			return a.UserId < b.UserId
+		case a.MeetingId != b.MeetingId:
+			return a.MeetingId < b.MeetingId
+		}
+		return a.StartAt.Before(b.StartAt)
+	})
+	if len(conflicts) > maxConflicts {
+		conflicts = conflicts[:maxConflicts]
+	}
+	return conflicts, nil
+}
+
+// calls fn for every pair of overlapping occurrences of the lists ordered by start
+func overlapping(a, b []occurrence, fn func(x, y occurrence)) {
+	var longest time.Duration
+	for _, y := range b {
+		if y.duration > longest {
+			longest = y.duration
+		}
+	}
+	j := 0
+	for _, x := range a {
+		// occurrences of b starting that early end before x and the next ones
+		for j < len(b) && !b[j].start.Add(longest).After(x.start) {
+			j++
+		}
+		for k := j; k < len(b) && b[k].start.Before(x.end()); k++ {
+			if b[k].end().After(x.start) {
+				fn(x, b[k])
+			}
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package lib
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestOverlapping(t *testing.T) {
+	at := func(clock string) time.Time { return getTestTime("2023-02-01T" + clock + ":00Z") }
+	a := []occurrence{{at("09:00"), time.Hour}, {at("11:00"), 30 * time.Minute}, {at("14:00"), time.Hour}}
+	// the long occurrence overlaps several ones of a
+	b := []occurrence{{at("08:00"), 4 * time.Hour}, {at("09:30"), 15 * time.Minute}, {at("10:00"), time.Hour}, {at("15:00"), time.Hour}}
+
+	actual := make([]string, 0)
+	overlapping(a, b, func(x, y occurrence) {
+		actual = append(actual, x.start.Format("15:04")+"/"+y.start.Format("15:04"))
+	})
+	expected := []string{"09:00/08:00", "09:00/09:30", "11:00/08:00"}
+	if fmt.Sprint(actual) != fmt.Sprint(expected) {
+		t.Errorf("overlapping occurrences: expected: %v, actual: %v", expected, actual)
+	}
+}
@@ -1,9 +1,42 @@
 package lib
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
-	ErrExist    = errors.New("already exists")
-	ErrNotExist = errors.New("does not exist")
-	ErrParse    = errors.New("parse error")
+	ErrExist        = errors.New("already exists")
+	ErrNotExist     = errors.New("does not exist")
+	ErrParse        = errors.New("parse error")
+	ErrConflict     = errors.New("conflict")
+	ErrForbidden    = errors.New("forbidden")
+	ErrUnauthorized = errors.New("unauthorized")
 )
+
+// ParamError tells which request parameter caused the error
+type ParamError struct {
+	Param string
+	Err   error
+}
+
+func (e *ParamError) Error() string {
+	return fmt.Sprintf("%q: %v", e.Param, e.Err)
+}
+
+func (e *ParamError) Unwrap() error {
+	return e.Err
+}
+
+// returns err marked with ErrParse unless it is already marked
+func parseError(err error) error {
+	if errors.Is(err, ErrParse) {
+		return err
+	}
+	return fmt.Errorf("%v: %w", err, ErrParse)
+}
+
+// returns the parse error of the request parameter
+func paramError(param string, err error) error {
+	return &ParamError{Param: param, Err: parseError(err)}
+}
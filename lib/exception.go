@@ -0,0 +1,233 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const occurrenceTag = "occurrence"
+
+// general handler for /meeting_exception path
+func (s *Service) MeetingExceptionHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPut:
+		s.meetingExceptionPutHandler(w, r)
+	case http.MethodDelete:
+		s.meetingExceptionDeleteHandler(w, r)
+	default:
+		writeError(w, r, fmt.Errorf("%s: %w", r.Method, errNotImplemented))
+	}
+}
+
+// @Summary     change single occurrence
+// @Description cancel or move a single occurrence of the recurring meeting. Only the meeting creator may change it.
+// @Description Changing the series start time or recurrence rule drops all the exceptions.
+// @Accept      application/x-www-form-urlencoded
+// @Produce     application/json
+// @Param       id         path     uint32      true  "Meeting ID"
+// @Param       user_id    path     uint32      true  "ID of the user changing the meeting"
+// @Param       occurrence path     string      true  "Original start time of the occurrence in RFC3339"
+// @Param       cancelled  path     bool        false "Cancel the occurrence"
+// @Param       start_at   path     string      false "New start time of the occurrence in RFC3339"
+// @Param       duration   path     string      false "New duration of the occurrence in format '1h2m3s'. Any of values may be ommited."
+// @Success     200        {object} lib.Meeting "Changed meeting"
+// @Failure     400        {object} lib.Problem "error description"
+// @Failure     403        {object} lib.Problem "error description"
+// @Failure     404        {object} lib.Problem "error description"
+// @Failure     409        {object} lib.Problem "error description"
+// @Failure     500        {object} lib.Problem "error description"
+// @Router      /meeting_exception [put]
+func (s *Service) meetingExceptionPutHandler(w http.ResponseWriter, r *http.Request) {
+	if err := parseForm(r); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	params := parameters{
+		idTag:         singleValue | parameterRequired,
+		userIdTag:     singleValue | parameterRequired,
+		occurrenceTag: singleValue | parameterRequired,
+		cancelledTag:  singleValue,
+		startAtTag:    singleValue,
+		durationTag:   singleValue,
+	}
+	if err := checkArgs(&r.Form, params); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	meeting, recurrenceId, ok := s.findOccurrence(w, r)
+	if !ok {
+		return
+	}
+
+	cancelled := false
+	if _, ok := r.Form[cancelledTag]; ok {
+		var err error
+		cancelled, err = strconv.ParseBool(r.FormValue(cancelledTag))
+		if err != nil {
+			writeError(w, r, paramError(cancelledTag, err))
+			return
+		}
+	}
+	_, startSet := r.Form[startAtTag]
+	_, durationSet := r.Form[durationTag]
+	if cancelled == (startSet || durationSet) {
+		writeError(w, r, fmt.Errorf("either %q or new time must be given: %w", cancelledTag, ErrParse))
+		return
+	}
+
+	meeting.removeException(recurrenceId)
+	if cancelled {
+		meeting.ExDates = append(meeting.ExDates, recurrenceId)
+	} else {
+		o := Override{RecurrenceId: recurrenceId, StartAt: recurrenceId, Duration: meeting.Duration}
+		if startSet {
+			startAt, err := time.Parse(time.RFC3339, r.FormValue(startAtTag))
+			if err != nil {
+				writeError(w, r, paramError(startAtTag, err))
+				return
+			}
+			o.StartAt = startAt.UTC()
+		}
+		if durationSet {
+			dur, err := time.ParseDuration(r.FormValue(durationTag))
+			if err != nil {
+				writeError(w, r, paramError(durationTag, err))
+				return
+			}
+			o.Duration = Duration{dur}
+		}
+		meeting.Overrides = append(meeting.Overrides, o)
+	}
+
+	if err := s.storage.MeetingUpdate(meeting); err != nil {
+		writeError(w, r, fmt.Errorf("update id=%v: %w", meeting.Id, err))
+		return
+	}
+
+	result, err := json.MarshalIndent(meeting, "", "  ")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.Header().Set(contentTypeTag, mimeJson)
+	w.Write(result)
+}
+
+// @Summary     restore single occurrence
+// @Description remove the cancellation or the change of the single occurrence. Only the meeting creator may restore it.
+// @Accept      application/x-www-form-urlencoded
+// @Produce     application/json
+// @Param       id         path     uint32      true "Meeting ID"
+// @Param       user_id    path     uint32      true "ID of the user changing the meeting"
+// @Param       occurrence path     string      true "Original start time of the occurrence in RFC3339"
+// @Success     200        {object} lib.Meeting "Changed meeting"
+// @Failure     400        {object} lib.Problem "error description"
+// @Failure     403        {object} lib.Problem "error description"
+// @Failure     404        {object} lib.Problem "error description"
+// @Failure     500        {object} lib.Problem "error description"
+// @Router      /meeting_exception [delete]
+func (s *Service) meetingExceptionDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	if err := parseForm(r); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	params := parameters{
+		idTag:         singleValue | parameterRequired,
+		userIdTag:     singleValue | parameterRequired,
+		occurrenceTag: singleValue | parameterRequired,
+	}
+	if err := checkArgs(&r.Form, params); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	meeting, recurrenceId, ok := s.findOccurrence(w, r)
+	if !ok {
+		return
+	}
+	if !meeting.removeException(recurrenceId) {
+		writeError(w, r, fmt.Errorf("occurrence %v of meeting %d is not changed: %w", recurrenceId, meeting.Id, ErrNotExist))
+		return
+	}
+
+	if err := s.storage.MeetingUpdate(meeting); err != nil {
+		writeError(w, r, fmt.Errorf("update id=%v: %w", meeting.Id, err))
+		return
+	}
+
+	result, err := json.MarshalIndent(meeting, "", "  ")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.Header().Set(contentTypeTag, mimeJson)
+	w.Write(result)
+}
+
+// looks up the recurring meeting changed by its creator and the original start
+// time of its occurrence. Writes the error response and returns false on failure.
+func (s *Service) findOccurrence(w http.ResponseWriter, r *http.Request) (Meeting, time.Time, bool) {
+	meetingId, err := strconv.ParseUint(r.FormValue(idTag), 10, 32)
+	if err != nil {
+		writeError(w, r, paramError(idTag, err))
+		return Meeting{}, time.Time{}, false
+	}
+
+	userId, err := strconv.ParseUint(r.FormValue(userIdTag), 10, 32)
+	if err != nil {
+		writeError(w, r, paramError(userIdTag, err))
+		return Meeting{}, time.Time{}, false
+	}
+	if err := s.authorize(r, userIdTag, UID(userId)); err != nil {
+		writeError(w, r, err)
+		return Meeting{}, time.Time{}, false
+	}
+
+	recurrenceId, err := time.Parse(time.RFC3339, r.FormValue(occurrenceTag))
+	if err != nil {
+		writeError(w, r, paramError(occurrenceTag, err))
+		return Meeting{}, time.Time{}, false
+	}
+	recurrenceId = recurrenceId.UTC()
+
+	meeting, err := s.storage.MeetingFindById(MeetingId(meetingId))
+	if err != nil {
+		writeError(w, r, err)
+		return Meeting{}, time.Time{}, false
+	}
+	if meeting.CreatorId != UID(userId) {
+		writeError(w, r, &ParamError{Param: userIdTag, Err: fmt.Errorf("user %d is not the creator of meeting %d: %w", userId, meetingId, ErrForbidden)})
+		return Meeting{}, time.Time{}, false
+	}
+	if meeting.recurrence() == nil {
+		writeError(w, r, &ParamError{Param: idTag, Err: fmt.Errorf("meeting %d is not recurring: %w", meetingId, ErrParse)})
+		return Meeting{}, time.Time{}, false
+	}
+	if !meeting.isScheduledAt(recurrenceId) {
+		writeError(w, r, &ParamError{Param: occurrenceTag, Err: fmt.Errorf("meeting %d has no occurrence at %v: %w", meetingId, recurrenceId, ErrNotExist)})
+		return Meeting{}, time.Time{}, false
+	}
+	return meeting, recurrenceId, true
+}
+
+// removes cancellation or override of the occurrence.
+// Reports whether there was any.
+func (m *Meeting) removeException(recurrenceId time.Time) bool {
+	for i, t := range m.ExDates {
+		if t.Equal(recurrenceId) {
+			m.ExDates = append(m.ExDates[:i], m.ExDates[i+1:]...)
+			return true
+		}
+	}
+	for i, o := range m.Overrides {
+		if o.RecurrenceId.Equal(recurrenceId) {
+			m.Overrides = append(m.Overrides[:i], m.Overrides[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,442 @@
+package lib
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	journalFileName  = "journal.log"
+	snapshotFileName = "snapshot.json"
+	// number of journal records after which the journal is compacted into the snapshot
+	snapshotInterval = 100
+)
+
+// journal operations
+const (
+	opPutUser     = "putUser"
+	opPutResource = "putResource"
+	opPutMeeting  = "putMeeting"
+	opDelUser     = "deleteUser"
+	opRemoveUser  = "removeUser"
+	opDelResource = "deleteResource"
+	opSetToken    = "setToken"
+	opCancel      = "cancelMeeting"
+	opReset       = "reset"
+)
+
+// journalRecord is a single line of the journal.
+// Replaying the records already included into the snapshot is harmless.
+type journalRecord struct {
+	Op       string
+	User     *User     `json:",omitempty"`
+	Resource *Resource `json:",omitempty"`
+	Meeting  *Meeting  `json:",omitempty"`
+	// Meeting field of the cancellation is omitted, only its Id is kept
+	Cancellation *Cancellation `json:",omitempty"`
+	Token        *userToken    `json:",omitempty"`
+	Removal      *userRemoval  `json:",omitempty"`
+}
+
+// userToken is the hash of the access token issued to the user, empty if it is revoked
+type userToken struct {
+	UserId UID
+	Hash   string
+}
+
+type snapshot struct {
+	MaxUserId     UID
+	MaxResourceId ResourceId
+	MaxMeetingId  MeetingId
+	Users         []User
+	Resources     []Resource
+	Meetings      []Meeting
+	Cancellations map[UID][]Cancellation
+	Tokens        map[string]UID
+}
+
+// fileStorage keeps the data in memory and appends every mutation to the
+// journal in the data directory. The journal is periodically compacted into
+// the snapshot. Both are replayed when the storage is opened.
+type fileStorage struct {
+	sync.Mutex
+	*memoryStorage
+	dir     string
+	journal *os.File
+	records int
+}
+
+// NewFileStorage opens the storage persisted in the directory dir.
+// The directory is created if it does not exist.
+func NewFileStorage(dir string) (Storage, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	s := &fileStorage{memoryStorage: newMemoryStorage(), dir: dir}
+	if err := s.loadSnapshot(); err != nil {
+		return nil, fmt.Errorf("load snapshot: %w", err)
+	}
+	if err := s.replayJournal(); err != nil {
+		return nil, fmt.Errorf("replay journal: %w", err)
+	}
+	f, err := os.OpenFile(filepath.Join(dir, journalFileName), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	s.journal = f
+	return s, nil
+}
+
+func (s *fileStorage) UserAdd(u UserInfo) (UID, error) {
+	s.Lock()
+	defer s.Unlock()
+	id, err := s.memoryStorage.UserAdd(u)
+	if err != nil {
+		return id, err
+	}
+	return id, s.logUser(id)
+}
+
+func (s *fileStorage) UserUpdate(u User) error {
+	s.Lock()
+	defer s.Unlock()
+	if err := s.memoryStorage.UserUpdate(u); err != nil {
+		return err
+	}
+	return s.logUser(u.Id)
+}
+
+func (s *fileStorage) UserDelete(id UID) error {
+	s.Lock()
+	defer s.Unlock()
+	if err := s.memoryStorage.UserDelete(id); err != nil {
+		return err
+	}
+	return s.appendRecord(journalRecord{Op: opDelUser, User: &User{Id: id}})
+}
+
+// UserRemove writes a single record for the user and all their meetings
+func (s *fileStorage) UserRemove(id UID, deactivate, cancelCreated bool, reassignTo UID) error {
+	s.Lock()
+	defer s.Unlock()
+	rm := userRemoval{UserId: id, Deactivate: deactivate, CancelCreated: cancelCreated, ReassignTo: reassignTo, At: time.Now().UTC()}
+	if err := s.memoryStorage.removeUser(rm); err != nil {
+		return err
+	}
+	return s.appendRecord(journalRecord{Op: opRemoveUser, Removal: &rm})
+}
+
+func (s *fileStorage) UserSetToken(id UID, hash string) error {
+	s.Lock()
+	defer s.Unlock()
+	if err := s.memoryStorage.UserSetToken(id, hash); err != nil {
+		return err
+	}
+	return s.appendRecord(journalRecord{Op: opSetToken, Token: &userToken{UserId: id, Hash: hash}})
+}
+
+func (s *fileStorage) ResourceAdd(r ResourceInfo) (ResourceId, error) {
+	s.Lock()
+	defer s.Unlock()
+	id, err := s.memoryStorage.ResourceAdd(r)
+	if err != nil {
+		return id, err
+	}
+	return id, s.logResource(id)
+}
+
+func (s *fileStorage) ResourceUpdate(r Resource) error {
+	s.Lock()
+	defer s.Unlock()
+	if err := s.memoryStorage.ResourceUpdate(r); err != nil {
+		return err
+	}
+	return s.logResource(r.Id)
+}
+
+func (s *fileStorage) ResourceDelete(id ResourceId) error {
+	s.Lock()
+	defer s.Unlock()
+	if err := s.memoryStorage.ResourceDelete(id); err != nil {
+		return err
+	}
+	return s.appendRecord(journalRecord{Op: opDelResource, Resource: &Resource{Id: id}})
+}
+
+func (s *fileStorage) MeetingAdd(m MeetingInfo) (MeetingId, error) {
+	s.Lock()
+	defer s.Unlock()
+	id, err := s.memoryStorage.MeetingAdd(m)
+	if err != nil {
+		return id, err
+	}
+	return id, s.logMeeting(id)
+}
+
+func (s *fileStorage) MeetingUpdate(m Meeting) error {
+	s.Lock()
+	defer s.Unlock()
+	if err := s.memoryStorage.MeetingUpdate(m); err != nil {
+		return err
+	}
+	return s.logMeeting(m.Id)
+}
+
+func (s *fileStorage) MeetingDelete(id MeetingId, by UID) error {
+	s.Lock()
+	defer s.Unlock()
+	c, err := s.memoryStorage.cancelMeeting(id, by, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	c.Meeting = Meeting{Id: c.Id}
+	return s.appendRecord(journalRecord{Op: opCancel, Cancellation: &c})
+}
+
+func (s *fileStorage) Reset() error {
+	s.Lock()
+	defer s.Unlock()
+	if err := s.memoryStorage.Reset(); err != nil {
+		return err
+	}
+	return s.appendRecord(journalRecord{Op: opReset})
+}
+
+// Close writes the snapshot and closes the journal.
+func (s *fileStorage) Close() error {
+	s.Lock()
+	defer s.Unlock()
+	if err := s.compact(); err != nil {
+		return err
+	}
+	return s.journal.Close()
+}
+
+func (s *fileStorage) logUser(id UID) error {
+	u, err := s.memoryStorage.UserFindById(id)
+	if err != nil {
+		return err
+	}
+	return s.appendRecord(journalRecord{Op: opPutUser, User: &u})
+}
+
+func (s *fileStorage) logResource(id ResourceId) error {
+	r, err := s.memoryStorage.ResourceFindById(id)
+	if err != nil {
+		return err
+	}
+	return s.appendRecord(journalRecord{Op: opPutResource, Resource: &r})
+}
+
+func (s *fileStorage) logMeeting(id MeetingId) error {
+	m, err := s.memoryStorage.MeetingFindById(id)
+	if err != nil {
+		return err
+	}
+	return s.appendRecord(journalRecord{Op: opPutMeeting, Meeting: &m})
+}
+
+// writes the record to the journal and waits until it reaches the disk.
+// If it fails, the change stays in memory but will be lost after restart.
+func (s *fileStorage) appendRecord(rec journalRecord) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if _, err := s.journal.Write(data); err != nil {
+		return err
+	}
+	if err := s.journal.Sync(); err != nil {
+		return err
+	}
+	s.records++
+	if s.records >= snapshotInterval {
+		return s.compact()
+	}
+	return nil
+}
+
+// writes the current state to the snapshot and truncates the journal
+func (s *fileStorage) compact() error {
+	s.users.Lock()
+	s.resources.Lock()
+	s.meetings.Lock()
+	snap := snapshot{
+		MaxUserId:     s.users.maxId,
+		MaxResourceId: s.resources.maxId,
+		MaxMeetingId:  s.meetings.maxId,
+		Users:         make([]User, 0, len(s.users.m)),
+		Resources:     make([]Resource, 0, len(s.resources.m)),
+		Meetings:      make([]Meeting, 0, len(s.meetings.m)),
+	}
+	for _, u := range s.users.m {
+		snap.Users = append(snap.Users, u)
+	}
+	for _, r := range s.resources.m {
+		snap.Resources = append(snap.Resources, r)
+	}
+	for _, m := range s.meetings.m {
+		snap.Meetings = append(snap.Meetings, m)
+	}
+	snap.Cancellations = s.users.cancellations
+	snap.Tokens = s.users.tokens
+	data, err := json.Marshal(snap)
+	s.meetings.Unlock()
+	s.resources.Unlock()
+	s.users.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := writeFileSync(filepath.Join(s.dir, snapshotFileName), data); err != nil {
+		return err
+	}
+	// the snapshot already contains everything from the journal
+	if err := s.journal.Truncate(0); err != nil {
+		return err
+	}
+	s.records = 0
+	return s.journal.Sync()
+}
+
+func (s *fileStorage) loadSnapshot() error {
+	data, err := os.ReadFile(filepath.Join(s.dir, snapshotFileName))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return fmt.Errorf("%v: %w", err, ErrParse)
+	}
+	for _, u := range snap.Users {
+		s.putUser(u)
+	}
+	for _, r := range snap.Resources {
+		s.putResource(r)
+	}
+	for _, m := range snap.Meetings {
+		s.putMeeting(m)
+	}
+	for id, c := range snap.Cancellations {
+		s.users.cancellations[id] = c
+	}
+	for hash, id := range snap.Tokens {
+		s.users.tokens[hash] = id
+	}
+	if s.users.maxId < snap.MaxUserId {
+		s.users.maxId = snap.MaxUserId
+	}
+	if s.resources.maxId < snap.MaxResourceId {
+		s.resources.maxId = snap.MaxResourceId
+	}
+	if s.meetings.maxId < snap.MaxMeetingId {
+		s.meetings.maxId = snap.MaxMeetingId
+	}
+	return nil
+}
+
+func (s *fileStorage) replayJournal() error {
+	f, err := os.Open(filepath.Join(s.dir, journalFileName))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	for line := 1; ; line++ {
+		data, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			if len(bytes.TrimSpace(data)) != 0 {
+				// the last record has not been completely written
+				log.Printf("warning: journal: drop incomplete record at line %d\n", line)
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var rec journalRecord
+		if err := json.Unmarshal(data, &rec); err != nil {
+			return fmt.Errorf("line %d: %v: %w", line, err, ErrParse)
+		}
+		if err := s.apply(rec); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		s.records++
+	}
+}
+
+func (s *fileStorage) apply(rec journalRecord) error {
+	switch {
+	case rec.Op == opPutUser && rec.User != nil:
+		s.putUser(*rec.User)
+	case rec.Op == opPutResource && rec.Resource != nil:
+		s.putResource(*rec.Resource)
+	case rec.Op == opPutMeeting && rec.Meeting != nil:
+		s.putMeeting(*rec.Meeting)
+	case rec.Op == opDelUser && rec.User != nil:
+		if err := s.memoryStorage.UserDelete(rec.User.Id); err != nil && !errors.Is(err, ErrNotExist) {
+			return fmt.Errorf("delete user %d: %w", rec.User.Id, err)
+		}
+	case rec.Op == opRemoveUser && rec.Removal != nil:
+		if err := s.removeUser(*rec.Removal); err != nil && !errors.Is(err, ErrNotExist) {
+			return fmt.Errorf("remove user %d: %w", rec.Removal.UserId, err)
+		}
+	case rec.Op == opDelResource && rec.Resource != nil:
+		if err := s.memoryStorage.ResourceDelete(rec.Resource.Id); err != nil && !errors.Is(err, ErrNotExist) {
+			return fmt.Errorf("delete resource %d: %w", rec.Resource.Id, err)
+		}
+	case rec.Op == opSetToken && rec.Token != nil:
+		if err := s.memoryStorage.UserSetToken(rec.Token.UserId, rec.Token.Hash); err != nil && !errors.Is(err, ErrNotExist) {
+			return fmt.Errorf("set token of user %d: %w", rec.Token.UserId, err)
+		}
+	case rec.Op == opCancel && rec.Cancellation != nil:
+		c := rec.Cancellation
+		_, err := s.cancelMeeting(c.Id, c.CancelledBy, c.CancelledAt)
+		if err != nil && !errors.Is(err, ErrNotExist) {
+			return fmt.Errorf("cancel meeting %d: %w", c.Id, err)
+		}
+	case rec.Op == opReset:
+		s.memoryStorage.Reset()
+	default:
+		return fmt.Errorf("unknown record %q: %w", rec.Op, ErrParse)
+	}
+	return nil
+}
+
+// replaces the file content so that it is never left partially written
+func writeFileSync(name string, data []byte) error {
+	tmp := name + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, name)
+}
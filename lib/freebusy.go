@@ -0,0 +1,131 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// general handler for /freebusy path
+func (s *Service) FreeBusyHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.freeBusyGetHandler(w, r)
+	default:
+		writeError(w, r, fmt.Errorf("%s: %w", r.Method, errNotImplemented))
+	}
+}
+
+// @Summary     get busy time of users
+// @Description returns merged busy periods of every user in the period without the meeting details.
+// @Description Recurring meetings are expanded, rejected meetings are skipped.
+// @Accept      application/x-www-form-urlencoded
+// @Produce     application/json
+// @Param       id               path     []uint32       true  "User ID list separated with a comma (',')"
+// @Param       start_at         path     string         true  "Period start time in RFC3339"
+// @Param       end_at           path     string         true  "Period end time in RFC3339, at most 366 days after the start"
+// @Param       unknown_presence path     string         false "Meetings without response are busy (default) or tentative" Enums(busy, tentative)
+// @Success     200              {array}  []lib.FreeBusy "Busy periods of the users"
+// @Failure     400              {object} lib.Problem    "error description"
+// @Failure     404              {object} lib.Problem    "error description"
+// @Failure     500              {object} lib.Problem    "error description"
+// @Router      /freebusy [get]
+func (s *Service) freeBusyGetHandler(w http.ResponseWriter, r *http.Request) {
+	if err := parseForm(r); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	params := parameters{
+		idTag:      multipleValue | parameterRequired,
+		startAtTag: singleValue | parameterRequired,
+		endAtTag:   singleValue | parameterRequired,
+		unknownTag: singleValue,
+	}
+	if err := checkArgs(&r.Form, params); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	userList, err := parseIdList(r.Form[idTag])
+	if err != nil {
+		writeError(w, r, paramError(idTag, err))
+		return
+	}
+
+	startAt, err := time.Parse(time.RFC3339, r.FormValue(startAtTag))
+	if err != nil {
+		writeError(w, r, paramError(startAtTag, err))
+		return
+	}
+	startAt = startAt.UTC()
+
+	endAt, err := time.Parse(time.RFC3339, r.FormValue(endAtTag))
+	if err != nil {
+		writeError(w, r, paramError(endAtTag, err))
+		return
+	}
+	endAt = endAt.UTC()
+	if !endAt.After(startAt) {
+		writeError(w, r, &ParamError{Param: endAtTag, Err: fmt.Errorf("period end %v is not after its start %v: %w", endAt, startAt, ErrParse)})
+		return
+	}
+	if err := checkPeriodLength(endAtTag, startAt, endAt); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	unknownTentative := false
+	switch r.FormValue(unknownTag) {
+	case "", "busy":
+	case "tentative":
+		unknownTentative = true
+	default:
+		writeError(w, r, &ParamError{Param: unknownTag, Err: fmt.Errorf("unknown value %q: %w", r.FormValue(unknownTag), ErrParse)})
+		return
+	}
+
+	result := make([]FreeBusy, 0, len(userList))
+	for _, userId := range userList {
+		periods, err := s.userBusyPeriods(userId, startAt, endAt, unknownTentative)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		busy := make([]interval, 0, len(periods))
+		tentative := make([]interval, 0)
+		for _, p := range periods {
+			// the periods are cut to the requested one
+			if p.start.Before(startAt) {
+				p.start = startAt
+			}
+			if p.end.After(endAt) {
+				p.end = endAt
+			}
+			if p.tentative {
+				tentative = append(tentative, p.interval)
+			} else {
+				busy = append(busy, p.interval)
+			}
+		}
+		busy = mergeIntervals(busy)
+		tentative = subtractIntervals(mergeIntervals(tentative), busy)
+
+		result = append(result, FreeBusy{UserId: userId, Busy: busyIntervals(busy), Tentative: busyIntervals(tentative)})
+	}
+
+	w.Header().Set(contentTypeTag, mimeJson)
+	if err = json.NewEncoder(w).Encode(result); err != nil {
+		writeError(w, r, err)
+		return
+	}
+}
+
+func busyIntervals(periods []interval) []BusyInterval {
+	result := make([]BusyInterval, 0, len(periods))
+	for _, p := range periods {
+		result = append(result, BusyInterval{StartAt: p.start, EndAt: p.end})
+	}
+	return result
+}
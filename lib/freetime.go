@@ -0,0 +1,404 @@
+package lib
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Slot is a period all the required attendees are free
+type Slot struct {
+	StartAt time.Time
+	EndAt   time.Time
+	// optional attendees who can't make it
+	Unavailable []UID `json:",omitempty"`
+	// suitable resource free in the slot if the search asks for one
+	ResourceId ResourceId `json:",omitempty" swaggertype:"integer"`
+}
+
+type rankMode int
+
+const (
+	// the earliest slots first
+	rankEarliest rankMode = iota
+	// the slots with the lowest penalty for delay, adjacent meetings and unround time first
+	rankScore
+)
+
+var namesToRank = map[string]rankMode{
+	"earliest": rankEarliest,
+	"score":    rankScore,
+}
+
+func parseRank(s string) (rankMode, error) {
+	rank, ok := namesToRank[s]
+	if !ok {
+		return rank, fmt.Errorf("unknown rank: %s: %w", s, ErrParse)
+	}
+	return rank, nil
+}
+
+const (
+	// candidate slots start at the end of busy periods and at multiples of the step
+	slotStep = 15 * time.Minute
+	// meetings closer to the slot than that are considered adjacent
+	adjacentGap = 15 * time.Minute
+	// scored search considers slots in this period after the first free one
+	rankingPeriod = 7 * 24 * time.Hour
+	// maximum number of slots returned at once
+	maxSlots = 50
+)
+
+// penalties of the scored search, a day of delay costs 1
+const (
+	adjacentPenalty = 0.25
+	halfHourPenalty = 0.1
+	unroundPenalty  = 0.2
+	// for every optional attendee who can't make it
+	unavailablePenalty = 0.25
+)
+
+// freeTimeQuery describes the search of the slots
+type freeTimeQuery struct {
+	users []UID
+	// at least quorum of the optional users must be free
+	optional []UID
+	quorum   int
+	// resources which must be free
+	resources []ResourceId
+	// one of the rooms must be free if roomSearch is set, the preferred ones first
+	rooms      []ResourceId
+	roomSearch bool
+	// slots are placed in [start, end)
+	start, end time.Time
+	duration   time.Duration
+	limit      int
+	rank       rankMode
+	// slots start at multiples of the granularity from the midnight in the location if it is set
+	granularity time.Duration
+	location    *time.Location
+	// the allowed weekdays and hours of the day if it is set
+	window *WorkingHours
+	// meetings without response are tentative instead of busy
+	unknownTentative bool
+	// tentative meetings don't block the time
+	tentativeFree bool
+}
+
+// busyTimes keeps the periods the attendees are busy
+type busyTimes struct {
+	// meetings ordered by start and by end
+	byStart, byEnd []interval
+	// union of the meetings and the other unavailable periods ordered by start
+	merged []interval
+}
+
+func newBusyTimes(meetings, unavailable []interval) *busyTimes {
+	b := &busyTimes{
+		byStart: append([]interval(nil), meetings...),
+		byEnd:   append([]interval(nil), meetings...),
+	}
+	sort.Slice(b.byStart, func(i, j int) bool { return b.byStart[i].start.Before(b.byStart[j].start) })
+	sort.Slice(b.byEnd, func(i, j int) bool { return b.byEnd[i].end.Before(b.byEnd[j].end) })
+	b.merged = mergeIntervals(append(append([]interval(nil), meetings...), unavailable...))
+	return b
+}
+
+// returns union of the periods ordered by start, empty periods are skipped
+func mergeIntervals(periods []interval) []interval {
+	sorted := append([]interval(nil), periods...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].start.Before(sorted[j].start) })
+	merged := make([]interval, 0, len(sorted))
+	for _, p := range sorted {
+		if !p.end.After(p.start) {
+			continue
+		}
+		if last := len(merged) - 1; last >= 0 && !p.start.After(merged[last].end) {
+			if p.end.After(merged[last].end) {
+				merged[last].end = p.end
+			}
+			continue
+		}
+		merged = append(merged, p)
+	}
+	return merged
+}
+
+// returns parts of the merged periods not covered by the merged cut periods
+func subtractIntervals(periods, cut []interval) []interval {
+	result := make([]interval, 0, len(periods))
+	j := 0
+	for _, p := range periods {
+		for j < len(cut) && !cut[j].end.After(p.start) {
+			j++
+		}
+		for k := j; k < len(cut) && cut[k].start.Before(p.end); k++ {
+			if cut[k].start.After(p.start) {
+				result = append(result, interval{p.start, cut[k].start})
+			}
+			p.start = cut[k].end
+		}
+		if p.end.After(p.start) {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// returns number of periods ending shortly before the slot or starting shortly after it
+func (b *busyTimes) adjacent(slot interval) int {
+	from := sort.Search(len(b.byEnd), func(i int) bool { return !b.byEnd[i].end.Before(slot.start.Add(-adjacentGap)) })
+	to := sort.Search(len(b.byEnd), func(i int) bool { return b.byEnd[i].end.After(slot.start) })
+	count := to - from
+	from = sort.Search(len(b.byStart), func(i int) bool { return !b.byStart[i].start.Before(slot.end) })
+	to = sort.Search(len(b.byStart), func(i int) bool { return b.byStart[i].start.After(slot.end.Add(adjacentGap)) })
+	return count + to - from
+}
+
+// calls fn for every slot of the query which doesn't overlap busy periods,
+// in time order, until fn returns false. Slots start at the end of busy periods
+// and at multiples of the step or at multiples of the query granularity only.
+func (b *busyTimes) candidates(q freeTimeQuery, fn func(slot interval) bool) {
+	gapStart := q.start
+	emit := func(gapEnd time.Time) bool {
+		if gapEnd.After(q.end) {
+			gapEnd = q.end
+		}
+		t := gapStart
+		if q.granularity != 0 {
+			t = alignUp(t, q.granularity, q.location)
+		}
+		for !t.Add(q.duration).After(gapEnd) {
+			if !fn(interval{t, t.Add(q.duration)}) {
+				return false
+			}
+			if q.granularity != 0 {
+				t = t.Add(q.granularity)
+			} else {
+				t = t.Truncate(slotStep).Add(slotStep)
+			}
+		}
+		return true
+	}
+	for _, p := range b.merged {
+		if !p.end.After(gapStart) {
+			continue
+		}
+		if !gapStart.Before(q.end) {
+			return
+		}
+		if !emit(p.start) {
+			return
+		}
+		gapStart = p.end
+	}
+	emit(q.end)
+}
+
+// returns the first multiple of step from the midnight in loc not before t
+func alignUp(t time.Time, step time.Duration, loc *time.Location) time.Time {
+	if loc == nil {
+		loc = time.UTC
+	}
+	y, m, d := t.In(loc).Date()
+	if rem := t.Sub(time.Date(y, m, d, 0, 0, 0, 0, loc)) % step; rem != 0 {
+		t = t.Add(step - rem)
+	}
+	return t
+}
+
+// reports whether the slot doesn't overlap busy periods
+func (b *busyTimes) isFree(slot interval) bool {
+	i := sort.Search(len(b.merged), func(i int) bool { return b.merged[i].end.After(slot.start) })
+	return i == len(b.merged) || !b.merged[i].start.Before(slot.end)
+}
+
+// returns penalty of the slot, the lower the better
+func (b *busyTimes) penalty(slot interval, first time.Time) float64 {
+	p := slot.start.Sub(first).Hours() / 24
+	p += adjacentPenalty * float64(b.adjacent(slot))
+	switch {
+	case slot.start.Truncate(time.Hour).Equal(slot.start):
+	case slot.start.Truncate(30 * time.Minute).Equal(slot.start):
+		p += halfHourPenalty
+	default:
+		p += unroundPenalty
+	}
+	return p
+}
+
+// busyPeriod is an occurrence of the meeting blocking the user time
+type busyPeriod struct {
+	interval
+	tentative bool
+}
+
+// returns occurrences of the user meetings overlapping [start, end) ordered by start.
+// Rejected meetings don't block the time, the meetings without response
+// are tentative if unknownTentative is set and busy otherwise.
+func (s *Service) userBusyPeriods(userId UID, start, end time.Time, unknownTentative bool) ([]busyPeriod, error) {
+	meets, err := s.storage.UserMeetingsBetween(userId, start, end)
+	if err != nil {
+		return nil, err
+	}
+	periods := make([]busyPeriod, 0)
+	for _, meet := range meets {
+		tentative := false
+		switch meet.memberStatus(userId) {
+		case Rejected:
+			continue
+		case Tentative:
+			tentative = true
+		case Unknown:
+			tentative = unknownTentative
+		}
+		for _, o := range meet.occurrencesBetween(start, end) {
+			periods = append(periods, busyPeriod{interval{o.start, o.end()}, tentative})
+		}
+	}
+	sort.Slice(periods, func(i, j int) bool { return periods[i].start.Before(periods[j].start) })
+	return periods, nil
+}
+
+// returns meetings and off hours of the users overlapping the search period
+// together with the other unavailable periods
+func (s *Service) busyTimes(users []UID, q freeTimeQuery, unavailable []interval) (*busyTimes, error) {
+	start, end := q.start, q.end
+	meetings := make([]interval, 0)
+	unavailable = append([]interval(nil), unavailable...)
+	for _, userId := range users {
+		usr, err := s.storage.UserFindById(userId)
+		if err != nil {
+			return nil, err
+		}
+		if usr.WorkingHours != nil {
+			unavailable = append(unavailable, usr.WorkingHours.offHours(start, end)...)
+		}
+
+		periods, err := s.userBusyPeriods(userId, start, end, q.unknownTentative)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range periods {
+			if !p.tentative || !q.tentativeFree {
+				meetings = append(meetings, p.interval)
+			}
+		}
+	}
+	return newBusyTimes(meetings, unavailable), nil
+}
+
+// returns up to q.limit non-overlapping slots ordered by rank
+func (s *Service) findFreeSlots(q freeTimeQuery) ([]Slot, error) {
+	var unavailable []interval
+	if q.window != nil {
+		unavailable = q.window.offHours(q.start, q.end)
+	}
+	for _, id := range q.resources {
+		periods, err := s.resourceBusyPeriods(id, q.start, q.end)
+		if err != nil {
+			return nil, err
+		}
+		unavailable = append(unavailable, periods...)
+	}
+	busy, err := s.busyTimes(q.users, q, unavailable)
+	if err != nil {
+		return nil, err
+	}
+	if q.roomSearch && len(q.rooms) == 0 {
+		return []Slot{}, nil
+	}
+	rooms := make([]*busyTimes, 0, len(q.rooms))
+	for _, id := range q.rooms {
+		periods, err := s.resourceBusyPeriods(id, q.start, q.end)
+		if err != nil {
+			return nil, err
+		}
+		rooms = append(rooms, newBusyTimes(periods, nil))
+	}
+	optional := make([]*busyTimes, 0, len(q.optional))
+	for _, userId := range q.optional {
+		b, err := s.busyTimes([]UID{userId}, q, nil)
+		if err != nil {
+			return nil, err
+		}
+		optional = append(optional, b)
+	}
+	// returns the slot listing optional users who can't make it and the free room.
+	// Reports whether the quorum is met and a room is found if it is needed.
+	check := func(slot interval) (Slot, bool) {
+		result := Slot{StartAt: slot.start, EndAt: slot.end}
+		for i, b := range optional {
+			if !b.isFree(slot) {
+				result.Unavailable = append(result.Unavailable, q.optional[i])
+			}
+		}
+		if len(optional)-len(result.Unavailable) < q.quorum {
+			return result, false
+		}
+		if len(rooms) == 0 {
+			return result, true
+		}
+		for i, b := range rooms {
+			if b.isFree(slot) {
+				result.ResourceId = q.rooms[i]
+				return result, true
+			}
+		}
+		return result, false
+	}
+
+	picked := make([]Slot, 0, q.limit)
+	overlapsPicked := func(slot interval) bool {
+		for _, p := range picked {
+			if slot.start.Before(p.EndAt) && p.StartAt.Before(slot.end) {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch q.rank {
+	case rankEarliest:
+		busy.candidates(q, func(slot interval) bool {
+			if overlapsPicked(slot) {
+				return true
+			}
+			if c, ok := check(slot); ok {
+				picked = append(picked, c)
+			}
+			return len(picked) < q.limit
+		})
+	case rankScore:
+		type scored struct {
+			Slot
+			penalty float64
+		}
+		list := make([]scored, 0)
+		var first time.Time
+		busy.candidates(q, func(slot interval) bool {
+			c, ok := check(slot)
+			if !ok {
+				return true
+			}
+			if first.IsZero() {
+				first = slot.start
+			} else if slot.start.Sub(first) >= rankingPeriod {
+				return false
+			}
+			penalty := busy.penalty(slot, first) + unavailablePenalty*float64(len(c.Unavailable))
+			list = append(list, scored{c, penalty})
+			return true
+		})
+		sort.SliceStable(list, func(i, j int) bool { return list[i].penalty < list[j].penalty })
+		for _, c := range list {
+			if len(picked) == q.limit {
+				break
+			}
+			if !overlapsPicked(interval{c.StartAt, c.EndAt}) {
+				picked = append(picked, c.Slot)
+			}
+		}
+	}
+	return picked, nil
+}
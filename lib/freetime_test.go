@@ -0,0 +1,58 @@
+package lib
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestBusyTimesCandidates(t *testing.T) {
+	busy := newBusyTimes([]interval{
+		{getTestTime("2023-02-01T10:00:00Z"), getTestTime("2023-02-01T10:50:00Z")},
+		{getTestTime("2023-02-01T09:00:00Z"), getTestTime("2023-02-01T10:00:00Z")},
+		{getTestTime("2023-02-01T12:20:00Z"), getTestTime("2023-02-01T13:00:00Z")},
+		{getTestTime("2023-02-01T12:30:00Z"), getTestTime("2023-02-01T12:40:00Z")},
+	}, nil)
+	if len(busy.merged) != 2 {
+		t.Errorf("merged periods: expected 2, actual: %v", busy.merged)
+	}
+
+	q := freeTimeQuery{start: getTestTime("2023-02-01T09:30:00Z"), end: getTestTime("2023-02-01T14:10:00Z"), duration: time.Hour}
+	for _, test := range []struct {
+		granularity time.Duration
+		expected    []string
+	}{
+		{0, []string{"10:50", "11:00", "11:15", "13:00"}},
+		{30 * time.Minute, []string{"11:00", "13:00"}},
+		{20 * time.Minute, []string{"11:00", "11:20", "13:00"}},
+	} {
+		q.granularity = test.granularity
+		actual := make([]string, 0)
+		busy.candidates(q, func(slot interval) bool {
+			actual = append(actual, slot.start.Format("15:04"))
+			return true
+		})
+		if fmt.Sprint(actual) != fmt.Sprint(test.expected) {
+			t.Errorf("candidates with granularity %v: expected: %v, actual: %v", test.granularity, test.expected, actual)
+		}
+	}
+
+	slot := interval{getTestTime("2023-02-01T11:05:00Z"), getTestTime("2023-02-01T12:14:00Z")}
+	if n := busy.adjacent(slot); n != 2 {
+		t.Errorf("adjacent meetings: expected: 2, actual: %d", n)
+	}
+	slot = interval{getTestTime("2023-02-01T11:06:00Z"), getTestTime("2023-02-01T12:00:00Z")}
+	if n := busy.adjacent(slot); n != 0 {
+		t.Errorf("adjacent meetings: expected: 0, actual: %d", n)
+	}
+}
+
+func TestSubtractIntervals(t *testing.T) {
+	at := func(clock string) time.Time { return getTestTime("2023-02-01T" + clock + ":00Z") }
+	periods := []interval{{at("09:00"), at("12:00")}, {at("13:00"), at("14:00")}, {at("15:00"), at("16:00")}}
+	cut := []interval{{at("08:00"), at("09:30")}, {at("10:00"), at("10:30")}, {at("11:00"), at("13:30")}, {at("15:00"), at("16:00")}}
+	expected := []interval{{at("09:30"), at("10:00")}, {at("10:30"), at("11:00")}, {at("13:30"), at("14:00")}}
+	if actual := subtractIntervals(periods, cut); fmt.Sprint(actual) != fmt.Sprint(expected) {
+		t.Errorf("subtracted periods: expected: %v, actual: %v", expected, actual)
+	}
+}
@@ -0,0 +1,666 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ImportedEvent is the VEVENT saved as the meeting
+type ImportedEvent struct {
+	Uid       string
+	Summary   string `json:",omitempty"`
+	MeetingId MeetingId
+	// parts of the event which are dropped, e.g. the attendees not matching any user
+	Warnings []string `json:",omitempty"`
+}
+
+// SkippedEvent is the VEVENT which can't be represented as the meeting
+type SkippedEvent struct {
+	Uid     string
+	Summary string `json:",omitempty"`
+	Reason  string
+}
+
+// ImportResult reports the meetings created from the iCalendar object
+// and the events which are not imported
+type ImportResult struct {
+	Imported []ImportedEvent
+	Skipped  []SkippedEvent
+}
+
+var partStatToPresence = map[string]Presence{
+	"ACCEPTED":  Accepted,
+	"DECLINED":  Rejected,
+	"TENTATIVE": Tentative,
+}
+
+// @Summary     import calendar
+// @Description creates meetings from the events of the iCalendar object (RFC 5545) given in the body.
+// @Description Attendees and the organizer are matched to the users by name or email, rooms and resources to the resources by name.
+// @Description Simple RRULEs become the meeting period, the others the Custom rule. EXDATEs and the moved occurrences are kept.
+// @Description The events which can't be represented, e.g. all-day ones or the ones with RDATE, are skipped and reported.
+// @Accept      text/calendar
+// @Produce     application/json
+// @Param       creator_id path     uint32           false "ID of the user creating the meetings which organizer doesn't match any user"
+// @Param       calendar   body     string           true  "iCalendar object"
+// @Success     200        {object} lib.ImportResult "Imported and skipped events"
+// @Failure     400        {object} lib.Problem      "error description"
+// @Failure     403        {object} lib.Problem      "error description"
+// @Failure     404        {object} lib.Problem      "error description"
+// @Failure     500        {object} lib.Problem      "error description"
+// @Router      /calendar.ics [post]
+func (s *Service) calendarPostHandler(w http.ResponseWriter, r *http.Request) {
+	if err := parseForm(r); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	params := parameters{creatorIdTag: singleValue}
+	if err := checkArgs(&r.Form, params); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var creator UID
+	if _, ok := r.Form[creatorIdTag]; ok {
+		id, err := strconv.ParseUint(r.FormValue(creatorIdTag), 10, 32)
+		if err != nil {
+			writeError(w, r, paramError(creatorIdTag, err))
+			return
+		}
+		if err := s.authorize(r, creatorIdTag, UID(id)); err != nil {
+			writeError(w, r, err)
+			return
+		}
+		creator = UID(id)
+	}
+
+	canCreate := func(id UID) bool { return s.authorize(r, creatorIdTag, id) == nil }
+	res, err := s.importCalendar(r.Body, creator, canCreate)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	result, err := json.MarshalIndent(res, "", "  ")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.Header().Set(contentTypeTag, mimeJson)
+	w.Write(result)
+}
+
+// ImportCalendar creates meetings from the events of the iCalendar object.
+// The meetings which organizer doesn't match any user are created by creator,
+// such events are skipped if it is 0.
+// Possible errors:
+//
+//	ErrNotExist The creator is not found or deactivated.
+//	ErrParse    The data is not a valid iCalendar object.
+func (s *Service) ImportCalendar(r io.Reader, creator UID) (ImportResult, error) {
+	return s.importCalendar(r, creator, func(UID) bool { return true })
+}
+
+func (s *Service) importCalendar(r io.Reader, creator UID, canCreate func(UID) bool) (ImportResult, error) {
+	if creator != 0 {
+		if _, err := s.findActiveUser(creator); err != nil {
+			return ImportResult{}, fmt.Errorf("creator %d: %w", creator, err)
+		}
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return ImportResult{}, err
+	}
+	events, err := parseCalendar(string(data))
+	if err != nil {
+		return ImportResult{}, err
+	}
+	users, err := s.storage.UserList()
+	if err != nil {
+		return ImportResult{}, err
+	}
+	resources, err := s.storage.ResourceList()
+	if err != nil {
+		return ImportResult{}, err
+	}
+	imp := importer{service: s, creator: creator, canCreate: canCreate, resources: resources}
+	for _, u := range users {
+		if !u.Deactivated {
+			imp.users = append(imp.users, u)
+		}
+	}
+	return imp.run(events), nil
+}
+
+// importer converts the events to meetings
+type importer struct {
+	service   *Service
+	creator   UID
+	canCreate func(UID) bool
+	// active users and all resources attendees are matched to
+	users     []User
+	resources []Resource
+	warnings  []string
+}
+
+func (imp *importer) run(events []icsEvent) ImportResult {
+	res := ImportResult{Imported: make([]ImportedEvent, 0), Skipped: make([]SkippedEvent, 0)}
+	// the moved occurrences are kept with the recurring event of the same UID
+	masters := make(map[string]int)
+	for i, e := range events {
+		if _, ok := e.get("RECURRENCE-ID"); !ok && e.uid() != "" {
+			if _, ok := masters[e.uid()]; ok {
+				res.Skipped = append(res.Skipped, SkippedEvent{e.uid(), e.text("SUMMARY"), "UID is not unique"})
+				continue
+			}
+			masters[e.uid()] = i
+		}
+	}
+	overrides := make(map[int][]icsEvent)
+	for _, e := range events {
+		if _, ok := e.get("RECURRENCE-ID"); !ok {
+			continue
+		}
+		i, ok := masters[e.uid()]
+		if !ok {
+			res.Skipped = append(res.Skipped, SkippedEvent{e.uid(), e.text("SUMMARY"), "the recurring event of the moved occurrence is not found"})
+			continue
+		}
+		overrides[i] = append(overrides[i], e)
+	}
+
+	for i, e := range events {
+		if _, ok := e.get("RECURRENCE-ID"); ok {
+			continue
+		}
+		if j, ok := masters[e.uid()]; ok && j != i {
+			continue
+		}
+		imp.warnings = nil
+		id, err := imp.add(e, overrides[i])
+		if err != nil {
+			res.Skipped = append(res.Skipped, SkippedEvent{e.uid(), e.text("SUMMARY"), err.Error()})
+			continue
+		}
+		res.Imported = append(res.Imported, ImportedEvent{e.uid(), e.text("SUMMARY"), id, imp.warnings})
+	}
+	return res
+}
+
+func (imp *importer) warn(format string, a ...interface{}) {
+	imp.warnings = append(imp.warnings, fmt.Sprintf(format, a...))
+}
+
+// saves the event and the moved occurrences as the meeting
+func (imp *importer) add(e icsEvent, overrides []icsEvent) (MeetingId, error) {
+	if strings.EqualFold(e.value("STATUS"), "CANCELLED") {
+		return 0, fmt.Errorf("the event is cancelled")
+	}
+	for _, name := range []string{"RDATE", "EXRULE"} {
+		if _, ok := e.get(name); ok {
+			return 0, fmt.Errorf("%s is not supported", name)
+		}
+	}
+
+	var m Meeting
+	var err error
+	dtstart, ok := e.get("DTSTART")
+	if !ok {
+		return 0, fmt.Errorf("DTSTART is required")
+	}
+	if m.FirstOccurence, m.TimeZone, err = parseIcsTime(dtstart); err != nil {
+		return 0, fmt.Errorf("DTSTART: %v", err)
+	}
+	if m.Duration.Duration, err = e.duration(m.FirstOccurence); err != nil {
+		return 0, err
+	}
+
+	if rules := e.props["RRULE"]; len(rules) > 1 {
+		return 0, fmt.Errorf("several RRULEs are not supported")
+	} else if len(rules) == 1 {
+		rule, err := ParseRecurrence(rules[0].value)
+		if err != nil {
+			return 0, fmt.Errorf("RRULE: %v", err)
+		}
+		var ok bool
+		if m.Repeat, ok = rulePeriod(rule, m.FirstOccurence.In(m.location())); !ok {
+			m.Rule = &rule
+		}
+	}
+
+	if err := imp.participants(e, &m); err != nil {
+		return 0, err
+	}
+	if m.recurrence() != nil {
+		imp.exceptions(e, overrides, &m)
+	}
+	if err := imp.service.checkResources(m); err != nil {
+		return 0, err
+	}
+	return imp.service.storage.MeetingAdd(m.MeetingInfo)
+}
+
+// sets the creator, the members and the resources of the meeting
+func (imp *importer) participants(e icsEvent, m *Meeting) error {
+	m.CreatorId = imp.creator
+	if org, ok := e.get("ORGANIZER"); ok {
+		if u, ok := imp.user(org); ok {
+			m.CreatorId = u.Id
+		} else if imp.creator != 0 {
+			imp.warn("organizer %s doesn't match any user", attendeeName(org))
+		}
+	}
+	if m.CreatorId == 0 {
+		return fmt.Errorf("the organizer doesn't match any user and the creator is not given")
+	}
+	if !imp.canCreate(m.CreatorId) {
+		return fmt.Errorf("the meeting can't be created on behalf of user %d: %v", m.CreatorId, ErrForbidden)
+	}
+
+	attendees := e.props["ATTENDEE"]
+	if len(attendees) == 0 {
+		// the event of the organizer only
+		m.Members = []Participant{{UserId: m.CreatorId}}
+		return nil
+	}
+	m.Members = make([]Participant, 0, len(attendees))
+	for _, a := range attendees {
+		switch strings.ToUpper(a.params["CUTYPE"]) {
+		case "ROOM", "RESOURCE":
+			if res, ok := imp.resource(a); ok {
+				if !m.hasResource(res.Id) {
+					m.Resources = append(m.Resources, res.Id)
+				}
+			} else {
+				imp.warn("resource %s doesn't match any resource", attendeeName(a))
+			}
+			continue
+		case "GROUP":
+			imp.warn("group %s is not supported", attendeeName(a))
+			continue
+		}
+		role := Required
+		switch strings.ToUpper(a.params["ROLE"]) {
+		case "NON-PARTICIPANT":
+			continue
+		case "OPT-PARTICIPANT":
+			role = Optional
+		}
+		u, ok := imp.user(a)
+		if !ok {
+			imp.warn("attendee %s doesn't match any user", attendeeName(a))
+			continue
+		}
+		if !m.hasMember(u.Id) {
+			status := partStatToPresence[strings.ToUpper(a.params["PARTSTAT"])]
+			m.Members = append(m.Members, Participant{UserId: u.Id, Status: status, Role: role})
+		}
+	}
+	if len(m.Members) == 0 {
+		return fmt.Errorf("none of the attendees matches a user")
+	}
+	return nil
+}
+
+// adds EXDATEs and the moved occurrences to the recurring meeting
+func (imp *importer) exceptions(e icsEvent, overrides []icsEvent, m *Meeting) {
+	for _, p := range e.props["EXDATE"] {
+		for _, v := range strings.Split(p.value, ",") {
+			t, _, err := parseIcsTime(icsProperty{p.name, p.params, v})
+			if err != nil {
+				imp.warn("EXDATE %s: %v", v, err)
+			} else if !m.isScheduledAt(t) {
+				imp.warn("EXDATE %s is not an occurrence of the event", v)
+			} else if !m.isException(t) {
+				m.ExDates = append(m.ExDates, t)
+			}
+		}
+	}
+	for _, o := range overrides {
+		rid, _ := o.get("RECURRENCE-ID")
+		recurrenceId, _, err := parseIcsTime(rid)
+		if err != nil {
+			imp.warn("RECURRENCE-ID %s: %v", rid.value, err)
+			continue
+		}
+		if !m.isScheduledAt(recurrenceId) {
+			imp.warn("RECURRENCE-ID %s is not an occurrence of the event", rid.value)
+			continue
+		}
+		if strings.EqualFold(rid.params["RANGE"], "THISANDFUTURE") {
+			imp.warn("RECURRENCE-ID %s: RANGE is not supported", rid.value)
+			continue
+		}
+		if m.isException(recurrenceId) {
+			continue
+		}
+		if strings.EqualFold(o.value("STATUS"), "CANCELLED") {
+			m.ExDates = append(m.ExDates, recurrenceId)
+			continue
+		}
+		startAt := recurrenceId
+		if p, ok := o.get("DTSTART"); ok {
+			if startAt, _, err = parseIcsTime(p); err != nil {
+				imp.warn("RECURRENCE-ID %s: DTSTART: %v", rid.value, err)
+				continue
+			}
+		}
+		duration := m.Duration.Duration
+		if _, ok := o.get("DTEND"); ok {
+			duration, err = o.duration(startAt)
+		} else if _, ok := o.get("DURATION"); ok {
+			duration, err = o.duration(startAt)
+		}
+		if err != nil {
+			imp.warn("RECURRENCE-ID %s: %v", rid.value, err)
+			continue
+		}
+		m.Overrides = append(m.Overrides, Override{RecurrenceId: recurrenceId, StartAt: startAt, Duration: Duration{duration}})
+	}
+}
+
+// returns the user matching the attendee by name or, if it isn't unique, by email
+func (imp *importer) user(a icsProperty) (User, bool) {
+	var found []User
+	if name := strings.TrimSpace(a.params["CN"]); name != "" {
+		for _, u := range imp.users {
+			if strings.EqualFold(u.Name, name) {
+				found = append(found, u)
+			}
+		}
+	}
+	if len(found) == 1 {
+		return found[0], true
+	}
+	email := a.value
+	if len(email) > len("mailto:") && strings.EqualFold(email[:len("mailto:")], "mailto:") {
+		email = email[len("mailto:"):]
+	}
+	for _, u := range imp.users {
+		if u.Email != "" && strings.EqualFold(u.Email, email) {
+			return u, true
+		}
+	}
+	return User{}, false
+}
+
+// returns the resource matching the attendee by name
+func (imp *importer) resource(a icsProperty) (Resource, bool) {
+	name := strings.TrimSpace(a.params["CN"])
+	for _, res := range imp.resources {
+		if name != "" && strings.EqualFold(res.Name, name) {
+			return res, true
+		}
+	}
+	return Resource{}, false
+}
+
+// returns the name of the attendee for the report
+func attendeeName(a icsProperty) string {
+	if name := a.params["CN"]; name != "" {
+		return strconv.Quote(name)
+	}
+	return a.value
+}
+
+// returns the Period the rule repeats by if it is that simple
+func rulePeriod(rule Recurrence, start time.Time) (Period, bool) {
+	// the week day or the month day of the start time is repeated anyway
+	if rule.Freq == Weekly && len(rule.ByDay) == 1 && rule.ByDay[0] == (WeekdayNum{Day: start.Weekday()}) {
+		rule.ByDay = nil
+	}
+	if rule.Freq == Monthly && len(rule.ByMonthDay) == 1 && rule.ByMonthDay[0] == start.Day() {
+		rule.ByMonthDay = nil
+	}
+	for _, p := range []Period{EveryDay, EveryWeek, EveryMonth, EveryYear} {
+		if p.recurrence().String() == rule.String() {
+			return p, true
+		}
+	}
+	return Custom, false
+}
+
+// icsProperty is the content line of the iCalendar object
+type icsProperty struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// icsEvent keeps the properties of VEVENT by name
+type icsEvent struct {
+	props map[string][]icsProperty
+}
+
+func (e icsEvent) get(name string) (icsProperty, bool) {
+	if p := e.props[name]; len(p) != 0 {
+		return p[0], true
+	}
+	return icsProperty{}, false
+}
+
+func (e icsEvent) value(name string) string {
+	p, _ := e.get(name)
+	return p.value
+}
+
+// returns the TEXT property value unescaped
+func (e icsEvent) text(name string) string {
+	return unescapeText(e.value(name))
+}
+
+func (e icsEvent) uid() string {
+	return e.text("UID")
+}
+
+// returns the event duration given by DTEND or DURATION
+func (e icsEvent) duration(start time.Time) (time.Duration, error) {
+	var d time.Duration
+	if p, ok := e.get("DTEND"); ok {
+		end, _, err := parseIcsTime(p)
+		if err != nil {
+			return 0, fmt.Errorf("DTEND: %v", err)
+		}
+		d = end.Sub(start)
+	} else if p, ok := e.get("DURATION"); ok {
+		var err error
+		if d, err = parseIcsDuration(p.value); err != nil {
+			return 0, fmt.Errorf("DURATION: %v", err)
+		}
+	} else {
+		return 0, fmt.Errorf("DTEND or DURATION is required")
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("the event ends before it starts")
+	}
+	return d, nil
+}
+
+// returns VEVENTs of the iCalendar object. The nested components, e.g. VALARM, are skipped.
+func parseCalendar(data string) ([]icsEvent, error) {
+	data = strings.ReplaceAll(data, "\r\n", "\n")
+	data = strings.NewReplacer("\n ", "", "\n\t", "").Replace(data)
+	events := make([]icsEvent, 0)
+	var components []string
+	var event *icsEvent
+	found := false
+	for n, line := range strings.Split(data, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		p, err := parseContentLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n+1, err)
+		}
+		switch p.name {
+		case "BEGIN":
+			name := strings.ToUpper(p.value)
+			if len(components) == 0 && name != "VCALENDAR" {
+				return nil, fmt.Errorf("line %d: VCALENDAR is expected: %w", n+1, ErrParse)
+			}
+			components = append(components, name)
+			if name == "VEVENT" && len(components) == 2 {
+				event = &icsEvent{props: make(map[string][]icsProperty)}
+			}
+			found = true
+		case "END":
+			name := strings.ToUpper(p.value)
+			if len(components) == 0 || components[len(components)-1] != name {
+				return nil, fmt.Errorf("line %d: END:%s doesn't match BEGIN: %w", n+1, p.value, ErrParse)
+			}
+			components = components[:len(components)-1]
+			if name == "VEVENT" && event != nil && len(components) == 1 {
+				events = append(events, *event)
+				event = nil
+			}
+		default:
+			if len(components) == 0 {
+				return nil, fmt.Errorf("line %d: VCALENDAR is expected: %w", n+1, ErrParse)
+			}
+			if event != nil && len(components) == 2 {
+				event.props[p.name] = append(event.props[p.name], p)
+			}
+		}
+	}
+	if !found || len(components) != 0 {
+		return nil, fmt.Errorf("VCALENDAR is not complete: %w", ErrParse)
+	}
+	return events, nil
+}
+
+// parses the unfolded content line "NAME;PARAM=VALUE:value"
+func parseContentLine(line string) (icsProperty, error) {
+	i := strings.IndexAny(line, ";:")
+	if i <= 0 {
+		return icsProperty{}, fmt.Errorf("property name is expected: %w", ErrParse)
+	}
+	p := icsProperty{name: strings.ToUpper(line[:i]), params: make(map[string]string)}
+	rest := line[i:]
+	for strings.HasPrefix(rest, ";") {
+		rest = rest[1:]
+		eq := strings.IndexByte(rest, '=')
+		if eq <= 0 {
+			return p, fmt.Errorf("%s: parameter name is expected: %w", p.name, ErrParse)
+		}
+		name := strings.ToUpper(rest[:eq])
+		rest = rest[eq+1:]
+		var value strings.Builder
+		for {
+			if strings.HasPrefix(rest, `"`) {
+				end := strings.IndexByte(rest[1:], '"')
+				if end < 0 {
+					return p, fmt.Errorf("%s: parameter %s is not closed: %w", p.name, name, ErrParse)
+				}
+				value.WriteString(rest[1 : end+1])
+				rest = rest[end+2:]
+			} else {
+				end := strings.IndexAny(rest, ",;:")
+				if end < 0 {
+					end = len(rest)
+				}
+				value.WriteString(rest[:end])
+				rest = rest[end:]
+			}
+			if !strings.HasPrefix(rest, ",") {
+				break
+			}
+			value.WriteByte(',')
+			rest = rest[1:]
+		}
+		p.params[name] = value.String()
+	}
+	if !strings.HasPrefix(rest, ":") {
+		return p, fmt.Errorf("%s: value is expected: %w", p.name, ErrParse)
+	}
+	p.value = rest[1:]
+	return p, nil
+}
+
+// parses DATE-TIME property value. Returns UTC time and the time zone it is
+// given in, empty for UTC and the floating time which is treated as UTC.
+func parseIcsTime(p icsProperty) (time.Time, string, error) {
+	if strings.EqualFold(p.params["VALUE"], "DATE") || len(p.value) == len("20060102") {
+		return time.Time{}, "", fmt.Errorf("all-day events are not supported")
+	}
+	if tzid, ok := p.params["TZID"]; ok {
+		tzid = strings.TrimPrefix(tzid, "/")
+		loc, err := loadLocation(tzid)
+		if err != nil {
+			return time.Time{}, "", err
+		}
+		t, err := time.ParseInLocation(icsDateTime, p.value, loc)
+		if err != nil {
+			return time.Time{}, "", parseError(err)
+		}
+		if loc == time.UTC {
+			tzid = ""
+		}
+		return t.UTC(), tzid, nil
+	}
+	layout := icsDateTime
+	if strings.HasSuffix(p.value, "Z") {
+		layout = icsUtcDateTime
+	}
+	t, err := time.Parse(layout, p.value)
+	if err != nil {
+		return time.Time{}, "", parseError(err)
+	}
+	return t, "", nil
+}
+
+// parses DURATION value, e.g. "PT1H30M" or "P1W"
+func parseIcsDuration(s string) (time.Duration, error) {
+	v := strings.TrimPrefix(s, "+")
+	if !strings.HasPrefix(v, "P") || len(v) == 1 {
+		return 0, fmt.Errorf("%q: %w", s, ErrParse)
+	}
+	v = v[1:]
+	var d time.Duration
+	inTime := false
+	for v != "" {
+		if v[0] == 'T' && !inTime {
+			inTime = true
+			v = v[1:]
+			continue
+		}
+		i := 0
+		for i < len(v) && v[i] >= '0' && v[i] <= '9' {
+			i++
+		}
+		if i == 0 || i == len(v) {
+			return 0, fmt.Errorf("%q: %w", s, ErrParse)
+		}
+		n, err := strconv.Atoi(v[:i])
+		if err != nil {
+			return 0, parseError(err)
+		}
+		var unit time.Duration
+		switch c := v[i]; {
+		case c == 'W' && !inTime:
+			unit = 7 * 24 * time.Hour
+		case c == 'D' && !inTime:
+			unit = 24 * time.Hour
+		case c == 'H' && inTime:
+			unit = time.Hour
+		case c == 'M' && inTime:
+			unit = time.Minute
+		case c == 'S' && inTime:
+			unit = time.Second
+		default:
+			return 0, fmt.Errorf("%q: %w", s, ErrParse)
+		}
+		d += time.Duration(n) * unit
+		v = v[i+1:]
+	}
+	return d, nil
+}
+
+// unescapes TEXT property value
+func unescapeText(s string) string {
+	return strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n").Replace(s)
+}
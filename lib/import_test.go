@@ -0,0 +1,71 @@
+package lib
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseContentLine(t *testing.T) {
+	p, err := parseContentLine(`attendee;CN="Doe, Jane";ROLE=OPT-PARTICIPANT;DELEGATED-TO="mailto:a@b.c","mailto:d@e.f":mailto:jane@example.com`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.name != "ATTENDEE" || p.value != "mailto:jane@example.com" {
+		t.Errorf("name or value: %q %q", p.name, p.value)
+	}
+	for name, expected := range map[string]string{"CN": "Doe, Jane", "ROLE": "OPT-PARTICIPANT", "DELEGATED-TO": "mailto:a@b.c,mailto:d@e.f"} {
+		if actual := p.params[name]; actual != expected {
+			t.Errorf("%s: expected: %q, actual: %q", name, expected, actual)
+		}
+	}
+
+	for _, line := range []string{"no value", ":value", `X;CN="open:value`, "X;=a:b", "X;CN=a"} {
+		if _, err := parseContentLine(line); err == nil {
+			t.Errorf("%q: error expected", line)
+		}
+	}
+}
+
+func TestParseIcsDuration(t *testing.T) {
+	for s, expected := range map[string]time.Duration{
+		"PT1H30M":  90 * time.Minute,
+		"+PT45S":   45 * time.Second,
+		"P1D":      24 * time.Hour,
+		"P1W":      7 * 24 * time.Hour,
+		"P1DT2H":   26 * time.Hour,
+		"PT0H15M0": -1,
+		"P1H":      -1,
+		"-PT1H":    -1,
+		"P":        -1,
+	} {
+		actual, err := parseIcsDuration(s)
+		if expected < 0 {
+			if err == nil {
+				t.Errorf("%q: error expected", s)
+			}
+		} else if err != nil || actual != expected {
+			t.Errorf("%q: expected: %v, actual: %v %v", s, expected, actual, err)
+		}
+	}
+}
+
+func TestRulePeriod(t *testing.T) {
+	monday := getTestTime("2023-03-20T09:00:00Z")
+	for s, expected := range map[string]Period{
+		"FREQ=DAILY":                 EveryDay,
+		"FREQ=WEEKLY;BYDAY=MO":       EveryWeek,
+		"FREQ=WEEKLY;BYDAY=TU":       Custom,
+		"FREQ=WEEKLY;INTERVAL=2":     Custom,
+		"FREQ=MONTHLY;BYMONTHDAY=20": EveryMonth,
+		"FREQ=MONTHLY;COUNT=3":       Custom,
+		"FREQ=YEARLY;INTERVAL=1":     EveryYear,
+	} {
+		rule, err := ParseRecurrence(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual, ok := rulePeriod(rule, monday); actual != expected || ok != (expected != Custom) {
+			t.Errorf("%q: expected: %v, actual: %v", s, expected, actual)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package lib
+
+import (
+	"math/rand"
+	"time"
+)
+
+// meetingIndex keeps meetings of a single user ordered by time.
+// One-off meetings are kept in the interval tree, so looking up the meetings
+// of a period costs O(log(n) + k). Recurring series can't be placed on the time
+// line, they are kept aside and checked one by one.
+type meetingIndex struct {
+	once   *intervalNode
+	spans  map[MeetingId]interval
+	series map[MeetingId]bool
+}
+
+type interval struct {
+	start, end time.Time
+}
+
+func newMeetingIndex() *meetingIndex {
+	return &meetingIndex{
+		spans:  make(map[MeetingId]interval),
+		series: make(map[MeetingId]bool),
+	}
+}
+
+func (x *meetingIndex) add(m Meeting) {
+	x.remove(m.Id)
+	if m.recurrence() != nil {
+		x.series[m.Id] = true
+		return
+	}
+	span := interval{m.FirstOccurence, m.FirstOccurence.Add(m.Duration.Duration)}
+	x.spans[m.Id] = span
+	x.once = x.once.insert(&intervalNode{interval: span, id: m.Id, maxEnd: span.end, priority: rand.Uint32()})
+}
+
+func (x *meetingIndex) remove(id MeetingId) {
+	if x.series[id] {
+		delete(x.series, id)
+		return
+	}
+	if span, ok := x.spans[id]; ok {
+		delete(x.spans, id)
+		x.once = x.once.delete(span.start, id)
+	}
+}
+
+// returns ids of all indexed meetings
+func (x *meetingIndex) ids() []MeetingId {
+	ids := make([]MeetingId, 0, len(x.spans)+len(x.series))
+	for id := range x.spans {
+		ids = append(ids, id)
+	}
+	for id := range x.series {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// calls fn for every one-off meeting overlapping the period [start, end)
+// and for every recurring series which has to be checked by the caller
+func (x *meetingIndex) between(start, end time.Time, once, series func(id MeetingId)) {
+	x.once.query(start, end, once)
+	for id := range x.series {
+		series(id)
+	}
+}
+
+// intervalNode is a node of the treap ordered by interval start and meeting id.
+// Every node keeps the latest end of the intervals in its subtree.
+type intervalNode struct {
+	interval
+	id          MeetingId
+	maxEnd      time.Time
+	priority    uint32
+	left, right *intervalNode
+}
+
+func (n *intervalNode) less(start time.Time, id MeetingId) bool {
+	return n.start.Before(start) || n.start.Equal(start) && n.id < id
+}
+
+func (n *intervalNode) update() {
+	n.maxEnd = n.end
+	if n.left != nil && n.left.maxEnd.After(n.maxEnd) {
+		n.maxEnd = n.left.maxEnd
+	}
+	if n.right != nil && n.right.maxEnd.After(n.maxEnd) {
+		n.maxEnd = n.right.maxEnd
+	}
+}
+
+// splits the tree into nodes less than (start, id) and the rest
+func (n *intervalNode) split(start time.Time, id MeetingId) (*intervalNode, *intervalNode) {
+	if n == nil {
+		return nil, nil
+	}
+	if n.less(start, id) {
+		l, r := n.right.split(start, id)
+		n.right = l
+		n.update()
+		return n, r
+	}
+	l, r := n.left.split(start, id)
+	n.left = r
+	n.update()
+	return l, n
+}
+
+// merges trees where all nodes of l are less than nodes of r
+func merge(l, r *intervalNode) *intervalNode {
+	if l == nil {
+		return r
+	}
+	if r == nil {
+		return l
+	}
+	if l.priority > r.priority {
+		l.right = merge(l.right, r)
+		l.update()
+		return l
+	}
+	r.left = merge(l, r.left)
+	r.update()
+	return r
+}
+
+func (n *intervalNode) insert(node *intervalNode) *intervalNode {
+	l, r := n.split(node.start, node.id)
+	return merge(merge(l, node), r)
+}
+
+func (n *intervalNode) delete(start time.Time, id MeetingId) *intervalNode {
+	if n == nil {
+		return nil
+	}
+	if n.start.Equal(start) && n.id == id {
+		return merge(n.left, n.right)
+	}
+	if n.less(start, id) {
+		n.right = n.right.delete(start, id)
+	} else {
+		n.left = n.left.delete(start, id)
+	}
+	n.update()
+	return n
+}
+
+func (n *intervalNode) query(start, end time.Time, fn func(id MeetingId)) {
+	if n == nil || !n.maxEnd.After(start) {
+		return
+	}
+	n.left.query(start, end, fn)
+	// the node and its right subtree begin after the period
+	if !n.start.Before(end) {
+		return
+	}
+	if n.end.After(start) {
+		fn(n.id)
+	}
+	n.right.query(start, end, fn)
+}
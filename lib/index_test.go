@@ -0,0 +1,123 @@
+package lib
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestMeetingIndex(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	base := time.Date(2022, time.November, 1, 0, 0, 0, 0, time.UTC)
+	meets := make(map[MeetingId]Meeting)
+	index := newMeetingIndex()
+	for i := 1; i <= 2000; i++ {
+		m := randomMeeting(rnd, base, MeetingId(i))
+		meets[m.Id] = m
+		index.add(m)
+	}
+	// move and remove some of them
+	for i := 1; i <= 2000; i += 7 {
+		m := randomMeeting(rnd, base, MeetingId(i))
+		meets[m.Id] = m
+		index.add(m)
+	}
+	for i := 3; i <= 2000; i += 11 {
+		delete(meets, MeetingId(i))
+		index.remove(MeetingId(i))
+	}
+
+	for i := 0; i < 200; i++ {
+		start := base.Add(time.Duration(rnd.Int63n(int64(60 * 24 * time.Hour))))
+		end := start.Add(time.Duration(rnd.Int63n(int64(48 * time.Hour))))
+
+		expected := make([]MeetingId, 0)
+		for _, m := range meets {
+			if m.takesPlaceBetween(start, end) {
+				expected = append(expected, m.Id)
+			}
+		}
+		actual := make([]MeetingId, 0)
+		index.between(start, end,
+			func(id MeetingId) { actual = append(actual, id) },
+			func(id MeetingId) {
+				if meets[id].takesPlaceBetween(start, end) {
+					actual = append(actual, id)
+				}
+			})
+
+		sort.Slice(expected, func(i, j int) bool { return expected[i] < expected[j] })
+		sort.Slice(actual, func(i, j int) bool { return actual[i] < actual[j] })
+		if fmt.Sprint(expected) != fmt.Sprint(actual) {
+			t.Fatalf("meetings in period %v - %v: expected: %v, actual: %v", start, end, expected, actual)
+		}
+	}
+}
+
+func BenchmarkUserMeetingsScan(b *testing.B) {
+	for _, count := range []int{1000, 5000, 20000} {
+		b.Run(fmt.Sprintf("meetings-%d", count), func(b *testing.B) {
+			s, start := benchmarkStorage(count)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				from := start.Add(time.Duration(i%365) * 24 * time.Hour)
+				to := from.Add(24 * time.Hour)
+				meets, _ := s.UserMeetings(1)
+				found := 0
+				for _, m := range meets {
+					if m.takesPlaceBetween(from, to) {
+						found++
+					}
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkUserMeetingsIndex(b *testing.B) {
+	for _, count := range []int{1000, 5000, 20000} {
+		b.Run(fmt.Sprintf("meetings-%d", count), func(b *testing.B) {
+			s, start := benchmarkStorage(count)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				from := start.Add(time.Duration(i%365) * 24 * time.Hour)
+				to := from.Add(24 * time.Hour)
+				s.UserMeetingsBetween(1, from, to)
+			}
+		})
+	}
+}
+
+// returns storage with a single user taking part in count meetings
+// spread over a year, a few of them are recurring
+func benchmarkStorage(count int) (*memoryStorage, time.Time) {
+	rnd := rand.New(rand.NewSource(1))
+	start := time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)
+	s := newMemoryStorage()
+	id, _ := s.UserAdd(UserInfo{Name: "John Doe"})
+	for i := 0; i < count; i++ {
+		m := MeetingInfo{
+			Members:        []Participant{{UserId: id}},
+			CreatorId:      id,
+			FirstOccurence: start.Add(time.Duration(rnd.Int63n(int64(365 * 24 * time.Hour)))).Truncate(15 * time.Minute),
+			Duration:       Duration{time.Duration(1+rnd.Intn(8)) * 15 * time.Minute},
+		}
+		if i%100 == 0 {
+			m.Repeat = EveryWeek
+		}
+		s.MeetingAdd(m)
+	}
+	return s, start
+}
+
+func randomMeeting(rnd *rand.Rand, base time.Time, id MeetingId) Meeting {
+	m := Meeting{Id: id}
+	m.FirstOccurence = base.Add(time.Duration(rnd.Int63n(int64(60 * 24 * time.Hour))))
+	m.Duration = Duration{time.Duration(rnd.Int63n(int64(6 * time.Hour)))}
+	if rnd.Intn(20) == 0 {
+		m.Repeat = EveryDay
+	}
+	return m
+}
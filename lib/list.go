@@ -0,0 +1,263 @@
+package lib
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	sortTag        = "sort"
+	cursorTag      = "cursor"
+	memberIdTag    = "member_id"
+	deactivatedTag = "deactivated"
+	sortById       = "id"
+	sortByName     = "name"
+	sortByStart    = "start_at"
+	// the page can't be longer
+	maxListLimit = 1000
+	// fixed width layout sorting the times as strings
+	sortTimeLayout = "2006-01-02T15:04:05.000000000Z"
+)
+
+// listCursor is the position in the sorted list, the next page starts after it.
+// The records are ordered by Key and then by Id.
+type listCursor struct {
+	Sort string
+	Key  string
+	Id   uint32
+}
+
+func (c listCursor) less(o listCursor) bool {
+	return c.Key < o.Key || c.Key == o.Key && c.Id < o.Id
+}
+
+func (c listCursor) String() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func parseListCursor(s string) (listCursor, error) {
+	var c listCursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, err
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, err
+	}
+	return c, nil
+}
+
+// listPage tells which part of the sorted list is returned
+type listPage struct {
+	sort string
+	// the first page is returned if it is nil
+	after *listCursor
+	// all the records are returned if it is 0
+	limit int
+}
+
+// parses the page parameters, sorts lists the allowed orders and the first one is the default
+func parseListPage(form url.Values, sorts ...string) (listPage, error) {
+	p := listPage{sort: sorts[0]}
+	if v, ok := form[sortTag]; ok {
+		found := false
+		for _, s := range sorts {
+			found = found || s == v[0]
+		}
+		if !found {
+			return p, &ParamError{Param: sortTag, Err: fmt.Errorf("unknown value %q, expected one of %s: %w", v[0], strings.Join(sorts, ", "), ErrParse)}
+		}
+		p.sort = v[0]
+	}
+	if _, ok := form[limitTag]; ok {
+		limit, err := strconv.ParseUint(form.Get(limitTag), 10, 32)
+		if err != nil || limit == 0 || limit > maxListLimit {
+			return p, &ParamError{Param: limitTag, Err: fmt.Errorf("must be from 1 to %d: %w", maxListLimit, ErrParse)}
+		}
+		p.limit = int(limit)
+	}
+	if _, ok := form[cursorTag]; ok {
+		c, err := parseListCursor(form.Get(cursorTag))
+		if err != nil {
+			return p, paramError(cursorTag, err)
+		}
+		if c.Sort != p.sort {
+			return p, &ParamError{Param: cursorTag, Err: fmt.Errorf("the cursor is given for %q order: %w", c.Sort, ErrParse)}
+		}
+		p.after = &c
+	}
+	return p, nil
+}
+
+// sorts the records by their keys and returns indexes of the records on the page
+// and the cursor of the next page, nil if it is the last one
+func (p listPage) cut(keys []listCursor) ([]int, *listCursor) {
+	order := make([]int, 0, len(keys))
+	for i := range keys {
+		if p.after == nil || p.after.less(keys[i]) {
+			order = append(order, i)
+		}
+	}
+	sort.Slice(order, func(i, j int) bool { return keys[order[i]].less(keys[order[j]]) })
+	if p.limit == 0 || len(order) <= p.limit {
+		return order, nil
+	}
+	order = order[:p.limit]
+	next := keys[order[len(order)-1]]
+	return order, &next
+}
+
+// writes the page of the list. The link to the next page is given in the Link header.
+func writeList(w http.ResponseWriter, r *http.Request, page interface{}, next *listCursor) {
+	result, err := json.MarshalIndent(page, "", "  ")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if next != nil {
+		query := r.URL.Query()
+		query.Set(cursorTag, next.String())
+		link := url.URL{Path: r.URL.Path, RawQuery: query.Encode()}
+		w.Header().Add(linkTag, fmt.Sprintf("<%s>; rel=\"next\"", link.String()))
+	}
+	w.Header().Set(contentTypeTag, mimeJson)
+	w.Write(result)
+}
+
+// writes the page of the users matching the name and deactivated filters
+func (s *Service) userList(w http.ResponseWriter, r *http.Request) {
+	page, err := parseListPage(r.Form, sortById, sortByName)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	name := strings.ToLower(r.FormValue(nameTag))
+	var deactivated *bool
+	if _, ok := r.Form[deactivatedTag]; ok {
+		v, err := strconv.ParseBool(r.FormValue(deactivatedTag))
+		if err != nil {
+			writeError(w, r, paramError(deactivatedTag, err))
+			return
+		}
+		deactivated = &v
+	}
+
+	list, err := s.storage.UserList()
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	users := make([]User, 0, len(list))
+	keys := make([]listCursor, 0, len(list))
+	for _, u := range list {
+		if !strings.Contains(strings.ToLower(u.Name), name) || deactivated != nil && u.Deactivated != *deactivated {
+			continue
+		}
+		key := listCursor{Sort: page.sort, Id: uint32(u.Id)}
+		if page.sort == sortByName {
+			key.Key = strings.ToLower(u.Name)
+		}
+		users = append(users, u)
+		keys = append(keys, key)
+	}
+
+	order, next := page.cut(keys)
+	result := make([]User, 0, len(order))
+	for _, i := range order {
+		result = append(result, users[i])
+	}
+	writeList(w, r, result, next)
+}
+
+// writes the page of the meetings matching the creator, member, period and time range filters
+func (s *Service) meetingList(w http.ResponseWriter, r *http.Request) {
+	page, err := parseListPage(r.Form, sortById, sortByStart)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	ids := make(map[string]UID)
+	for _, tag := range []string{creatorIdTag, memberIdTag} {
+		if _, ok := r.Form[tag]; ok {
+			id, err := strconv.ParseUint(r.FormValue(tag), 10, 32)
+			if err != nil {
+				writeError(w, r, paramError(tag, err))
+				return
+			}
+			ids[tag] = UID(id)
+		}
+	}
+	var period *Period
+	if _, ok := r.Form[periodTag]; ok {
+		p, err := ParsePeriod(r.FormValue(periodTag))
+		if err != nil {
+			writeError(w, r, paramError(periodTag, err))
+			return
+		}
+		period = &p
+	}
+	var from, to time.Time
+	for _, arg := range []struct {
+		tag string
+		t   *time.Time
+	}{{startAtTag, &from}, {endAtTag, &to}} {
+		if _, ok := r.Form[arg.tag]; ok {
+			if *arg.t, err = time.Parse(time.RFC3339, r.FormValue(arg.tag)); err != nil {
+				writeError(w, r, paramError(arg.tag, err))
+				return
+			}
+		}
+	}
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		writeError(w, r, &ParamError{Param: endAtTag, Err: fmt.Errorf("must be after %q: %w", startAtTag, ErrParse)})
+		return
+	}
+
+	var list []Meeting
+	if member, ok := ids[memberIdTag]; ok {
+		list, err = s.storage.UserMeetings(member)
+	} else {
+		list, err = s.storage.MeetingList()
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	meets := make([]Meeting, 0, len(list))
+	keys := make([]listCursor, 0, len(list))
+	for _, m := range list {
+		if creator, ok := ids[creatorIdTag]; ok && m.CreatorId != creator {
+			continue
+		}
+		if period != nil && m.Repeat != *period {
+			continue
+		}
+		if !from.IsZero() || !to.IsZero() {
+			o, ok := m.occurrenceAfter(from)
+			if !ok || !to.IsZero() && !o.start.Before(to) {
+				continue
+			}
+		}
+		key := listCursor{Sort: page.sort, Id: uint32(m.Id)}
+		if page.sort == sortByStart {
+			key.Key = m.FirstOccurence.UTC().Format(sortTimeLayout)
+		}
+		meets = append(meets, m)
+		keys = append(keys, key)
+	}
+
+	order, next := page.cut(keys)
+	result := make([]Meeting, 0, len(order))
+	for _, i := range order {
+		result = append(result, meets[i])
+	}
+	writeList(w, r, result, next)
+}
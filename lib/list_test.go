@@ -0,0 +1,33 @@
+package lib
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestListPageCut(t *testing.T) {
+	keys := []listCursor{{Key: "b", Id: 1}, {Key: "a", Id: 4}, {Key: "b", Id: 2}, {Key: "a", Id: 3}, {Key: "c", Id: 5}}
+	var page listPage
+	if order, next := page.cut(keys); fmt.Sprint(order) != "[3 1 0 2 4]" || next != nil {
+		t.Errorf("all records: %v, next: %v", order, next)
+	}
+
+	page.limit = 2
+	order, next := page.cut(keys)
+	if fmt.Sprint(order) != "[3 1]" || next == nil || *next != keys[1] {
+		t.Fatalf("first page: %v, next: %v", order, next)
+	}
+	page.after = next
+	if order, next = page.cut(keys); fmt.Sprint(order) != "[0 2]" || next == nil || *next != keys[2] {
+		t.Fatalf("second page: %v, next: %v", order, next)
+	}
+	page.after = next
+	if order, next = page.cut(keys); fmt.Sprint(order) != "[4]" || next != nil {
+		t.Errorf("last page: %v, next: %v", order, next)
+	}
+
+	c := listCursor{Sort: sortByName, Key: "jane doe", Id: 7}
+	if parsed, err := parseListCursor(c.String()); err != nil || parsed != c {
+		t.Errorf("cursor: expected: %+v, actual: %+v %v", c, parsed, err)
+	}
+}
@@ -0,0 +1,114 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// the period the meetings are expanded in can't be longer, a leap year fits in it
+const maxPeriodLength = 366 * 24 * time.Hour
+
+// general handler for /occurrences path
+func (s *Service) OccurrencesHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.occurrencesGetHandler(w, r)
+	default:
+		writeError(w, r, fmt.Errorf("%s: %w", r.Method, errNotImplemented))
+	}
+}
+
+// @Summary     get user meeting occurrences for specified period
+// @Description expands every meeting of the user into occurrences overlapping the period sorted by start time
+// @Accept      application/x-www-form-urlencoded
+// @Produce     application/json
+// @Param       user_id  path     uint32           true "User ID"
+// @Param       start_at path     string           true "Period start time in RFC3339"
+// @Param       end_at   path     string           true "Period end time in RFC3339, at most 366 days after the start"
+// @Success     200      {array}  []lib.Occurrence "Occurrences"
+// @Failure     400      {object} lib.Problem      "error description"
+// @Failure     404      {object} lib.Problem      "error description"
+// @Failure     500      {object} lib.Problem      "error description"
+// @Router      /occurrences [get]
+func (s *Service) occurrencesGetHandler(w http.ResponseWriter, r *http.Request) {
+	if err := parseForm(r); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	params := parameters{
+		userIdTag:  singleValue | parameterRequired,
+		startAtTag: singleValue | parameterRequired,
+		endAtTag:   singleValue | parameterRequired,
+	}
+	if err := checkArgs(&r.Form, params); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	id, err := strconv.ParseUint(r.FormValue(userIdTag), 10, 32)
+	if err != nil {
+		writeError(w, r, paramError(userIdTag, err))
+		return
+	}
+	userId := UID(id)
+
+	startAt, err := time.Parse(time.RFC3339, r.FormValue(startAtTag))
+	if err != nil {
+		writeError(w, r, paramError(startAtTag, err))
+		return
+	}
+	startAt = startAt.UTC()
+
+	endAt, err := time.Parse(time.RFC3339, r.FormValue(endAtTag))
+	if err != nil {
+		writeError(w, r, paramError(endAtTag, err))
+		return
+	}
+	endAt = endAt.UTC()
+	if !endAt.After(startAt) {
+		writeError(w, r, &ParamError{Param: endAtTag, Err: fmt.Errorf("period end %v is not after its start %v: %w", endAt, startAt, ErrParse)})
+		return
+	}
+	if err := checkPeriodLength(endAtTag, startAt, endAt); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	meets, err := s.storage.UserMeetingsBetween(userId, startAt, endAt)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	result := make([]Occurrence, 0, len(meets))
+	for _, meet := range meets {
+		presence := meet.memberStatus(userId)
+		for _, o := range meet.occurrencesBetween(startAt, endAt) {
+			result = append(result, Occurrence{MeetingId: meet.Id, StartAt: o.start, EndAt: o.end(), Presence: presence})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].StartAt.Equal(result[j].StartAt) {
+			return result[i].StartAt.Before(result[j].StartAt)
+		}
+		return result[i].MeetingId < result[j].MeetingId
+	})
+
+	w.Header().Set(contentTypeTag, mimeJson)
+	if err = json.NewEncoder(w).Encode(result); err != nil {
+		writeError(w, r, err)
+		return
+	}
+}
+
+// returns the error of the parameter giving the period end if the period is too long
+func checkPeriodLength(tag string, startAt, endAt time.Time) error {
+	if endAt.Sub(startAt) > maxPeriodLength {
+		return &ParamError{Param: tag, Err: fmt.Errorf("period from %v to %v is longer than %d days: %w", startAt, endAt, maxPeriodLength/(24*time.Hour), ErrParse)}
+	}
+	return nil
+}
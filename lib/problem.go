@@ -0,0 +1,98 @@
+package lib
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+const mimeProblem = "application/problem+json"
+
+// stable error codes of the Problem
+const (
+	codeInvalidParameter = "invalid_parameter"
+	codeNotFound         = "not_found"
+	codeAlreadyExists    = "already_exists"
+	codeConflict         = "conflict"
+	codeForbidden        = "forbidden"
+	codeUnauthorized     = "unauthorized"
+	codeNotImplemented   = "not_implemented"
+	codeMethodNotAllowed = "method_not_allowed"
+	codeInternal         = "internal_error"
+)
+
+// errNotImplemented is returned for the methods the path doesn't support
+var errNotImplemented = errors.New("method is not supported")
+
+// errMethodNotAllowed is returned for the methods the API resource doesn't support
+var errMethodNotAllowed = errors.New("method is not allowed")
+
+var errorCodes = []struct {
+	err    error
+	status int
+	code   string
+}{
+	{ErrParse, http.StatusBadRequest, codeInvalidParameter},
+	{ErrNotExist, http.StatusNotFound, codeNotFound},
+	{ErrExist, http.StatusConflict, codeAlreadyExists},
+	{ErrConflict, http.StatusConflict, codeConflict},
+	{ErrForbidden, http.StatusForbidden, codeForbidden},
+	{ErrUnauthorized, http.StatusUnauthorized, codeUnauthorized},
+	{errNotImplemented, http.StatusNotImplemented, codeNotImplemented},
+	{errMethodNotAllowed, http.StatusMethodNotAllowed, codeMethodNotAllowed},
+}
+
+// Problem is the body of the error response in the style of RFC 7807
+type Problem struct {
+	Title  string `json:"title" example:"Not Found"`
+	Status int    `json:"status" example:"404"`
+	// stable error code: invalid_parameter, not_found, already_exists,
+	// conflict, forbidden, unauthorized, not_implemented, method_not_allowed or internal_error
+	Code   string `json:"code" example:"not_found"`
+	Detail string `json:"detail,omitempty" example:"user 5: does not exist"`
+	// the request parameter which caused the error
+	Param string `json:"param,omitempty" example:"id"`
+	// meetings of the members overlapping the rejected one
+	Conflicts []Conflict `json:"conflicts,omitempty"`
+}
+
+// returns the problem describing the error. Details of the internal errors are hidden.
+func newProblem(err error) Problem {
+	p := Problem{Status: http.StatusInternalServerError, Code: codeInternal}
+	for _, c := range errorCodes {
+		if errors.Is(err, c.err) {
+			p.Status, p.Code, p.Detail = c.status, c.code, err.Error()
+			break
+		}
+	}
+	var paramErr *ParamError
+	if errors.As(err, &paramErr) {
+		p.Param = paramErr.Param
+	}
+	p.Title = http.StatusText(p.Status)
+	return p
+}
+
+// logs the error and writes its problem as the response
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	log.Printf("error: %s %s: %v\n", r.Method, r.URL.Path, err)
+	p := newProblem(err)
+	p.Param = requestParam(r, p.Param)
+	writeProblem(w, p)
+}
+
+func writeProblem(w http.ResponseWriter, p Problem) {
+	w.Header().Set(contentTypeTag, mimeProblem)
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
+// parses the request form marking the failure with ErrParse
+func parseForm(r *http.Request) error {
+	if err := r.ParseForm(); err != nil {
+		return fmt.Errorf("parse form: %v: %w", err, ErrParse)
+	}
+	return nil
+}
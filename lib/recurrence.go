@@ -0,0 +1,560 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Frequency int
+
+const (
+	Daily Frequency = iota + 1
+	Weekly
+	Monthly
+	Yearly
+)
+
+var frequencyToNames = map[Frequency]string{
+	Daily:   "DAILY",
+	Weekly:  "WEEKLY",
+	Monthly: "MONTHLY",
+	Yearly:  "YEARLY",
+}
+
+var weekdayToNames = map[time.Weekday]string{
+	time.Monday:    "MO",
+	time.Tuesday:   "TU",
+	time.Wednesday: "WE",
+	time.Thursday:  "TH",
+	time.Friday:    "FR",
+	time.Saturday:  "SA",
+	time.Sunday:    "SU",
+}
+
+// WeekdayNum is a BYDAY value: the week day and optionally its ordinal
+// number within the month or the year, e.g. 1MO is the first Monday,
+// -1FR is the last Friday and TU is every Tuesday.
+type WeekdayNum struct {
+	N   int
+	Day time.Weekday
+}
+
+// Recurrence is a recurrence rule in RFC 5545 RRULE format.
+// FREQ, INTERVAL, COUNT, UNTIL, BYDAY, BYMONTHDAY, BYMONTH, BYSETPOS and WKST parts are supported.
+type Recurrence struct {
+	Freq       Frequency
+	Interval   int
+	Count      int
+	Until      time.Time
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	ByMonth    []time.Month
+	BySetPos   []int
+	WeekStart  time.Weekday
+}
+
+// ParseRecurrence parses the RRULE value, e.g. "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH".
+// Floating UNTIL time is treated as UTC.
+func ParseRecurrence(s string) (Recurrence, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	r := Recurrence{Interval: 1, WeekStart: time.Monday}
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(s, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		name = strings.ToUpper(name)
+		if !ok || value == "" || seen[name] {
+			return r, fmt.Errorf("rule part %q: %w", part, ErrParse)
+		}
+		seen[name] = true
+		var err error
+		switch name {
+		case "FREQ":
+			r.Freq, err = parseFrequency(value)
+		case "INTERVAL":
+			r.Interval, err = parseRuleInt(value, 1, 0)
+		case "COUNT":
+			r.Count, err = parseRuleInt(value, 1, 0)
+		case "UNTIL":
+			r.Until, err = parseRuleTime(value)
+		case "BYDAY":
+			for _, v := range strings.Split(value, ",") {
+				var wd WeekdayNum
+				if wd, err = parseWeekdayNum(v); err != nil {
+					break
+				}
+				r.ByDay = append(r.ByDay, wd)
+			}
+		case "BYMONTHDAY":
+			r.ByMonthDay, err = parseRuleIntList(value, 31)
+		case "BYMONTH":
+			var months []int
+			months, err = parseRuleIntList(value, 12)
+			for _, m := range months {
+				if m < 0 {
+					err = fmt.Errorf("%s: negative month: %w", name, ErrParse)
+				}
+				r.ByMonth = append(r.ByMonth, time.Month(m))
+			}
+		case "BYSETPOS":
+			r.BySetPos, err = parseRuleIntList(value, 366)
+		case "WKST":
+			var wd WeekdayNum
+			wd, err = parseWeekdayNum(value)
+			if wd.N != 0 {
+				err = fmt.Errorf("%s: %q: %w", name, value, ErrParse)
+			}
+			r.WeekStart = wd.Day
+		default:
+			err = fmt.Errorf("%s is not supported: %w", name, ErrParse)
+		}
+		if err != nil {
+			return r, err
+		}
+	}
+	return r, r.validate()
+}
+
+func (r Recurrence) validate() error {
+	if r.Freq == 0 {
+		return fmt.Errorf("FREQ is required: %w", ErrParse)
+	}
+	if r.Count != 0 && !r.Until.IsZero() {
+		return fmt.Errorf("COUNT and UNTIL can't be used together: %w", ErrParse)
+	}
+	if r.Freq == Weekly && len(r.ByMonthDay) != 0 {
+		return fmt.Errorf("BYMONTHDAY can't be used with WEEKLY frequency: %w", ErrParse)
+	}
+	for _, wd := range r.ByDay {
+		if wd.N != 0 && r.Freq != Monthly && r.Freq != Yearly {
+			return fmt.Errorf("numeric BYDAY value %v requires MONTHLY or YEARLY frequency: %w", wd, ErrParse)
+		}
+	}
+	if len(r.BySetPos) != 0 && len(r.ByDay)+len(r.ByMonthDay)+len(r.ByMonth) == 0 {
+		return fmt.Errorf("BYSETPOS requires another BYxxx rule part: %w", ErrParse)
+	}
+	return nil
+}
+
+func (r Recurrence) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "FREQ=%s", frequencyToNames[r.Freq])
+	if r.Interval > 1 {
+		fmt.Fprintf(&b, ";INTERVAL=%d", r.Interval)
+	}
+	if r.Count != 0 {
+		fmt.Fprintf(&b, ";COUNT=%d", r.Count)
+	}
+	if !r.Until.IsZero() {
+		fmt.Fprintf(&b, ";UNTIL=%s", r.Until.UTC().Format("20060102T150405Z"))
+	}
+	if len(r.ByDay) != 0 {
+		days := make([]string, 0, len(r.ByDay))
+		for _, wd := range r.ByDay {
+			days = append(days, wd.String())
+		}
+		fmt.Fprintf(&b, ";BYDAY=%s", strings.Join(days, ","))
+	}
+	if len(r.ByMonthDay) != 0 {
+		fmt.Fprintf(&b, ";BYMONTHDAY=%s", joinInts(r.ByMonthDay))
+	}
+	if len(r.ByMonth) != 0 {
+		months := make([]int, 0, len(r.ByMonth))
+		for _, m := range r.ByMonth {
+			months = append(months, int(m))
+		}
+		fmt.Fprintf(&b, ";BYMONTH=%s", joinInts(months))
+	}
+	if len(r.BySetPos) != 0 {
+		fmt.Fprintf(&b, ";BYSETPOS=%s", joinInts(r.BySetPos))
+	}
+	if r.WeekStart != time.Monday {
+		fmt.Fprintf(&b, ";WKST=%s", weekdayToNames[r.WeekStart])
+	}
+	return b.String()
+}
+
+func (r Recurrence) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(r.String())), nil
+}
+
+func (r *Recurrence) UnmarshalJSON(b []byte) error {
+	var s string
+	err := json.Unmarshal(b, &s)
+	if err != nil {
+		return err
+	}
+	*r, err = ParseRecurrence(s)
+	return err
+}
+
+func (wd WeekdayNum) String() string {
+	if wd.N == 0 {
+		return weekdayToNames[wd.Day]
+	}
+	return strconv.Itoa(wd.N) + weekdayToNames[wd.Day]
+}
+
+// returns the rule the Period is a shorthand for or nil for Once
+func (p Period) recurrence() *Recurrence {
+	var freq Frequency
+	switch p {
+	case EveryDay:
+		freq = Daily
+	case EveryWeek:
+		freq = Weekly
+	case EveryMonth:
+		freq = Monthly
+	case EveryYear:
+		freq = Yearly
+	default:
+		return nil
+	}
+	return &Recurrence{Freq: freq, Interval: 1, WeekStart: time.Monday}
+}
+
+func parseFrequency(s string) (Frequency, error) {
+	for f, name := range frequencyToNames {
+		if strings.EqualFold(s, name) {
+			return f, nil
+		}
+	}
+	return 0, fmt.Errorf("FREQ %q is not supported: %w", s, ErrParse)
+}
+
+func parseWeekdayNum(s string) (WeekdayNum, error) {
+	s = strings.ToUpper(s)
+	if len(s) < 2 {
+		return WeekdayNum{}, fmt.Errorf("week day %q: %w", s, ErrParse)
+	}
+	wd := WeekdayNum{}
+	if num := s[:len(s)-2]; num != "" {
+		n, err := strconv.Atoi(num)
+		if err != nil || n == 0 || n < -53 || n > 53 {
+			return wd, fmt.Errorf("week day %q: %w", s, ErrParse)
+		}
+		wd.N = n
+	}
+	for day, name := range weekdayToNames {
+		if name == s[len(s)-2:] {
+			wd.Day = day
+			return wd, nil
+		}
+	}
+	return wd, fmt.Errorf("week day %q: %w", s, ErrParse)
+}
+
+// parses positive integer not less than min and not greater than max if it is not 0
+func parseRuleInt(s string, min, max int) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil || n < min || max != 0 && n > max {
+		return 0, fmt.Errorf("value %q: %w", s, ErrParse)
+	}
+	return n, nil
+}
+
+// parses comma separated list of non-zero integers in range [-max, max]
+func parseRuleIntList(s string, max int) ([]int, error) {
+	values := make([]int, 0)
+	for _, v := range strings.Split(s, ",") {
+		n, err := strconv.Atoi(v)
+		if err != nil || n == 0 || n < -max || n > max {
+			return nil, fmt.Errorf("value %q: %w", v, ErrParse)
+		}
+		values = append(values, n)
+	}
+	return values, nil
+}
+
+func parseRuleTime(s string) (time.Time, error) {
+	for _, layout := range []string{"20060102T150405Z", "20060102T150405"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	// the date covers the whole day
+	if t, err := time.Parse("20060102", s); err == nil {
+		return t.AddDate(0, 0, 1).Add(-time.Second), nil
+	}
+	return time.Time{}, fmt.Errorf("UNTIL %q: %w", s, ErrParse)
+}
+
+func joinInts(values []int) string {
+	s := make([]string, 0, len(values))
+	for _, v := range values {
+		s = append(s, strconv.Itoa(v))
+	}
+	return strings.Join(s, ",")
+}
+
+// the number of periods in a row without any occurrence after which
+// the rule is considered to have no more occurrences,
+// e.g. FREQ=DAILY;BYMONTH=2;BYMONTHDAY=29 may skip almost 4 years
+const maxEmptyPeriods = 3000
+
+// occurrenceIter generates start times of the recurrence in chronological order.
+// DTSTART is always the first occurrence.
+type occurrenceIter struct {
+	rule    *Recurrence
+	dtstart time.Time
+	period  int
+	pending []time.Time
+	count   int
+	empty   int
+	done    bool
+}
+
+// returns the iterator over occurrences of the rule starting at dtstart.
+// Occurrences before after may be skipped. Time of the day and the location
+// of the occurrences are taken from dtstart.
+func (r *Recurrence) occurrences(dtstart, after time.Time) *occurrenceIter {
+	it := &occurrenceIter{rule: r, dtstart: dtstart}
+	// COUNT requires all the occurrences to be counted from the beginning
+	if r.Count == 0 && after.After(dtstart) {
+		it.period = r.periodsBetween(dtstart, after.In(dtstart.Location())) - 1
+	}
+	if it.period <= 0 {
+		it.period = 0
+		it.pending = []time.Time{dtstart}
+	}
+	return it
+}
+
+// returns the next occurrence or zero time if there are no more
+func (it *occurrenceIter) next() time.Time {
+	for !it.done {
+		if len(it.pending) == 0 {
+			it.fill()
+			continue
+		}
+		t := it.pending[0]
+		it.pending = it.pending[1:]
+		if !it.rule.Until.IsZero() && t.After(it.rule.Until) {
+			it.done = true
+			break
+		}
+		it.count++
+		if it.rule.Count != 0 && it.count > it.rule.Count {
+			it.done = true
+			break
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (it *occurrenceIter) fill() {
+	candidates := it.rule.candidates(it.dtstart, it.period)
+	it.period++
+	if len(it.rule.BySetPos) != 0 {
+		candidates = selectPositions(candidates, it.rule.BySetPos)
+	}
+	for _, t := range candidates {
+		if t.After(it.dtstart) {
+			it.pending = append(it.pending, t)
+		}
+	}
+	if len(it.pending) == 0 {
+		it.empty++
+		it.done = it.empty > maxEmptyPeriods
+	} else {
+		it.empty = 0
+	}
+}
+
+// returns the number of whole periods of the rule between the dates
+func (r *Recurrence) periodsBetween(from, to time.Time) int {
+	y0, m0, d0 := from.Date()
+	y1, m1, d1 := to.Date()
+	var n int
+	switch r.Freq {
+	case Daily:
+		n = daysBetween(y0, m0, d0, y1, m1, d1)
+	case Weekly:
+		n = daysBetween(y0, m0, d0, y1, m1, d1) / 7
+	case Monthly:
+		n = (y1-y0)*12 + int(m1-m0)
+	case Yearly:
+		n = y1 - y0
+	}
+	return n / r.Interval
+}
+
+func daysBetween(y0 int, m0 time.Month, d0 int, y1 int, m1 time.Month, d1 int) int {
+	from := time.Date(y0, m0, d0, 0, 0, 0, 0, time.UTC)
+	to := time.Date(y1, m1, d1, 0, 0, 0, 0, time.UTC)
+	return int(to.Sub(from).Hours() / 24)
+}
+
+// returns sorted start times of the rule in the given period since dtstart
+func (r *Recurrence) candidates(dtstart time.Time, period int) []time.Time {
+	y, m, d := dtstart.Date()
+	var days []time.Time
+	switch r.Freq {
+	case Daily:
+		day := dateToTime(y, m, d+period*r.Interval)
+		if r.matchMonth(day.Month()) && r.matchMonthDay(day) && r.matchWeekday(day) {
+			days = append(days, day)
+		}
+	case Weekly:
+		offset := (int(dtstart.Weekday()) - int(r.WeekStart) + 7) % 7
+		weekStart := dateToTime(y, m, d-offset+7*period*r.Interval)
+		for i := 0; i < 7; i++ {
+			day := weekStart.AddDate(0, 0, i)
+			if len(r.ByDay) == 0 && day.Weekday() != dtstart.Weekday() {
+				continue
+			}
+			if r.matchMonth(day.Month()) && r.matchWeekday(day) {
+				days = append(days, day)
+			}
+		}
+	case Monthly:
+		month := dateToTime(y, m+time.Month(period*r.Interval), 1)
+		if r.matchMonth(month.Month()) {
+			days = r.monthDays(month.Year(), month.Month(), d)
+		}
+	case Yearly:
+		year := y + period*r.Interval
+		switch {
+		case len(r.ByMonth) != 0:
+			months := append([]time.Month(nil), r.ByMonth...)
+			sort.Slice(months, func(i, j int) bool { return months[i] < months[j] })
+			for _, month := range months {
+				days = append(days, r.monthDays(year, month, d)...)
+			}
+		case len(r.ByDay) != 0:
+			days = r.yearWeekdays(year)
+		case len(r.ByMonthDay) != 0:
+			for month := time.January; month <= time.December; month++ {
+				days = append(days, r.monthDays(year, month, d)...)
+			}
+		default:
+			if day := dateToTime(year, m, d); day.Day() == d {
+				days = append(days, day)
+			}
+		}
+	}
+
+	hour, min, sec := dtstart.Clock()
+	times := make([]time.Time, 0, len(days))
+	for _, day := range days {
+		times = append(times, time.Date(day.Year(), day.Month(), day.Day(), hour, min, sec, dtstart.Nanosecond(), dtstart.Location()))
+	}
+	return times
+}
+
+// returns sorted days of the month matching BYMONTHDAY and BYDAY parts
+// or the day of DTSTART if there are no such parts
+func (r *Recurrence) monthDays(year int, month time.Month, dtstartDay int) []time.Time {
+	last := dateToTime(year, month+1, 0).Day()
+	if len(r.ByMonthDay) == 0 && len(r.ByDay) == 0 {
+		if dtstartDay > last {
+			return nil
+		}
+		return []time.Time{dateToTime(year, month, dtstartDay)}
+	}
+
+	days := make([]time.Time, 0)
+	for day := 1; day <= last; day++ {
+		t := dateToTime(year, month, day)
+		if len(r.ByMonthDay) != 0 && !r.matchMonthDay(t) {
+			continue
+		}
+		if len(r.ByDay) != 0 && !r.matchWeekdayNum(t, day, last) {
+			continue
+		}
+		days = append(days, t)
+	}
+	return days
+}
+
+// returns sorted days of the year matching BYDAY (ordinals count within the year)
+// and BYMONTHDAY parts
+func (r *Recurrence) yearWeekdays(year int) []time.Time {
+	last := dateToTime(year, time.December, 31).YearDay()
+	days := make([]time.Time, 0)
+	for day := 1; day <= last; day++ {
+		t := dateToTime(year, time.January, day)
+		if r.matchWeekdayNum(t, day, last) && r.matchMonthDay(t) {
+			days = append(days, t)
+		}
+	}
+	return days
+}
+
+// reports whether the day is in BYDAY. Ordinals are counted for the day number n
+// within a period of the given length (month or year).
+func (r *Recurrence) matchWeekdayNum(t time.Time, n, length int) bool {
+	for _, wd := range r.ByDay {
+		if wd.Day != t.Weekday() {
+			continue
+		}
+		switch {
+		case wd.N == 0,
+			wd.N > 0 && (n-1)/7+1 == wd.N,
+			wd.N < 0 && (length-n)/7+1 == -wd.N:
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Recurrence) matchWeekday(t time.Time) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, wd := range r.ByDay {
+		if wd.Day == t.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Recurrence) matchMonthDay(t time.Time) bool {
+	if len(r.ByMonthDay) == 0 {
+		return true
+	}
+	last := dateToTime(t.Year(), t.Month()+1, 0).Day()
+	for _, d := range r.ByMonthDay {
+		if d == t.Day() || d < 0 && last+d+1 == t.Day() {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Recurrence) matchMonth(m time.Month) bool {
+	if len(r.ByMonth) == 0 {
+		return true
+	}
+	for _, month := range r.ByMonth {
+		if month == m {
+			return true
+		}
+	}
+	return false
+}
+
+// returns the elements at BYSETPOS positions of the sorted set
+func selectPositions(set []time.Time, positions []int) []time.Time {
+	selected := make(map[int]bool)
+	for _, pos := range positions {
+		switch {
+		case pos > 0 && pos <= len(set):
+			selected[pos-1] = true
+		case pos < 0 && -pos <= len(set):
+			selected[len(set)+pos] = true
+		}
+	}
+	result := make([]time.Time, 0, len(selected))
+	for i, t := range set {
+		if selected[i] {
+			result = append(result, t)
+		}
+	}
+	return result
+}
@@ -0,0 +1,181 @@
+package lib
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRecurrenceOccurrences(t *testing.T) {
+	tests := []struct {
+		rule     string
+		dtstart  string
+		expected []string
+	}{
+		{
+			rule:     "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH",
+			dtstart:  "2023-01-03T10:00:00Z",
+			expected: []string{"2023-01-03T10:00:00Z", "2023-01-05T10:00:00Z", "2023-01-17T10:00:00Z", "2023-01-19T10:00:00Z", "2023-01-31T10:00:00Z"},
+		},
+		{
+			rule:     "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR",
+			dtstart:  "2023-01-05T09:30:00Z",
+			expected: []string{"2023-01-05T09:30:00Z", "2023-01-06T09:30:00Z", "2023-01-09T09:30:00Z", "2023-01-10T09:30:00Z"},
+		},
+		{
+			rule:     "FREQ=DAILY;COUNT=3;BYDAY=MO,TU,WE,TH,FR",
+			dtstart:  "2023-01-06T09:30:00Z",
+			expected: []string{"2023-01-06T09:30:00Z", "2023-01-09T09:30:00Z", "2023-01-10T09:30:00Z"},
+		},
+		{
+			rule:     "FREQ=MONTHLY;BYDAY=1MO",
+			dtstart:  "2023-01-02T12:00:00Z",
+			expected: []string{"2023-01-02T12:00:00Z", "2023-02-06T12:00:00Z", "2023-03-06T12:00:00Z", "2023-04-03T12:00:00Z"},
+		},
+		{
+			rule:     "FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1",
+			dtstart:  "2023-01-31T17:00:00Z",
+			expected: []string{"2023-01-31T17:00:00Z", "2023-02-28T17:00:00Z", "2023-03-31T17:00:00Z", "2023-04-28T17:00:00Z"},
+		},
+		{
+			rule:     "FREQ=MONTHLY;UNTIL=20230331T235959Z;BYMONTHDAY=-1",
+			dtstart:  "2023-01-31T08:00:00Z",
+			expected: []string{"2023-01-31T08:00:00Z", "2023-02-28T08:00:00Z", "2023-03-31T08:00:00Z"},
+		},
+		{
+			rule:     "FREQ=MONTHLY",
+			dtstart:  "2023-01-31T08:00:00Z",
+			expected: []string{"2023-01-31T08:00:00Z", "2023-03-31T08:00:00Z", "2023-05-31T08:00:00Z"},
+		},
+		{
+			rule:     "FREQ=YEARLY",
+			dtstart:  "2000-02-29T23:00:00Z",
+			expected: []string{"2000-02-29T23:00:00Z", "2004-02-29T23:00:00Z", "2008-02-29T23:00:00Z"},
+		},
+		{
+			rule:     "FREQ=YEARLY;BYDAY=-1FR;BYMONTH=1,7",
+			dtstart:  "2023-01-27T15:00:00Z",
+			expected: []string{"2023-01-27T15:00:00Z", "2023-07-28T15:00:00Z", "2024-01-26T15:00:00Z"},
+		},
+		{
+			rule:     "FREQ=YEARLY;BYDAY=20MO",
+			dtstart:  "2023-05-15T15:00:00Z",
+			expected: []string{"2023-05-15T15:00:00Z", "2024-05-13T15:00:00Z", "2025-05-19T15:00:00Z"},
+		},
+	}
+
+	for _, test := range tests {
+		rule, err := ParseRecurrence(test.rule)
+		if err != nil {
+			t.Fatalf("%s: parse: %v", test.rule, err)
+		}
+		if rule.String() != test.rule {
+			t.Errorf("rule string: expected: %q, actual: %q", test.rule, rule.String())
+		}
+		it := rule.occurrences(getTestTime(test.dtstart), time.Time{})
+		for _, expected := range test.expected {
+			if actual := it.next(); !actual.Equal(getTestTime(expected)) {
+				t.Errorf("%s: occurrence: expected: %s, actual: %v", test.rule, expected, actual)
+				break
+			}
+		}
+	}
+}
+
+func TestRecurrenceCountAndUntil(t *testing.T) {
+	rule, _ := ParseRecurrence("FREQ=DAILY;COUNT=2")
+	it := rule.occurrences(getTestTime("2023-01-01T10:00:00Z"), time.Time{})
+	it.next()
+	it.next()
+	if actual := it.next(); !actual.IsZero() {
+		t.Errorf("occurrence after COUNT: expected none, actual: %v", actual)
+	}
+
+	m := Meeting{MeetingInfo: MeetingInfo{FirstOccurence: getTestTime("2023-01-01T10:00:00Z"), Duration: Duration{time.Hour}, Repeat: Custom}}
+	rule, _ = ParseRecurrence("FREQ=WEEKLY;UNTIL=20230115")
+	m.Rule = &rule
+	if actual := m.meetingStartTimeAfter(getTestTime("2023-01-15T10:30:00Z")); !actual.Equal(getTestTime("2023-01-15T10:00:00Z")) {
+		t.Errorf("last occurrence: expected: 2023-01-15T10:00:00Z, actual: %v", actual)
+	}
+	if actual := m.meetingStartTimeAfter(getTestTime("2023-01-15T11:00:00Z")); !actual.IsZero() {
+		t.Errorf("occurrence after UNTIL: expected none, actual: %v", actual)
+	}
+}
+
+func TestRecurrenceSkipsToPeriod(t *testing.T) {
+	rule, _ := ParseRecurrence("FREQ=WEEKLY;INTERVAL=3;BYDAY=SU,SA;WKST=SU")
+	m := Meeting{MeetingInfo: MeetingInfo{FirstOccurence: getTestTime("2000-01-01T22:00:00Z"), Duration: Duration{4 * time.Hour}, Repeat: Custom, Rule: &rule}}
+	expected := m.FirstOccurence
+	it := rule.occurrences(m.FirstOccurence, time.Time{})
+	for at := m.FirstOccurence; at.Before(getTestTime("2030-01-01T00:00:00Z")); at = at.Add(5 * 24 * time.Hour) {
+		for !expected.Add(m.Duration.Duration).After(at) {
+			expected = it.next()
+		}
+		if actual := m.meetingStartTimeAfter(at); !actual.Equal(expected) {
+			t.Fatalf("occurrence after %v: expected: %v, actual: %v", at, expected, actual)
+		}
+	}
+}
+
+func TestOccurrenceExceptions(t *testing.T) {
+	m := Meeting{MeetingInfo: MeetingInfo{FirstOccurence: getTestTime("2023-01-01T10:00:00Z"), Duration: Duration{time.Hour}, Repeat: EveryDay}}
+	m.ExDates = []time.Time{getTestTime("2023-01-01T10:00:00Z"), getTestTime("2023-01-02T10:00:00Z")}
+	// the third occurrence takes place in the evening of the first day
+	m.Overrides = []Override{{RecurrenceId: getTestTime("2023-01-03T10:00:00Z"), StartAt: getTestTime("2023-01-01T20:00:00Z"), Duration: Duration{3 * time.Hour}}}
+
+	for _, test := range []struct {
+		at, start, end string
+	}{
+		{"2023-01-01T00:00:00Z", "2023-01-01T20:00:00Z", "2023-01-01T23:00:00Z"},
+		{"2023-01-01T22:00:00Z", "2023-01-01T20:00:00Z", "2023-01-01T23:00:00Z"},
+		{"2023-01-01T23:00:00Z", "2023-01-04T10:00:00Z", "2023-01-04T11:00:00Z"},
+	} {
+		o, ok := m.occurrenceAfter(getTestTime(test.at))
+		if !ok || !o.start.Equal(getTestTime(test.start)) || !o.end().Equal(getTestTime(test.end)) {
+			t.Errorf("occurrence after %s: expected: %s - %s, actual: %v - %v", test.at, test.start, test.end, o.start, o.end())
+		}
+	}
+	if !m.isScheduledAt(getTestTime("2023-01-03T10:00:00Z")) || m.isScheduledAt(getTestTime("2023-01-03T11:00:00Z")) {
+		t.Errorf("scheduled occurrences are not recognized")
+	}
+}
+
+func TestTimeZoneOccurrences(t *testing.T) {
+	m := Meeting{MeetingInfo: MeetingInfo{FirstOccurence: getTestTime("2023-03-05T14:00:00Z"), Duration: Duration{time.Hour}, Repeat: EveryWeek, TimeZone: "America/New_York"}}
+	// 9:00 in New York, daylight saving time is from 2023-03-12 to 2023-11-05
+	for _, expected := range []string{"2023-03-05T14:00:00Z", "2023-03-12T13:00:00Z", "2023-10-29T13:00:00Z", "2023-11-05T14:00:00Z"} {
+		at := getTestTime(expected).Add(-time.Hour)
+		if actual := m.meetingStartTimeAfter(at); !actual.Equal(getTestTime(expected)) || actual.Location() != time.UTC {
+			t.Errorf("occurrence after %v: expected: %s, actual: %v", at, expected, actual)
+		}
+	}
+	if !m.isScheduledAt(getTestTime("2023-03-19T13:00:00Z")) || m.isScheduledAt(getTestTime("2023-03-19T14:00:00Z")) {
+		t.Errorf("scheduled occurrences are not recognized")
+	}
+}
+
+func TestParseRecurrenceErrors(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;BYHOUR=10",
+		"FREQ=DAILY;COUNT=2;UNTIL=20230101",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=WEEKLY;BYMONTHDAY=1",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=MONTHLY;BYSETPOS=1",
+		"FREQ=MONTHLY;BYDAY=XX",
+		"FREQ=MONTHLY;FREQ=DAILY",
+	} {
+		if _, err := ParseRecurrence(s); !errors.Is(err, ErrParse) {
+			t.Errorf("%q: expected parse error, actual: %v", s, err)
+		}
+	}
+}
+
+func getTestTime(s string) time.Time {
+	t, _ := time.Parse(time.RFC3339, s)
+	return t
+}
@@ -0,0 +1,173 @@
+package lib
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+const mimeAppJson = "application/json"
+
+// the context key marking the request which form is taken from the JSON body
+type jsonBodyKey struct{}
+
+// jsonBody is the JSON request body which is handled as the form,
+// so both kinds of requests pass the same validation
+type jsonBody interface {
+	form() (url.Values, error)
+}
+
+// names of the JSON body fields by the form parameters they are passed in
+var jsonFields = map[string]string{
+	creatorIdTag:      "CreatorId",
+	memberIdsTag:      "Members",
+	optionalTag:       "Members",
+	startAtTag:        "FirstOccurence",
+	durationTag:       "Duration",
+	periodTag:         "Repeat",
+	ruleTag:           "Rule",
+	timeZoneTag:       "TimeZone",
+	resourceIdsTag:    "Resources",
+	conflictPolicyTag: "ConflictPolicy",
+	nameTag:           "Name",
+	emailTag:          "Email",
+	userIdTag:         "UserId",
+	meetingIdTag:      "MeetingId",
+	presenceTag:       "Status",
+}
+
+// MeetingRequest is the JSON body of the new meeting mirroring MeetingInfo
+type MeetingRequest struct {
+	CreatorId *UID
+	// required and optional members, the responses can't be given
+	Members        []Participant
+	FirstOccurence string       `example:"2023-09-11T10:00:00Z"`
+	Duration       string       `example:"1h30m"`
+	Repeat         string       `json:",omitempty" example:"EveryWeek"`
+	Rule           string       `json:",omitempty" example:"FREQ=WEEKLY;BYDAY=TU,TH"`
+	TimeZone       string       `json:",omitempty" example:"Europe/Berlin"`
+	Resources      []ResourceId `json:",omitempty"`
+	ConflictPolicy string       `json:",omitempty" example:"warn"`
+}
+
+func (m *MeetingRequest) form() (url.Values, error) {
+	form := url.Values{}
+	if m.CreatorId != nil {
+		form.Set(creatorIdTag, strconv.FormatUint(uint64(*m.CreatorId), 10))
+	}
+	for _, member := range m.Members {
+		if member.Status != Unknown {
+			return nil, &ParamError{Param: memberIdsTag, Err: fmt.Errorf("user %d: status can't be set: %w", member.UserId, ErrParse)}
+		}
+		tag := memberIdsTag
+		if member.Role == Optional {
+			tag = optionalTag
+		}
+		form.Add(tag, strconv.FormatUint(uint64(member.UserId), 10))
+	}
+	setValue(form, startAtTag, m.FirstOccurence)
+	setValue(form, durationTag, m.Duration)
+	setValue(form, periodTag, m.Repeat)
+	setValue(form, ruleTag, m.Rule)
+	setValue(form, timeZoneTag, m.TimeZone)
+	for _, id := range m.Resources {
+		form.Add(resourceIdsTag, strconv.FormatUint(uint64(id), 10))
+	}
+	setValue(form, conflictPolicyTag, m.ConflictPolicy)
+	return form, nil
+}
+
+// UserRequest is the JSON body of the new user mirroring UserInfo
+type UserRequest struct {
+	Name  *string `example:"John"`
+	Email *string `json:",omitempty" example:"john@example.com"`
+}
+
+func (u *UserRequest) form() (url.Values, error) {
+	form := url.Values{}
+	if u.Name != nil {
+		form.Set(nameTag, *u.Name)
+	}
+	if u.Email != nil {
+		form.Set(emailTag, *u.Email)
+	}
+	return form, nil
+}
+
+// PresenceRequest is the JSON body of the member's response
+type PresenceRequest struct {
+	UserId    *UID
+	MeetingId *MeetingId
+	Status    string `example:"Accepted"`
+}
+
+func (p *PresenceRequest) form() (url.Values, error) {
+	form := url.Values{}
+	if p.UserId != nil {
+		form.Set(userIdTag, strconv.FormatUint(uint64(*p.UserId), 10))
+	}
+	if p.MeetingId != nil {
+		form.Set(meetingIdTag, strconv.FormatUint(uint64(*p.MeetingId), 10))
+	}
+	setValue(form, presenceTag, p.Status)
+	return form, nil
+}
+
+// sets the form value unless it is empty
+func setValue(form url.Values, key, value string) {
+	if value != "" {
+		form.Set(key, value)
+	}
+}
+
+// reports whether the request body is JSON
+func isJsonRequest(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get(contentTypeTag))
+	return err == nil && (mediaType == mimeAppJson || mediaType == mimeJson)
+}
+
+// parses the request form. The JSON body is decoded into body instead
+// and its values are added to the URL query parameters.
+func parseRequest(r *http.Request, body jsonBody) error {
+	if !isJsonRequest(r) {
+		return parseForm(r)
+	}
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(body); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			return &ParamError{Param: typeErr.Field, Err: parseError(err)}
+		}
+		return fmt.Errorf("decode body: %v: %w", err, ErrParse)
+	}
+	*r = *r.WithContext(context.WithValue(r.Context(), jsonBodyKey{}, true))
+	form, err := body.form()
+	if err != nil {
+		return err
+	}
+	r.Form = r.URL.Query()
+	for k, v := range form {
+		r.Form[k] = append(r.Form[k], v...)
+	}
+	return nil
+}
+
+// returns the name of the request parameter as the client has passed it
+func requestParam(r *http.Request, param string) string {
+	if r.Context().Value(jsonBodyKey{}) == nil {
+		return param
+	}
+	if _, ok := r.URL.Query()[param]; ok {
+		return param
+	}
+	if field, ok := jsonFields[param]; ok {
+		return field
+	}
+	return param
+}
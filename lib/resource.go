@@ -0,0 +1,449 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	capacityTag           = "capacity"
+	attributesTag         = "attributes"
+	resourceIdsTag        = "resource_ids"
+	addResourcesTag       = "add_resource_ids"
+	delResourcesTag       = "remove_resource_ids"
+	resourceCapacityTag   = "resource_capacity"
+	resourceAttributesTag = "resource_attributes"
+)
+
+// conflicts of the recurring meetings are checked for this period after now
+const bookingHorizon = 365 * 24 * time.Hour
+
+// endless series are looked up till this time
+var endOfTime = time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+// general handler for /resource path
+func (s *Service) ResourceHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.resourceGetHandler(w, r)
+	case http.MethodPost:
+		s.resourcePostHandler(w, r)
+	case http.MethodPut:
+		s.resourcePutHandler(w, r)
+	case http.MethodDelete:
+		s.resourceDeleteHandler(w, r)
+	default:
+		writeError(w, r, fmt.Errorf("%s: %w", r.Method, errNotImplemented))
+	}
+}
+
+// @Summary     get resource information
+// @Description returns the room or the equipment for given id or list of all resources ordered by id
+// @Accept      application/x-www-form-urlencoded
+// @Produce     application/json
+// @Param       id  path     uint32         false "Resource ID"
+// @Success     200 {array}  []lib.Resource "Resource information"
+// @Failure     400 {object} lib.Problem    "error description"
+// @Failure     404 {object} lib.Problem    "error description"
+// @Failure     500 {object} lib.Problem    "error description"
+// @Router      /resource [get]
+func (s *Service) resourceGetHandler(w http.ResponseWriter, r *http.Request) {
+	if err := parseForm(r); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	params := parameters{idTag: singleValue}
+	if err := checkArgs(&r.Form, params); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var value interface{}
+	if _, ok := r.Form[idTag]; ok {
+		id, err := strconv.ParseUint(r.FormValue(idTag), 10, 32)
+		if err != nil {
+			writeError(w, r, paramError(idTag, err))
+			return
+		}
+		res, err := s.storage.ResourceFindById(ResourceId(id))
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		value = res
+	} else {
+		list, err := s.storage.ResourceList()
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		sort.Slice(list, func(i, j int) bool { return list[i].Id < list[j].Id })
+		value = list
+	}
+
+	result, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.Header().Set(contentTypeTag, mimeJson)
+	w.Write(result)
+}
+
+// @Summary     add new resource
+// @Description add a room or a piece of equipment which can be booked for meetings
+// @Accept      application/x-www-form-urlencoded
+// @Produce     application/json
+// @Param       name       path     string         true  "Resource name"
+// @Param       capacity   path     uint32         false "Number of people the resource holds, not limited by default"
+// @Param       attributes path     []string       false "Features of the resource separated with a comma (','), e.g. 'room,projector'"
+// @Success     200        {object} lib.ResourceId "Resource ID"
+// @Failure     400        {object} lib.Problem    "error description"
+// @Failure     500        {object} lib.Problem    "error description"
+// @Router      /resource [post]
+func (s *Service) resourcePostHandler(w http.ResponseWriter, r *http.Request) {
+	if err := parseForm(r); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	params := parameters{
+		nameTag:       singleValue | parameterRequired,
+		capacityTag:   singleValue,
+		attributesTag: multipleValue,
+	}
+	if err := checkArgs(&r.Form, params); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := s.authorizeAdmin(r); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	info := ResourceInfo{Name: r.FormValue(nameTag), Attributes: parseAttributes(r.Form[attributesTag])}
+	if _, ok := r.Form[capacityTag]; ok {
+		capacity, err := strconv.ParseUint(r.FormValue(capacityTag), 10, 32)
+		if err != nil {
+			writeError(w, r, paramError(capacityTag, err))
+			return
+		}
+		info.Capacity = int(capacity)
+	}
+
+	id, err := s.storage.ResourceAdd(info)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(struct{ Id ResourceId }{id})
+}
+
+// @Summary     change resource information
+// @Description change name, capacity or attributes of the resource. The meetings already booked are not checked.
+// @Accept      application/x-www-form-urlencoded
+// @Produce     application/json
+// @Param       id         path     uint32       true  "Resource ID"
+// @Param       name       path     string       false "Resource name"
+// @Param       capacity   path     uint32       false "Number of people the resource holds, 0 if it is not limited"
+// @Param       attributes path     []string     false "Features of the resource separated with a comma (','). Replaces the current ones, empty value removes them."
+// @Success     200        {object} lib.Resource "Changed resource information"
+// @Failure     400        {object} lib.Problem  "error description"
+// @Failure     404        {object} lib.Problem  "error description"
+// @Failure     500        {object} lib.Problem  "error description"
+// @Router      /resource [put]
+func (s *Service) resourcePutHandler(w http.ResponseWriter, r *http.Request) {
+	if err := parseForm(r); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	params := parameters{
+		idTag:         singleValue | parameterRequired,
+		nameTag:       singleValue,
+		capacityTag:   singleValue,
+		attributesTag: multipleValue,
+	}
+	if err := checkArgs(&r.Form, params); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := s.authorizeAdmin(r); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	id, err := strconv.ParseUint(r.FormValue(idTag), 10, 32)
+	if err != nil {
+		writeError(w, r, paramError(idTag, err))
+		return
+	}
+
+	res, err := s.storage.ResourceFindById(ResourceId(id))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if v, ok := r.Form[nameTag]; ok {
+		res.Name = v[0]
+	}
+	if _, ok := r.Form[capacityTag]; ok {
+		capacity, err := strconv.ParseUint(r.FormValue(capacityTag), 10, 32)
+		if err != nil {
+			writeError(w, r, paramError(capacityTag, err))
+			return
+		}
+		res.Capacity = int(capacity)
+	}
+	if v, ok := r.Form[attributesTag]; ok {
+		res.Attributes = parseAttributes(v)
+	}
+
+	if err = s.storage.ResourceUpdate(res); err != nil {
+		writeError(w, r, fmt.Errorf("update id=%v: %w", id, err))
+		return
+	}
+
+	result, err := json.MarshalIndent(res, "", "  ")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.Header().Set(contentTypeTag, mimeJson)
+	w.Write(result)
+}
+
+// @Summary     remove resource
+// @Description remove the resource. The resource booked by a meeting can't be removed.
+// @Accept      application/x-www-form-urlencoded
+// @Produce     application/json
+// @Param       id  path     uint32      true "Resource ID"
+// @Success     200 {string} string      "empty"
+// @Failure     400 {object} lib.Problem "error description"
+// @Failure     404 {object} lib.Problem "error description"
+// @Failure     409 {object} lib.Problem "error description"
+// @Failure     500 {object} lib.Problem "error description"
+// @Router      /resource [delete]
+func (s *Service) resourceDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	if err := parseForm(r); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	params := parameters{idTag: singleValue | parameterRequired}
+	if err := checkArgs(&r.Form, params); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := s.authorizeAdmin(r); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	id, err := strconv.ParseUint(r.FormValue(idTag), 10, 32)
+	if err != nil {
+		writeError(w, r, paramError(idTag, err))
+		return
+	}
+
+	if err := s.storage.ResourceDelete(ResourceId(id)); err != nil {
+		writeError(w, r, err)
+		return
+	}
+}
+
+// parses list of attributes given as several values and/or separated with a comma.
+// Empty attributes are skipped.
+func parseAttributes(values []string) []string {
+	var attributes []string
+	for _, value := range values {
+		for _, attr := range strings.Split(value, ",") {
+			if attr = strings.TrimSpace(attr); attr != "" {
+				attributes = append(attributes, attr)
+			}
+		}
+	}
+	return attributes
+}
+
+// parses list of resource ids given as several values and/or separated with a comma
+func parseResourceIds(values []string) ([]ResourceId, error) {
+	ids, err := parseIdList(values)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]ResourceId, 0, len(ids))
+	for _, id := range ids {
+		result = append(result, ResourceId(id))
+	}
+	return result, nil
+}
+
+// returns ErrNotExist if any resource of the meeting is not found
+// and ErrParse if it can't hold all the members
+func (s *Service) checkResources(m Meeting) error {
+	for _, id := range m.Resources {
+		res, err := s.storage.ResourceFindById(id)
+		if err != nil {
+			return fmt.Errorf("resource %d: %w", id, err)
+		}
+		if res.Capacity != 0 && len(m.Members) > res.Capacity {
+			return fmt.Errorf("resource %d holds %d people, but the meeting has %d members: %w", id, res.Capacity, len(m.Members), ErrParse)
+		}
+	}
+	return nil
+}
+
+// reports whether the resource holds capacity people and has all the attributes
+func (r Resource) suits(capacity int, attributes []string) bool {
+	if r.Capacity != 0 && r.Capacity < capacity {
+		return false
+	}
+	for _, attr := range attributes {
+		found := false
+		for _, a := range r.Attributes {
+			if a == attr {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// returns ids of the resources holding capacity people and having all the attributes.
+// The smallest suitable resources come first, the resources of unlimited capacity last.
+func (s *Service) suitableResources(capacity int, attributes []string) ([]ResourceId, error) {
+	list, err := s.storage.ResourceList()
+	if err != nil {
+		return nil, err
+	}
+	suitable := make([]Resource, 0, len(list))
+	for _, res := range list {
+		if res.suits(capacity, attributes) {
+			suitable = append(suitable, res)
+		}
+	}
+	sort.Slice(suitable, func(i, j int) bool {
+		a, b := suitable[i], suitable[j]
+		if a.Capacity != b.Capacity {
+			return b.Capacity == 0 || a.Capacity != 0 && a.Capacity < b.Capacity
+		}
+		return a.Id < b.Id
+	})
+	ids := make([]ResourceId, 0, len(suitable))
+	for _, res := range suitable {
+		ids = append(ids, res.Id)
+	}
+	return ids, nil
+}
+
+// returns occurrences of the meetings booking the resource overlapping [start, end)
+func (s *Service) resourceBusyPeriods(id ResourceId, start, end time.Time) ([]interval, error) {
+	meets, err := s.storage.ResourceMeetingsBetween(id, start, end)
+	if err != nil {
+		return nil, err
+	}
+	periods := make([]interval, 0)
+	for _, meet := range meets {
+		for _, o := range meet.occurrencesBetween(start, end) {
+			periods = append(periods, interval{o.start, o.end()})
+		}
+	}
+	return periods, nil
+}
+
+func (m Meeting) hasResource(id ResourceId) bool {
+	for _, r := range m.Resources {
+		if r == id {
+			return true
+		}
+	}
+	return false
+}
+
+// returns the period [from, to) the occurrences of the meetings are compared in.
+// It begins at the latest start of the meetings and ends at the earliest end of
+// them, but not later than bookingHorizon after now or after the beginning
+// if the meetings start in the future. The period is empty if they never meet.
+func bookingPeriod(now time.Time, meets ...Meeting) (time.Time, time.Time) {
+	var from time.Time
+	for _, m := range meets {
+		if start := m.firstStart(); start.After(from) {
+			from = start
+		}
+	}
+	to := now
+	if from.After(to) {
+		to = from
+	}
+	to = to.Add(bookingHorizon)
+	for _, m := range meets {
+		if end := m.lastEnd(to); end.Before(to) {
+			to = end
+		}
+	}
+	return from, to
+}
+
+// returns the period [from, to) covering all the occurrences of the meeting.
+// The other meetings taking place in it are compared within their bookingPeriod.
+func (m Meeting) span() (time.Time, time.Time) {
+	return m.firstStart(), m.lastEnd(endOfTime)
+}
+
+// returns start time of the earliest occurrence of the meeting including the moved ones
+func (m Meeting) firstStart() time.Time {
+	start := m.FirstOccurence
+	for _, o := range m.Overrides {
+		if o.StartAt.Before(start) {
+			start = o.StartAt
+		}
+	}
+	return start
+}
+
+// returns end time of the latest occurrence of the meeting or limit
+// if the meeting takes place after it
+func (m Meeting) lastEnd(limit time.Time) time.Time {
+	var end time.Time
+	for _, o := range m.Overrides {
+		if e := o.StartAt.Add(o.Duration.Duration); e.After(end) {
+			end = e
+		}
+	}
+	rule := m.recurrence()
+	switch {
+	case rule == nil:
+		if e := m.FirstOccurence.Add(m.Duration.Duration); e.After(end) {
+			end = e
+		}
+	case rule.Count != 0:
+		next := m.scheduledOccurrences(m.FirstOccurence)
+		for t := next(); !t.IsZero(); t = next() {
+			if !t.Before(limit) {
+				return limit
+			}
+			if e := t.Add(m.Duration.Duration); e.After(end) {
+				end = e
+			}
+		}
+	case !rule.Until.IsZero():
+		// no occurrence starts after UNTIL
+		if e := rule.Until.Add(m.Duration.Duration); e.After(end) {
+			end = e
+		}
+	default:
+		return limit
+	}
+	if end.After(limit) {
+		return limit
+	}
+	return end
+}
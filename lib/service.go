@@ -2,10 +2,10 @@ package lib
 
 import (
 	"encoding/json"
-	"errors"
-	"log"
+	"fmt"
 	"net/http"
 	"net/url"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -24,80 +24,142 @@ const (
 	userIdTag      = "user_id"
 	meetingIdTag   = "meeting_id"
 	presenceTag    = "presence"
+	cancelledTag   = "cancelled"
+	addMembersTag  = "add_member_ids"
+	optionalTag    = "optional_member_ids"
+	addOptionalTag = "add_optional_member_ids"
+	optionalIdsTag = "optional_ids"
+	quorumTag      = "quorum"
+	delMembersTag  = "remove_member_ids"
+	emailTag       = "email"
+	modeTag        = "mode"
+	createdTag     = "created_meetings"
+	reassignToTag  = "reassign_to"
+	ruleTag        = "rrule"
+	timeZoneTag    = "time_zone"
+	endAtTag       = "end_at"
+	limitTag       = "limit"
+	rankTag        = "rank"
+	unknownTag     = "unknown_presence"
+	tentativeTag   = "tentative"
+	horizonTag     = "horizon"
+	weekdaysTag    = "weekdays"
+	windowTag      = "window"
+	granularityTag = "granularity"
 )
 
+// Service serves the HTTP API on top of the given storage.
+// Several services with different storages may be used in one process.
+type Service struct {
+	storage Storage
+	// hash of the admin token, the requests are not authenticated if it is empty
+	adminHash string
+}
+
+// NewService returns service keeping its data in the given storage.
+func NewService(storage Storage) *Service {
+	return &Service{storage: storage}
+}
+
+// Storage returns the backend the service works with.
+func (s *Service) Storage() Storage {
+	return s.storage
+}
+
 // general handler for /user path
-func UserHandler(w http.ResponseWriter, r *http.Request) {
+func (s *Service) UserHandler(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
-		userGetHandler(w, r)
+		s.userGetHandler(w, r)
 	case http.MethodPost:
-		userPostHandler(w, r)
+		s.userPostHandler(w, r)
+	case http.MethodPut:
+		s.userPutHandler(w, r)
+	case http.MethodDelete:
+		s.userDeleteHandler(w, r)
 	default:
-		w.WriteHeader(http.StatusNotImplemented)
+		writeError(w, r, fmt.Errorf("%s: %w", r.Method, errNotImplemented))
 	}
 }
 
 // general handler for /meeting path
-func MeetingHandler(w http.ResponseWriter, r *http.Request) {
+func (s *Service) MeetingHandler(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
-		meetingGetHandler(w, r)
+		s.meetingGetHandler(w, r)
 	case http.MethodPost:
-		meetingPostHandler(w, r)
+		s.meetingPostHandler(w, r)
+	case http.MethodPatch:
+		s.meetingPatchHandler(w, r)
+	case http.MethodDelete:
+		s.meetingDeleteHandler(w, r)
 	default:
-		w.WriteHeader(http.StatusNotImplemented)
+		writeError(w, r, fmt.Errorf("%s: %w", r.Method, errNotImplemented))
 	}
 }
 
 // general handler for /response path
-func ResponseHandler(w http.ResponseWriter, r *http.Request) {
+func (s *Service) ResponseHandler(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodPut:
-		responsePutHandler(w, r)
+		s.responsePutHandler(w, r)
 	default:
-		w.WriteHeader(http.StatusNotImplemented)
+		writeError(w, r, fmt.Errorf("%s: %w", r.Method, errNotImplemented))
 	}
 }
 
 // general handler for /user_meetings path
-func UserMeetingsHandler(w http.ResponseWriter, r *http.Request) {
+func (s *Service) UserMeetingsHandler(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
-		userMeetingsGetHandler(w, r)
+		s.userMeetingsGetHandler(w, r)
 	default:
-		w.WriteHeader(http.StatusNotImplemented)
+		writeError(w, r, fmt.Errorf("%s: %w", r.Method, errNotImplemented))
 	}
 }
 
-func FindFreeTimeHandler(w http.ResponseWriter, r *http.Request) {
+func (s *Service) FindFreeTimeHandler(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
-		findFreeTimeGetHandler(w, r)
+		s.findFreeTimeGetHandler(w, r)
 	default:
-		w.WriteHeader(http.StatusNotImplemented)
+		writeError(w, r, fmt.Errorf("%s: %w", r.Method, errNotImplemented))
 	}
 }
 
 // @Summary     get user information
-// @Description returns user information for given id or list with information about all users
+// @Description returns user information for given id or list with information about the users.
+// @Description The list is sorted and may be cut into pages, the Link header refers to the next page.
 // @Accept      application/x-www-form-urlencoded
 // @Produce     application/json
-// @Param       id  path     uint32     false "User ID"
-// @Success     200 {array}  []lib.User "User information"
-// @Failure     400 {string} string     "empty"
-// @Failure     404 {string} string     "empty"
-// @Failure     500 {string} string     "empty"
+// @Param       id          path     uint32      false "User ID"
+// @Param       name        path     string      false "List the users which name contains the value ignoring the case"
+// @Param       deactivated path     bool        false "List the deactivated or the active users only"
+// @Param       sort        path     string      false "List order, by id by default. The users of the same name are ordered by id." Enums(id, name)
+// @Param       limit       path     uint32      false "Page length, all the users by default. Up to 1000."
+// @Param       cursor      path     string      false "Position the page starts after, given in the Link header of the previous page"
+// @Success     200         {array}  []lib.User  "User information"
+// @Failure     400         {object} lib.Problem "error description"
+// @Failure     404         {object} lib.Problem "error description"
+// @Failure     500         {object} lib.Problem "error description"
 // @Router      /user [get]
-func userGetHandler(w http.ResponseWriter, r *http.Request) {
-	if err := r.ParseForm(); err != nil {
-		log.Printf("error: GET /user: parse form: %v\n", err)
-		w.WriteHeader(http.StatusBadRequest)
+// @Router      /api/v1/users [get]
+// @Router      /api/v1/users/{id} [get]
+func (s *Service) userGetHandler(w http.ResponseWriter, r *http.Request) {
+	if err := parseForm(r); err != nil {
+		writeError(w, r, err)
 		return
 	}
-	params := parameters{idTag: singleValue}
-	if !checkArgs(&r.Form, params) {
-		w.WriteHeader(http.StatusBadRequest)
+	params := parameters{
+		idTag:          singleValue,
+		nameTag:        singleValue,
+		deactivatedTag: singleValue,
+		sortTag:        singleValue,
+		limitTag:       singleValue,
+		cursorTag:      singleValue,
+	}
+	if err := checkArgs(&r.Form, params); err != nil {
+		writeError(w, r, err)
 		return
 	}
 	var id UID
@@ -105,26 +167,19 @@ func userGetHandler(w http.ResponseWriter, r *http.Request) {
 	if idSet {
 		tmp, err := strconv.ParseUint(v[0], 10, 32)
 		if err != nil {
-			log.Printf("error: GET /user: parse %q value (%q): %v\n", idTag, v[0], err)
-			w.WriteHeader(http.StatusBadRequest)
+			writeError(w, r, paramError(idTag, err))
 			return
 		}
 		id = UID(tmp)
 
-		usr, err := userFindById(id)
+		usr, err := s.storage.UserFindById(id)
 		if err != nil {
-			if errors.Is(err, ErrNotExist) {
-				w.WriteHeader(http.StatusNotFound)
-			} else {
-				log.Printf("error: GET /user: find id=%v: %v\n", id, err)
-				w.WriteHeader(http.StatusInternalServerError)
-			}
+			writeError(w, r, fmt.Errorf("find id=%v: %w", id, err))
 			return
 		}
 		result, err := json.MarshalIndent(usr, "", "  ")
 		if err != nil {
-			log.Printf("error: GET /user: marshal result: %v\n", err)
-			w.WriteHeader(http.StatusInternalServerError)
+			writeError(w, r, fmt.Errorf("marshal result: %w", err))
 			return
 		}
 		w.Header().Set(contentTypeTag, mimeJson)
@@ -132,67 +187,239 @@ func userGetHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	usrList, err := userList()
+	s.userList(w, r)
+}
+
+// @Summary     add new user
+// @Description add new user
+// @Accept      application/x-www-form-urlencoded,application/json
+// @Produce     application/json
+// @Param       name    path     string          true  "User name"
+// @Param       email   path     string          false "User e-mail"
+// @Param       request body     lib.UserRequest false "User in the JSON body instead of the parameters"
+// @Success     200     {object} lib.UID         "User ID"
+// @Success     201     {object} lib.UID         "User ID, the versioned API answers with the user Location"
+// @Failure     400     {object} lib.Problem     "error description"
+// @Failure     500     {object} lib.Problem     "error description"
+// @Router      /user [post]
+// @Router      /api/v1/users [post]
+func (s *Service) userPostHandler(w http.ResponseWriter, r *http.Request) {
+	if err := parseRequest(r, &UserRequest{}); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	params := parameters{
+		nameTag:  singleValue | parameterRequired,
+		emailTag: singleValue,
+	}
+	if err := checkArgs(&r.Form, params); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := s.authorizeAdmin(r); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	id, err := s.createUser(r.FormValue(nameTag), r.FormValue(emailTag))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeCreated(w, r, fmt.Sprintf("users/%d", id))
+	json.NewEncoder(w).Encode(struct{ Id UID }{id})
+}
+
+// @Summary     change user information
+// @Description change user profile
+// @Accept      application/x-www-form-urlencoded
+// @Produce     application/json
+// @Param       id    path     uint32      true  "User ID"
+// @Param       name  path     string      false "User name"
+// @Param       email path     string      false "User e-mail"
+// @Success     200   {object} lib.User    "Changed user information"
+// @Failure     400   {object} lib.Problem "error description"
+// @Failure     404   {object} lib.Problem "error description"
+// @Failure     500   {object} lib.Problem "error description"
+// @Router      /user [put]
+// @Router      /api/v1/users/{id} [patch]
+func (s *Service) userPutHandler(w http.ResponseWriter, r *http.Request) {
+	if err := parseForm(r); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	params := parameters{
+		idTag:    singleValue | parameterRequired,
+		nameTag:  singleValue,
+		emailTag: singleValue,
+	}
+	if err := checkArgs(&r.Form, params); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	id, err := strconv.ParseUint(r.FormValue(idTag), 10, 32)
+	if err != nil {
+		writeError(w, r, paramError(idTag, err))
+		return
+	}
+	if err := s.authorize(r, idTag, UID(id)); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	usr, err := s.storage.UserFindById(UID(id))
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
-	result, err := json.MarshalIndent(usrList, "", "  ")
+	if v, ok := r.Form[nameTag]; ok {
+		usr.Name = v[0]
+	}
+	if v, ok := r.Form[emailTag]; ok {
+		usr.Email = v[0]
+	}
+
+	if err = s.storage.UserUpdate(usr); err != nil {
+		writeError(w, r, fmt.Errorf("update id=%v: %w", id, err))
+		return
+	}
+
+	result, err := json.MarshalIndent(usr, "", "  ")
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 	w.Header().Set(contentTypeTag, mimeJson)
 	w.Write(result)
 }
 
-// @Summary     add new user
-// @Description add new user
+// @Summary     remove user
+// @Description deactivate or delete the user. The user is removed from all meetings.
+// @Description Meetings created by the user are either passed to another member or cancelled.
 // @Accept      application/x-www-form-urlencoded
 // @Produce     application/json
-// @Param       name path     string  true "User name"
-// @Success     200  {object} lib.UID "User ID"
-// @Failure     400  {string} string  "empty"
-// @Failure     500  {string} string  "empty"
-// @Router      /user [post]
-func userPostHandler(w http.ResponseWriter, r *http.Request) {
-	if r.ParseForm() != nil {
-		w.WriteHeader(http.StatusBadRequest)
+// @Param       id               path     uint32      true  "User ID"
+// @Param       mode             path     string      false "'delete' (default) removes the user, 'deactivate' keeps the record"
+// @Param       created_meetings path     string      false "'reassign' (default) passes meetings created by the user to another member, 'cancel' cancels them"
+// @Param       reassign_to      path     uint32      false "ID of the user to pass the meetings to. If not specified, the first remaining member is used."
+// @Success     200              {string} string      "empty"
+// @Success     204              {string} string      "empty, the versioned API answers with No Content"
+// @Failure     400              {object} lib.Problem "error description"
+// @Failure     404              {object} lib.Problem "error description"
+// @Failure     500              {object} lib.Problem "error description"
+// @Router      /user [delete]
+// @Router      /api/v1/users/{id} [delete]
+func (s *Service) userDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	if err := parseForm(r); err != nil {
+		writeError(w, r, err)
 		return
 	}
-	params := parameters{nameTag: singleValue | parameterRequired}
-	if !checkArgs(&r.Form, params) {
-		w.WriteHeader(http.StatusBadRequest)
+	params := parameters{
+		idTag:         singleValue | parameterRequired,
+		modeTag:       singleValue,
+		createdTag:    singleValue,
+		reassignToTag: singleValue,
+	}
+	if err := checkArgs(&r.Form, params); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	id, err := createUser(r.FormValue(nameTag))
+	id, err := strconv.ParseUint(r.FormValue(idTag), 10, 32)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, paramError(idTag, err))
+		return
+	}
+	if err := s.authorize(r, idTag, UID(id)); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	json.NewEncoder(w).Encode(struct{ Id UID }{id})
+	deactivate := false
+	switch r.FormValue(modeTag) {
+	case "", "delete":
+	case "deactivate":
+		deactivate = true
+	default:
+		writeError(w, r, &ParamError{Param: modeTag, Err: fmt.Errorf("unknown value %q: %w", r.FormValue(modeTag), ErrParse)})
+		return
+	}
+
+	cancelCreated := false
+	switch r.FormValue(createdTag) {
+	case "", "reassign":
+	case "cancel":
+		cancelCreated = true
+	default:
+		writeError(w, r, &ParamError{Param: createdTag, Err: fmt.Errorf("unknown value %q: %w", r.FormValue(createdTag), ErrParse)})
+		return
+	}
+
+	var reassignTo UID
+	if _, ok := r.Form[reassignToTag]; ok {
+		tmp, err := strconv.ParseUint(r.FormValue(reassignToTag), 10, 32)
+		if err != nil {
+			writeError(w, r, paramError(reassignToTag, err))
+			return
+		}
+		if cancelCreated || UID(tmp) == UID(id) {
+			writeError(w, r, &ParamError{Param: reassignToTag, Err: fmt.Errorf("can't be used to cancel the meetings or pass them to the removed user: %w", ErrParse)})
+			return
+		}
+		reassignTo = UID(tmp)
+		if _, err := s.findActiveUser(reassignTo); err != nil {
+			writeError(w, r, err)
+			return
+		}
+	}
+
+	if err = s.storage.UserRemove(UID(id), deactivate, cancelCreated, reassignTo); err != nil {
+		writeError(w, r, fmt.Errorf("remove id=%v: %w", id, err))
+		return
+	}
 }
 
 // @Summary     get meetings
-// @Description get meeting for given id or list with all meetings
+// @Description get meeting for given id or list of the meetings.
+// @Description The list is sorted and may be cut into pages, the Link header refers to the next page.
 // @Accept      application/x-www-form-urlencoded
 // @Produce     application/json
-// @Param       id  path     string      false "Meeting ID"
-// @Success     200 {object} lib.Meeting "Meeting information"
-// @Failure     400 {string} string      "empty"
-// @Failure     404 {string} string      "empty"
-// @Failure     500 {string} string      "empty"
+// @Param       id         path     string      false "Meeting ID"
+// @Param       creator_id path     uint32      false "List the meetings created by the user"
+// @Param       member_id  path     uint32      false "List the meetings the user is a member of"
+// @Param       period     path     string      false "List the meetings repeating with the period" Enums(lib.Period)
+// @Param       start_at   path     string      false "List the meetings which occurrences end after the time in RFC3339"
+// @Param       end_at     path     string      false "List the meetings which occurrences start before the time in RFC3339"
+// @Param       sort       path     string      false "List order, by id by default. The meetings of the same first occurrence time are ordered by id." Enums(id, start_at)
+// @Param       limit      path     uint32      false "Page length, all the meetings by default. Up to 1000."
+// @Param       cursor     path     string      false "Position the page starts after, given in the Link header of the previous page"
+// @Success     200        {object} lib.Meeting "Meeting information"
+// @Failure     400        {object} lib.Problem "error description"
+// @Failure     404        {object} lib.Problem "error description"
+// @Failure     500        {object} lib.Problem "error description"
 // @Router      /meeting [get]
-func meetingGetHandler(w http.ResponseWriter, r *http.Request) {
-	if r.ParseForm() != nil {
-		w.WriteHeader(http.StatusBadRequest)
+// @Router      /api/v1/meetings [get]
+// @Router      /api/v1/meetings/{id} [get]
+func (s *Service) meetingGetHandler(w http.ResponseWriter, r *http.Request) {
+	if err := parseForm(r); err != nil {
+		writeError(w, r, err)
 		return
 	}
-	params := parameters{idTag: singleValue}
-	if !checkArgs(&r.Form, params) {
-		w.WriteHeader(http.StatusBadRequest)
+	params := parameters{
+		idTag:        singleValue,
+		creatorIdTag: singleValue,
+		memberIdTag:  singleValue,
+		periodTag:    singleValue,
+		startAtTag:   singleValue,
+		endAtTag:     singleValue,
+		sortTag:      singleValue,
+		limitTag:     singleValue,
+		cursorTag:    singleValue,
+	}
+	if err := checkArgs(&r.Form, params); err != nil {
+		writeError(w, r, err)
 		return
 	}
 	var id MeetingId
@@ -200,23 +427,19 @@ func meetingGetHandler(w http.ResponseWriter, r *http.Request) {
 	if idSet {
 		tmp, err := strconv.ParseUint(v[0], 10, 32)
 		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
+			writeError(w, r, paramError(idTag, err))
 			return
 		}
 		id = MeetingId(tmp)
 
-		meet, err := meetingFindById(id)
+		meet, err := s.storage.MeetingFindById(id)
 		if err != nil {
-			if errors.Is(err, ErrNotExist) {
-				w.WriteHeader(http.StatusNotFound)
-			} else {
-				w.WriteHeader(http.StatusInternalServerError)
-			}
+			writeError(w, r, err)
 			return
 		}
 		result, err := json.MarshalIndent(meet, "", "  ")
 		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
+			writeError(w, r, err)
 			return
 		}
 		w.Header().Set(contentTypeTag, mimeJson)
@@ -224,53 +447,61 @@ func meetingGetHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	meets, err := meetingList()
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-	result, err := json.MarshalIndent(meets, "", "  ")
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-	w.Header().Set(contentTypeTag, mimeJson)
-	w.Write(result)
+	s.meetingList(w, r)
 }
 
 // @Summary     add new meeting
 // @Description add new meeting
-// @Accept      application/x-www-form-urlencoded
+// @Accept      application/x-www-form-urlencoded,application/json
 // @Produce     application/json
-// @Param       creator_id path     uint32        true "Organizator ID"
-// @Param       member_ids path     []uint32      true "Member ID list separated with a comma (',')"
-// @Param       start_at   path     string             true  "Meeting start time in RFC3339"
-// @Param       duration   path     string             true  "Meeting duration in format '1h2m3s'. Any of values may be ommited."
-// @Param       period     path     string             false "string enums" Enums(lib.Period)
-// @Success     200        {object} lib.MeetingId "Meeting ID"
-// @Failure     400        {string} string        "empty"
-// @Failure     500        {string} string        "empty"
+// @Param       creator_id          path     uint32             true  "Organizator ID"
+// @Param       member_ids          path     []uint32           true  "Member ID list separated with a comma (',')"
+// @Param       optional_member_ids path     []uint32           false "ID list of optional members separated with a comma (',')"
+// @Param       start_at            path     string             true  "Meeting start time in RFC3339"
+// @Param       duration            path     string             true  "Meeting duration in format '1h2m3s'. Any of values may be ommited."
+// @Param       period              path     string             false "string enums" Enums(lib.Period)
+// @Param       rrule               path     string             false "Recurrence rule in RFC 5545 RRULE format, e.g. 'FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH'. Period must be omitted or Custom."
+// @Param       time_zone           path     string             false "IANA time zone, e.g. 'Europe/Berlin'. The meeting repeats at the same local time in it. UTC by default."
+// @Param       resource_ids        path     []uint32           false "ID list of the resources to book separated with a comma (',')"
+// @Param       conflict_policy     path     string             false "Overlapping meetings of the members are not checked (default), reported or make the meeting rejected" Enums(allow, warn, reject)
+// @Param       request             body     lib.MeetingRequest false "Meeting in the JSON body instead of the parameters. Members have roles, their status can't be set."
+// @Success     200                 {object} lib.MeetingId      "Meeting ID and the conflicts if the policy is warn"
+// @Success     201                 {object} lib.MeetingId      "Meeting ID, the versioned API answers with the meeting Location"
+// @Failure     400                 {object} lib.Problem        "error description"
+// @Failure     404                 {object} lib.Problem        "error description"
+// @Failure     409                 {object} lib.Problem        "Conflicts if the policy is reject or a resource is booked"
+// @Failure     500                 {object} lib.Problem        "error description"
 // @Router      /meeting [post]
-func meetingPostHandler(w http.ResponseWriter, r *http.Request) {
-	if r.ParseForm() != nil {
-		w.WriteHeader(http.StatusBadRequest)
+// @Router      /api/v1/meetings [post]
+func (s *Service) meetingPostHandler(w http.ResponseWriter, r *http.Request) {
+	if err := parseRequest(r, &MeetingRequest{}); err != nil {
+		writeError(w, r, err)
 		return
 	}
 	params := parameters{
-		creatorIdTag: singleValue | parameterRequired,
-		memberIdsTag: multipleValue | parameterRequired,
-		startAtTag:   singleValue | parameterRequired,
-		durationTag:  singleValue | parameterRequired,
-		periodTag:    singleValue,
+		creatorIdTag:      singleValue | parameterRequired,
+		memberIdsTag:      multipleValue | parameterRequired,
+		optionalTag:       multipleValue,
+		startAtTag:        singleValue | parameterRequired,
+		durationTag:       singleValue | parameterRequired,
+		periodTag:         singleValue,
+		ruleTag:           singleValue,
+		timeZoneTag:       singleValue,
+		resourceIdsTag:    multipleValue,
+		conflictPolicyTag: singleValue,
 	}
-	if !checkArgs(&r.Form, params) {
-		w.WriteHeader(http.StatusBadRequest)
+	if err := checkArgs(&r.Form, params); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
 	creatorId, err := strconv.ParseUint(r.FormValue(creatorIdTag), 10, 32)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
+		writeError(w, r, paramError(creatorIdTag, err))
+		return
+	}
+	if err := s.authorize(r, creatorIdTag, UID(creatorId)); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
@@ -279,32 +510,45 @@ func meetingPostHandler(w http.ResponseWriter, r *http.Request) {
 		for _, num := range strings.Split(mId, ",") {
 			id, err := strconv.ParseUint(num, 10, 32)
 			if err != nil {
-				w.WriteHeader(http.StatusBadRequest)
+				writeError(w, r, paramError(memberIdsTag, err))
 				return
 			}
-			_, err = userFindById(UID(id))
+			_, err = s.findActiveUser(UID(id))
 			if err != nil {
-				if errors.Is(err, ErrNotExist) {
-					w.WriteHeader(http.StatusNotFound)
-				} else {
-					w.WriteHeader(http.StatusInternalServerError)
-				}
+				writeError(w, r, err)
 				return
 			}
 			members = append(members, Participant{UserId: UID(id), Status: Unknown})
 		}
 	}
 
+	optionalIds, err := parseIdList(r.Form[optionalTag])
+	if err != nil {
+		writeError(w, r, paramError(optionalTag, err))
+		return
+	}
+	for _, id := range optionalIds {
+		if _, err := s.findActiveUser(id); err != nil {
+			writeError(w, r, err)
+			return
+		}
+		if (Meeting{MeetingInfo: MeetingInfo{Members: members}}).hasMember(id) {
+			writeError(w, r, &ParamError{Param: optionalTag, Err: fmt.Errorf("user %d is listed as a required and an optional member: %w", id, ErrParse)})
+			return
+		}
+		members = append(members, Participant{UserId: id, Status: Unknown, Role: Optional})
+	}
+
 	startAt, err := time.Parse(time.RFC3339, r.FormValue(startAtTag))
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
+		writeError(w, r, paramError(startAtTag, err))
 		return
 	}
 	startAt = startAt.UTC()
 
 	dur, err := time.ParseDuration(r.FormValue(durationTag))
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
+		writeError(w, r, paramError(durationTag, err))
 		return
 	}
 	duration := Duration{dur}
@@ -313,35 +557,408 @@ func meetingPostHandler(w http.ResponseWriter, r *http.Request) {
 	if str, ok := r.Form[periodTag]; ok {
 		repeat, err = ParsePeriod(str[0])
 		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
+			writeError(w, r, paramError(periodTag, err))
+			return
+		}
+	}
+
+	rule, err := parseRule(r.Form, repeat)
+	if err != nil {
+		writeError(w, r, parseError(err))
+		return
+	}
+	if rule != nil {
+		repeat = Custom
+	}
+
+	timeZone := r.FormValue(timeZoneTag)
+	if _, err := loadLocation(timeZone); err != nil {
+		writeError(w, r, paramError(timeZoneTag, err))
+		return
+	}
+
+	resources, err := parseResourceIds(r.Form[resourceIdsTag])
+	if err != nil {
+		writeError(w, r, paramError(resourceIdsTag, err))
+		return
+	}
+
+	policy := policyAllow
+	if _, ok := r.Form[conflictPolicyTag]; ok {
+		policy, err = parseConflictPolicy(r.FormValue(conflictPolicyTag))
+		if err != nil {
+			writeError(w, r, paramError(conflictPolicyTag, err))
+			return
+		}
+	}
+
+	meeting := Meeting{MeetingInfo: MeetingInfo{
+		CreatorId:      UID(creatorId),
+		Members:        members,
+		FirstOccurence: startAt,
+		Duration:       duration,
+		Repeat:         repeat,
+		Rule:           rule,
+		TimeZone:       timeZone,
+		Resources:      resources,
+	}}
+	if err := s.checkResources(meeting); err != nil {
+		writeError(w, r, &ParamError{Param: resourceIdsTag, Err: err})
+		return
+	}
+	conflicts, ok := s.checkConflicts(w, r, meeting, policy)
+	if !ok {
+		return
+	}
+
+	id, err := s.storage.MeetingAdd(meeting.MeetingInfo)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeCreated(w, r, fmt.Sprintf("meetings/%d", id))
+	json.NewEncoder(w).Encode(struct {
+		Id        MeetingId
+		Conflicts []Conflict `json:",omitempty"`
+	}{id, conflicts})
+}
+
+// @Summary     change meeting
+// @Description change meeting time, period or members. Only the meeting creator may change it.
+// @Description If the time is changed, the members' responses are reset to Unknown.
+// @Accept      application/x-www-form-urlencoded
+// @Produce     application/json
+// @Param       id                      path     uint32         true  "Meeting ID"
+// @Param       user_id                 path     uint32         true  "ID of the user changing the meeting"
+// @Param       add_member_ids          path     []uint32       false "ID list of required members to add separated with a comma (',')"
+// @Param       add_optional_member_ids path     []uint32       false "ID list of optional members to add separated with a comma (','). Members already in the meeting change their role."
+// @Param       remove_member_ids       path     []uint32       false "ID list of members to remove separated with a comma (',')"
+// @Param       start_at                path     string         false "Meeting start time in RFC3339"
+// @Param       duration                path     string         false "Meeting duration in format '1h2m3s'. Any of values may be ommited."
+// @Param       period                  path     string         false "string enums" Enums(lib.Period)
+// @Param       rrule                   path     string         false "Recurrence rule in RFC 5545 RRULE format. Period must be omitted or Custom."
+// @Param       time_zone               path     string         false "IANA time zone the meeting repeats in"
+// @Param       add_resource_ids        path     []uint32       false "ID list of the resources to book separated with a comma (',')"
+// @Param       remove_resource_ids     path     []uint32       false "ID list of the resources to release separated with a comma (',')"
+// @Param       conflict_policy         path     string         false "Overlapping meetings of the members are not checked (default), reported or make the change rejected" Enums(allow, warn, reject)
+// @Success     200                     {object} lib.Meeting    "Changed meeting and the conflicts if the policy is warn"
+// @Failure     400                     {object} lib.Problem    "error description"
+// @Failure     403                     {object} lib.Problem    "error description"
+// @Failure     404                     {object} lib.Problem    "error description"
+// @Failure     409                     {object} lib.Problem    "Conflicts if the policy is reject or a resource is booked"
+// @Failure     500                     {object} lib.Problem    "error description"
+// @Router      /meeting [patch]
+// @Router      /api/v1/meetings/{id} [patch]
+func (s *Service) meetingPatchHandler(w http.ResponseWriter, r *http.Request) {
+	if err := parseForm(r); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	params := parameters{
+		idTag:             singleValue | parameterRequired,
+		userIdTag:         singleValue | parameterRequired,
+		addMembersTag:     multipleValue,
+		addOptionalTag:    multipleValue,
+		delMembersTag:     multipleValue,
+		startAtTag:        singleValue,
+		durationTag:       singleValue,
+		periodTag:         singleValue,
+		ruleTag:           singleValue,
+		timeZoneTag:       singleValue,
+		addResourcesTag:   multipleValue,
+		delResourcesTag:   multipleValue,
+		conflictPolicyTag: singleValue,
+	}
+	if err := checkArgs(&r.Form, params); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	meetingId, err := strconv.ParseUint(r.FormValue(idTag), 10, 32)
+	if err != nil {
+		writeError(w, r, paramError(idTag, err))
+		return
+	}
+
+	userId, err := strconv.ParseUint(r.FormValue(userIdTag), 10, 32)
+	if err != nil {
+		writeError(w, r, paramError(userIdTag, err))
+		return
+	}
+	if err := s.authorize(r, userIdTag, UID(userId)); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	addIds, err := parseIdList(r.Form[addMembersTag])
+	if err != nil {
+		writeError(w, r, paramError(addMembersTag, err))
+		return
+	}
+
+	addOptionalIds, err := parseIdList(r.Form[addOptionalTag])
+	if err != nil {
+		writeError(w, r, paramError(addOptionalTag, err))
+		return
+	}
+
+	removeIds, err := parseIdList(r.Form[delMembersTag])
+	if err != nil {
+		writeError(w, r, paramError(delMembersTag, err))
+		return
+	}
+
+	addResources, err := parseResourceIds(r.Form[addResourcesTag])
+	if err != nil {
+		writeError(w, r, paramError(addResourcesTag, err))
+		return
+	}
+
+	removeResources, err := parseResourceIds(r.Form[delResourcesTag])
+	if err != nil {
+		writeError(w, r, paramError(delResourcesTag, err))
+		return
+	}
+
+	policy := policyAllow
+	if _, ok := r.Form[conflictPolicyTag]; ok {
+		policy, err = parseConflictPolicy(r.FormValue(conflictPolicyTag))
+		if err != nil {
+			writeError(w, r, paramError(conflictPolicyTag, err))
+			return
+		}
+	}
+
+	meeting, err := s.storage.MeetingFindById(MeetingId(meetingId))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if meeting.CreatorId != UID(userId) {
+		writeError(w, r, fmt.Errorf("user %d is not the creator of meeting %d: %w", userId, meetingId, ErrForbidden))
+		return
+	}
+
+	timeChanged := false
+	schedule := meeting.scheduleString()
+	if _, ok := r.Form[startAtTag]; ok {
+		startAt, err := time.Parse(time.RFC3339, r.FormValue(startAtTag))
+		if err != nil {
+			writeError(w, r, paramError(startAtTag, err))
+			return
+		}
+		startAt = startAt.UTC()
+		timeChanged = timeChanged || !startAt.Equal(meeting.FirstOccurence)
+		meeting.FirstOccurence = startAt
+	}
+	if _, ok := r.Form[durationTag]; ok {
+		dur, err := time.ParseDuration(r.FormValue(durationTag))
+		if err != nil {
+			writeError(w, r, paramError(durationTag, err))
+			return
+		}
+		timeChanged = timeChanged || dur != meeting.Duration.Duration
+		meeting.Duration = Duration{dur}
+	}
+	_, periodSet := r.Form[periodTag]
+	_, ruleSet := r.Form[ruleTag]
+	if periodSet || ruleSet {
+		repeat := Custom
+		if periodSet {
+			repeat, err = ParsePeriod(r.FormValue(periodTag))
+			if err != nil {
+				writeError(w, r, paramError(periodTag, err))
+				return
+			}
+		}
+		rule, err := parseRule(r.Form, repeat)
+		if err != nil {
+			writeError(w, r, parseError(err))
+			return
+		}
+		if rule != nil {
+			repeat = Custom
+		}
+		timeChanged = timeChanged || repeat != meeting.Repeat || rule != nil && (meeting.Rule == nil || rule.String() != meeting.Rule.String())
+		meeting.Repeat = repeat
+		meeting.Rule = rule
+	}
+	if _, ok := r.Form[timeZoneTag]; ok {
+		timeZone := r.FormValue(timeZoneTag)
+		if _, err := loadLocation(timeZone); err != nil {
+			writeError(w, r, paramError(timeZoneTag, err))
+			return
+		}
+		meeting.TimeZone = timeZone
+	}
+
+	for _, id := range removeIds {
+		found := false
+		for i := range meeting.Members {
+			if meeting.Members[i].UserId == id {
+				meeting.Members = append(meeting.Members[:i], meeting.Members[i+1:]...)
+				found = true
+				break
+			}
+		}
+		if !found {
+			writeError(w, r, fmt.Errorf("user %d is not a member of meeting %d: %w", id, meetingId, ErrNotExist))
+			return
+		}
+	}
+	for _, add := range []struct {
+		ids  []UID
+		role Role
+	}{{addIds, Required}, {addOptionalIds, Optional}} {
+		for _, id := range add.ids {
+			if _, err := s.findActiveUser(id); err != nil {
+				writeError(w, r, err)
+				return
+			}
+			meeting.setMember(id, add.role)
+		}
+	}
+	if len(meeting.Members) == 0 {
+		writeError(w, r, fmt.Errorf("no members left in meeting %d: %w", meetingId, ErrParse))
+		return
+	}
+
+	for _, id := range removeResources {
+		found := false
+		for i := range meeting.Resources {
+			if meeting.Resources[i] == id {
+				meeting.Resources = append(meeting.Resources[:i], meeting.Resources[i+1:]...)
+				found = true
+				break
+			}
+		}
+		if !found {
+			writeError(w, r, fmt.Errorf("resource %d is not booked by meeting %d: %w", id, meetingId, ErrNotExist))
 			return
 		}
 	}
+	for _, id := range addResources {
+		if !meeting.hasResource(id) {
+			meeting.Resources = append(meeting.Resources, id)
+		}
+	}
+	if err := s.checkResources(meeting); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// the changed occurrences may not exist in the new schedule
+	if meeting.scheduleString() != schedule {
+		timeChanged = true
+		meeting.ExDates = nil
+		meeting.Overrides = nil
+	}
+	if timeChanged {
+		for i := range meeting.Members {
+			meeting.Members[i].Status = Unknown
+		}
+	}
+	conflicts, ok := s.checkConflicts(w, r, meeting, policy)
+	if !ok {
+		return
+	}
+
+	if err = s.storage.MeetingUpdate(meeting); err != nil {
+		writeError(w, r, fmt.Errorf("update id=%v: %w", meetingId, err))
+		return
+	}
+
+	result, err := json.MarshalIndent(struct {
+		Meeting
+		Conflicts []Conflict `json:",omitempty"`
+	}{meeting, conflicts}, "", "  ")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.Header().Set(contentTypeTag, mimeJson)
+	w.Write(result)
+}
+
+// @Summary     cancel meeting
+// @Description remove the meeting and notify its members. Only the meeting creator may cancel it.
+// @Accept      application/x-www-form-urlencoded
+// @Produce     application/json
+// @Param       id      path     uint32      true "Meeting ID"
+// @Param       user_id path     uint32      true "ID of the user cancelling the meeting"
+// @Success     200     {string} string      "empty"
+// @Success     204     {string} string      "empty, the versioned API answers with No Content"
+// @Failure     400     {object} lib.Problem "error description"
+// @Failure     403     {object} lib.Problem "error description"
+// @Failure     404     {object} lib.Problem "error description"
+// @Failure     500     {object} lib.Problem "error description"
+// @Router      /meeting [delete]
+// @Router      /api/v1/meetings/{id} [delete]
+func (s *Service) meetingDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	if err := parseForm(r); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	params := parameters{
+		idTag:     singleValue | parameterRequired,
+		userIdTag: singleValue | parameterRequired,
+	}
+	if err := checkArgs(&r.Form, params); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	meetingId, err := strconv.ParseUint(r.FormValue(idTag), 10, 32)
+	if err != nil {
+		writeError(w, r, paramError(idTag, err))
+		return
+	}
+
+	userId, err := strconv.ParseUint(r.FormValue(userIdTag), 10, 32)
+	if err != nil {
+		writeError(w, r, paramError(userIdTag, err))
+		return
+	}
+	if err := s.authorize(r, userIdTag, UID(userId)); err != nil {
+		writeError(w, r, err)
+		return
+	}
 
-	id, err := createMeeting(UID(creatorId), members, startAt, duration, repeat)
+	meeting, err := s.storage.MeetingFindById(MeetingId(meetingId))
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, err)
+		return
+	}
+	if meeting.CreatorId != UID(userId) {
+		writeError(w, r, fmt.Errorf("user %d is not the creator of meeting %d: %w", userId, meetingId, ErrForbidden))
 		return
 	}
 
-	json.NewEncoder(w).Encode(struct{ Id MeetingId }{id})
+	if err = s.storage.MeetingDelete(meeting.Id, UID(userId)); err != nil {
+		writeError(w, r, fmt.Errorf("delete id=%v: %w", meetingId, err))
+		return
+	}
 }
 
 // @Summary     send presence response
 // @Description send presence responce
-// @Accept      application/x-www-form-urlencoded
+// @Accept      application/x-www-form-urlencoded,application/json
 // @Produce     application/json
-// @Param       user_id    path     uint32        true "User ID"
-// @Param       meeting_id path     uint32        true "Meeting ID"
-// @Param       presence   path     string        true "string enums" Enums(lib.Presence)
-// @Success     200        {object} lib.MeetingId "Meeting ID"
-// @Failure     400        {string} string        "empty"
-// @Failure     404        {string} string        "empty"
-// @Failure     500        {string} string        "empty"
+// @Param       user_id    path     uint32              true  "User ID"
+// @Param       meeting_id path     uint32              true  "Meeting ID"
+// @Param       presence   path     string              true  "string enums" Enums(lib.Presence)
+// @Param       request    body     lib.PresenceRequest false "Response in the JSON body instead of the parameters"
+// @Success     200        {object} lib.MeetingId       "Meeting ID"
+// @Success     204        {string} string              "empty, the versioned API answers with No Content"
+// @Failure     400        {object} lib.Problem         "error description"
+// @Failure     404        {object} lib.Problem         "error description"
+// @Failure     500        {object} lib.Problem         "error description"
 // @Router      /response [put]
-func responsePutHandler(w http.ResponseWriter, r *http.Request) {
-	if r.ParseForm() != nil {
-		w.WriteHeader(http.StatusBadRequest)
+// @Router      /api/v1/meetings/{meeting_id}/participants/{user_id}/response [put]
+func (s *Service) responsePutHandler(w http.ResponseWriter, r *http.Request) {
+	if err := parseRequest(r, &PresenceRequest{}); err != nil {
+		writeError(w, r, err)
 		return
 	}
 	params := parameters{
@@ -349,36 +966,36 @@ func responsePutHandler(w http.ResponseWriter, r *http.Request) {
 		meetingIdTag: singleValue | parameterRequired,
 		presenceTag:  singleValue | parameterRequired,
 	}
-	if !checkArgs(&r.Form, params) {
-		w.WriteHeader(http.StatusBadRequest)
+	if err := checkArgs(&r.Form, params); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
 	userId, err := strconv.ParseUint(r.FormValue(userIdTag), 10, 32)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
+		writeError(w, r, paramError(userIdTag, err))
+		return
+	}
+	if err := s.authorize(r, userIdTag, UID(userId)); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
 	meetingId, err := strconv.ParseUint(r.FormValue(meetingIdTag), 10, 32)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
+		writeError(w, r, paramError(meetingIdTag, err))
 		return
 	}
 
 	presence, err := ParsePresence(r.FormValue(presenceTag))
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
+		writeError(w, r, paramError(presenceTag, err))
 		return
 	}
 
-	meeting, err := meetingFindById(MeetingId(meetingId))
+	meeting, err := s.storage.MeetingFindById(MeetingId(meetingId))
 	if err != nil {
-		if errors.Is(err, ErrNotExist) {
-			w.WriteHeader(http.StatusNotFound)
-		} else {
-			w.WriteHeader(http.StatusInternalServerError)
-		}
+		writeError(w, r, err)
 		return
 	}
 
@@ -391,13 +1008,13 @@ func responsePutHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 	if !userFound {
-		w.WriteHeader(http.StatusNotFound)
+		writeError(w, r, &ParamError{Param: userIdTag, Err: fmt.Errorf("user %d is not a member of meeting %d: %w", userId, meetingId, ErrNotExist)})
 		return
 	}
 
-	err = meetingUpdate(meeting)
+	err = s.storage.MeetingUpdate(meeting)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 }
@@ -406,94 +1023,165 @@ func responsePutHandler(w http.ResponseWriter, r *http.Request) {
 // @Description get user meetings for specified period
 // @Accept      application/x-www-form-urlencoded
 // @Produce     application/json
-// @Param       id       path     uint32        true "User ID"
-// @Param       start_at path     string             true "Search period start time in RFC3339"
-// @Param       duration path     string             true "Search period duration in format '1h2m3s'. Any of values may be ommited."
-// @Success     200      {object} lib.MeetingId "Meeting ID"
-// @Failure     400      {string} string        "empty"
-// @Failure     404      {string} string        "empty"
-// @Failure     500      {string} string        "empty"
+// @Param       id        path     uint32        true  "User ID"
+// @Param       start_at  path     string        true  "Search period start time in RFC3339"
+// @Param       duration  path     string        true  "Search period duration in format '1h2m3s'. Any of values may be ommited."
+// @Param       cancelled path     bool          false "Return the list of lib.Cancellation for meetings cancelled in the period instead"
+// @Success     200       {object} lib.MeetingId "Meeting ID"
+// @Failure     400       {object} lib.Problem   "error description"
+// @Failure     404       {object} lib.Problem   "error description"
+// @Failure     500       {object} lib.Problem   "error description"
 // @Router      /user_meetings [get]
-func userMeetingsGetHandler(w http.ResponseWriter, r *http.Request) {
-	if r.ParseForm() != nil {
-		w.WriteHeader(http.StatusBadRequest)
+// @Router      /api/v1/users/{id}/meetings [get]
+func (s *Service) userMeetingsGetHandler(w http.ResponseWriter, r *http.Request) {
+	if err := parseForm(r); err != nil {
+		writeError(w, r, err)
 		return
 	}
 	params := parameters{
-		idTag:       singleValue | parameterRequired,
-		startAtTag:  singleValue | parameterRequired,
-		durationTag: singleValue | parameterRequired,
+		idTag:        singleValue | parameterRequired,
+		startAtTag:   singleValue | parameterRequired,
+		durationTag:  singleValue | parameterRequired,
+		cancelledTag: singleValue,
 	}
-	if !checkArgs(&r.Form, params) {
-		w.WriteHeader(http.StatusBadRequest)
+	if err := checkArgs(&r.Form, params); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
 	id, err := strconv.ParseUint(r.FormValue(idTag), 10, 32)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
+		writeError(w, r, paramError(idTag, err))
 		return
 	}
 	userId := UID(id)
 
 	startAt, err := time.Parse(time.RFC3339, r.FormValue(startAtTag))
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
+		writeError(w, r, paramError(startAtTag, err))
 		return
 	}
 	startAt = startAt.UTC()
 
 	duration, err := time.ParseDuration(r.FormValue(durationTag))
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
+		writeError(w, r, paramError(durationTag, err))
+		return
+	}
+
+	cancelled := false
+	if _, ok := r.Form[cancelledTag]; ok {
+		cancelled, err = strconv.ParseBool(r.FormValue(cancelledTag))
+		if err != nil {
+			writeError(w, r, paramError(cancelledTag, err))
+			return
+		}
+	}
+	if cancelled {
+		s.userCancellationsGetHandler(w, r, userId, startAt, duration)
 		return
 	}
 
-	meets, err := getUserMeetings(userId)
+	meets, err := s.storage.UserMeetingsBetween(userId, startAt, startAt.Add(duration))
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
 	meeting_ids := make([]MeetingId, 0, len(meets))
 	for _, meet := range meets {
-		meetingStartTime := meet.meetingStartTimeAfter(startAt)
-		if !meetingStartTime.IsZero() && meetingStartTime.Before(startAt.Add(duration)) {
-			meeting_ids = append(meeting_ids, meet.Id)
-		}
+		meeting_ids = append(meeting_ids, meet.Id)
 	}
 
 	w.Header().Set(contentTypeTag, mimeJson)
 	if err = json.NewEncoder(w).Encode(meeting_ids); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, err)
+		return
+	}
+}
+
+// writes cancellations of the meetings which would take place in the given period
+func (s *Service) userCancellationsGetHandler(w http.ResponseWriter, r *http.Request, userId UID, startAt time.Time, duration time.Duration) {
+	cancellations, err := s.storage.UserCancellations(userId)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	result := make([]Cancellation, 0, len(cancellations))
+	for _, c := range cancellations {
+		if c.takesPlaceBetween(startAt, startAt.Add(duration)) {
+			result = append(result, c)
+		}
+	}
+
+	w.Header().Set(contentTypeTag, mimeJson)
+	if err = json.NewEncoder(w).Encode(result); err != nil {
+		writeError(w, r, err)
 		return
 	}
 }
 
 // @Summary     find closest free time
-// @Description get the closest free time for all required users and the specified period
+// @Description get the closest free time for all required users and the specified period.
+// @Description Meetings rejected by the user don't block the user time.
+// @Description If limit, optional_ids or a resource requirement is given, returns the list of up to limit (1 by default)
+// @Description non-overlapping lib.Slot ordered by rank instead. Every slot lists the optional users who can't make it.
+// @Description If resource_capacity or resource_attributes is given, every slot has a suitable free resource,
+// @Description the smallest suitable one is suggested.
+// @Description The score rank prefers earlier slots, slots without adjacent meetings and slots starting at round time.
 // @Accept      application/x-www-form-urlencoded
 // @Produce     application/json
-// @Param       id       path     []uint32      true "User ID"
-// @Param       start_at path     string             false "Search period start time in RFC3339. If not specified, the app uses now."
-// @Param       duration path     string             true  "Search period duration in format '1h2m3s'. Any of values may be ommited."
-// @Success     200      {object} lib.MeetingId "Meeting ID"
-// @Failure     400      {string} string        "empty"
-// @Failure     404      {string} string        "empty"
-// @Failure     500      {string} string        "empty"
+// @Param       id                  path     []uint32    true  "Required user ID list separated with a comma (',')"
+// @Param       optional_ids        path     []uint32    false "Optional user ID list separated with a comma (',')"
+// @Param       quorum              path     uint32      false "Minimum number of optional users who must be free, 0 by default"
+// @Param       resource_ids        path     []uint32    false "ID list of the resources which must be free separated with a comma (',')"
+// @Param       resource_capacity   path     uint32      false "Minimum capacity of the suitable resource. The number of the users by default."
+// @Param       resource_attributes path     []string    false "Attributes the suitable resource must have separated with a comma (','), e.g. 'room,projector'"
+// @Param       start_at            path     string      false "Search period start time in RFC3339. If not specified, the app uses now."
+// @Param       duration            path     string      true  "Search period duration in format '1h2m3s'. Any of values may be ommited."
+// @Param       end_at              path     string      false "Search period end time in RFC3339, at most 366 days after the start. A year after the start by default."
+// @Param       horizon             path     string      false "Search period length in format '1h2m3s' instead of end_at, at most 366 days"
+// @Param       weekdays            path     string      false "Allowed week days separated with a comma, e.g. 'MO,TU,WE,TH,FR'"
+// @Param       window              path     string      false "Allowed periods of the day separated with a comma, e.g. '09:00-12:00,13:00-17:00'"
+// @Param       time_zone           path     string      false "IANA time zone of the week days, the day periods and the granularity. UTC by default."
+// @Param       granularity         path     string      false "Slots start at multiples of it from the midnight, e.g. '15m' or '30m'"
+// @Param       limit               path     uint32      false "Maximum number of slots to return"
+// @Param       rank                path     string      false "Slot ranking: earliest (default) or score"                 Enums(earliest, score)
+// @Param       unknown_presence    path     string      false "Meetings without response are busy (default) or tentative" Enums(busy, tentative)
+// @Param       tentative           path     string      false "Tentative meetings are busy (default) or free"             Enums(busy, free)
+// @Success     200                 {array}  lib.Slot    "Free slots. Only the start time of the closest one in RFC3339 if none of limit, optional_ids and resource requirements is given."
+// @Failure     400                 {object} lib.Problem "error description"
+// @Failure     404                 {object} lib.Problem "error description"
+// @Failure     500                 {object} lib.Problem "error description"
 // @Router      /find_free_time [get]
-func findFreeTimeGetHandler(w http.ResponseWriter, r *http.Request) {
-	if r.ParseForm() != nil {
-		w.WriteHeader(http.StatusBadRequest)
+func (s *Service) findFreeTimeGetHandler(w http.ResponseWriter, r *http.Request) {
+	if err := parseForm(r); err != nil {
+		writeError(w, r, err)
 		return
 	}
 	params := parameters{
-		idTag:       multipleValue | parameterRequired,
-		startAtTag:  singleValue,
-		durationTag: singleValue | parameterRequired,
+		idTag:                 multipleValue | parameterRequired,
+		optionalIdsTag:        multipleValue,
+		quorumTag:             singleValue,
+		resourceIdsTag:        multipleValue,
+		resourceCapacityTag:   singleValue,
+		resourceAttributesTag: multipleValue,
+		startAtTag:            singleValue,
+		durationTag:           singleValue | parameterRequired,
+		endAtTag:              singleValue,
+		horizonTag:            singleValue,
+		weekdaysTag:           singleValue,
+		windowTag:             singleValue,
+		timeZoneTag:           singleValue,
+		granularityTag:        singleValue,
+		limitTag:              singleValue,
+		rankTag:               singleValue,
+		unknownTag:            singleValue,
+		tentativeTag:          singleValue,
 	}
-	if !checkArgs(&r.Form, params) {
-		w.WriteHeader(http.StatusBadRequest)
+	if err := checkArgs(&r.Form, params); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
@@ -502,19 +1190,72 @@ func findFreeTimeGetHandler(w http.ResponseWriter, r *http.Request) {
 		for _, value := range strings.Split(values, ",") {
 			id, err := strconv.ParseUint(value, 10, 32)
 			if err != nil {
-				w.WriteHeader(http.StatusBadRequest)
+				writeError(w, r, paramError(idTag, err))
 				return
 			}
 			userList = append(userList, UID(id))
 		}
 	}
+	optionalList, err := parseIdList(r.Form[optionalIdsTag])
+	if err != nil {
+		writeError(w, r, paramError(optionalIdsTag, err))
+		return
+	}
+	for _, userId := range append(append([]UID(nil), userList...), optionalList...) {
+		if _, err := s.findActiveUser(userId); err != nil {
+			writeError(w, r, err)
+			return
+		}
+	}
+
+	quorum := 0
+	if _, ok := r.Form[quorumTag]; ok {
+		value, err := strconv.ParseUint(r.FormValue(quorumTag), 10, 32)
+		if err != nil || int(value) > len(optionalList) {
+			writeError(w, r, &ParamError{Param: quorumTag, Err: fmt.Errorf("must be from 0 to the number of optional users: %w", ErrParse)})
+			return
+		}
+		quorum = int(value)
+	}
+
+	resources, err := parseResourceIds(r.Form[resourceIdsTag])
+	if err != nil {
+		writeError(w, r, paramError(resourceIdsTag, err))
+		return
+	}
+	for _, id := range resources {
+		if _, err := s.storage.ResourceFindById(id); err != nil {
+			writeError(w, r, err)
+			return
+		}
+	}
+
+	_, capacitySet := r.Form[resourceCapacityTag]
+	_, attributesSet := r.Form[resourceAttributesTag]
+	var rooms []ResourceId
+	if capacitySet || attributesSet {
+		capacity := len(userList) + len(optionalList)
+		if capacitySet {
+			value, err := strconv.ParseUint(r.FormValue(resourceCapacityTag), 10, 32)
+			if err != nil {
+				writeError(w, r, paramError(resourceCapacityTag, err))
+				return
+			}
+			capacity = int(value)
+		}
+		rooms, err = s.suitableResources(capacity, parseAttributes(r.Form[resourceAttributesTag]))
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+	}
 
 	var startAt time.Time
 	if _, ok := r.Form[startAtTag]; ok {
 		var err error
 		startAt, err = time.Parse(time.RFC3339, r.FormValue(startAtTag))
 		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
+			writeError(w, r, paramError(startAtTag, err))
 			return
 		}
 	} else {
@@ -524,40 +1265,142 @@ func findFreeTimeGetHandler(w http.ResponseWriter, r *http.Request) {
 
 	duration, err := time.ParseDuration(r.FormValue(durationTag))
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
+		writeError(w, r, paramError(durationTag, err))
 		return
 	}
 
-	searchEndTime := startAt.AddDate(1, 0, 0)
-	found := false
-checkTime:
-	for !found && startAt.Before(searchEndTime) {
-		for _, userId := range userList {
-			meets, err := getUserMeetings(userId)
-			if err != nil {
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
+	query := freeTimeQuery{
+		users:      userList,
+		optional:   optionalList,
+		quorum:     quorum,
+		resources:  resources,
+		rooms:      rooms,
+		roomSearch: capacitySet || attributesSet,
+		start:      startAt,
+		end:        startAt.AddDate(1, 0, 0).Add(duration),
+		duration:   duration,
+		limit:      1,
+	}
+	_, endSet := r.Form[endAtTag]
+	_, horizonSet := r.Form[horizonTag]
+	if endSet && horizonSet {
+		writeError(w, r, &ParamError{Param: horizonTag, Err: fmt.Errorf("%q and %q can't be used together: %w", endAtTag, horizonTag, ErrParse)})
+		return
+	}
+	endTag := endAtTag
+	if endSet {
+		endAt, err := time.Parse(time.RFC3339, r.FormValue(endAtTag))
+		if err != nil {
+			writeError(w, r, paramError(endAtTag, err))
+			return
+		}
+		query.end = endAt.UTC()
+	}
+	if horizonSet {
+		horizon, err := time.ParseDuration(r.FormValue(horizonTag))
+		if err != nil {
+			writeError(w, r, paramError(horizonTag, err))
+			return
+		}
+		query.end = startAt.Add(horizon)
+		endTag = horizonTag
+	}
+	if !query.end.After(startAt) {
+		writeError(w, r, &ParamError{Param: endTag, Err: fmt.Errorf("search period end %v is not after its start %v: %w", query.end, startAt, ErrParse)})
+		return
+	}
+	if endSet || horizonSet {
+		if err := checkPeriodLength(endTag, startAt, query.end); err != nil {
+			writeError(w, r, err)
+			return
+		}
+	}
 
-			for _, meet := range meets {
-				meetingStartTime := meet.meetingStartTimeAfter(startAt)
-				if !meetingStartTime.IsZero() && meetingStartTime.Before(startAt.Add(duration)) {
-					startAt = meetingStartTime.Add(meet.Duration.Duration)
-					continue checkTime
-				}
-			}
+	query.location, err = loadLocation(r.FormValue(timeZoneTag))
+	if err != nil {
+		writeError(w, r, paramError(timeZoneTag, err))
+		return
+	}
+	_, weekdaysSet := r.Form[weekdaysTag]
+	_, windowSet := r.Form[windowTag]
+	if weekdaysSet || windowSet {
+		window := WeeklyHours{
+			Days:  []time.Weekday{time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday},
+			Hours: []ClockRange{{0, 24 * time.Hour}},
+		}
+		if weekdaysSet {
+			window.Days, err = parseWeekdays(r.FormValue(weekdaysTag))
+		}
+		if err == nil && windowSet {
+			window.Hours, err = parseClockRanges(r.FormValue(windowTag))
+		}
+		if err != nil {
+			writeError(w, r, parseError(err))
+			return
+		}
+		query.window = &WorkingHours{TimeZone: r.FormValue(timeZoneTag), Weekly: []WeeklyHours{window}}
+	}
+	if _, ok := r.Form[granularityTag]; ok {
+		query.granularity, err = time.ParseDuration(r.FormValue(granularityTag))
+		if err != nil || query.granularity <= 0 || query.granularity > 24*time.Hour {
+			writeError(w, r, &ParamError{Param: granularityTag, Err: fmt.Errorf("must be positive and not longer than 24h: %w", ErrParse)})
+			return
+		}
+	}
+	_, listed := r.Form[limitTag]
+	if _, ok := r.Form[optionalIdsTag]; ok || query.roomSearch {
+		listed = true
+	}
+	if _, ok := r.Form[limitTag]; ok {
+		limit, err := strconv.ParseUint(r.FormValue(limitTag), 10, 32)
+		if err != nil || limit == 0 || limit > maxSlots {
+			writeError(w, r, &ParamError{Param: limitTag, Err: fmt.Errorf("must be from 1 to %d: %w", maxSlots, ErrParse)})
+			return
+		}
+		query.limit = int(limit)
+	}
+	if _, ok := r.Form[rankTag]; ok {
+		query.rank, err = parseRank(r.FormValue(rankTag))
+		if err != nil {
+			writeError(w, r, paramError(rankTag, err))
+			return
 		}
-		found = true
 	}
 
-	if !found {
-		w.WriteHeader(http.StatusNotFound)
+	switch r.FormValue(unknownTag) {
+	case "", "busy":
+	case "tentative":
+		query.unknownTentative = true
+	default:
+		writeError(w, r, &ParamError{Param: unknownTag, Err: fmt.Errorf("unknown value %q: %w", r.FormValue(unknownTag), ErrParse)})
+		return
+	}
+	switch r.FormValue(tentativeTag) {
+	case "", "busy":
+	case "free":
+		query.tentativeFree = true
+	default:
+		writeError(w, r, &ParamError{Param: tentativeTag, Err: fmt.Errorf("unknown value %q: %w", r.FormValue(tentativeTag), ErrParse)})
+		return
+	}
+
+	slots, err := s.findFreeSlots(query)
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
 	w.Header().Set(contentTypeTag, mimeJson)
-	if err = json.NewEncoder(w).Encode(startAt); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+	if listed {
+		err = json.NewEncoder(w).Encode(slots)
+	} else if len(slots) == 0 {
+		writeError(w, r, fmt.Errorf("no free time in the search period: %w", ErrNotExist))
+		return
+	} else {
+		err = json.NewEncoder(w).Encode(slots[0].StartAt)
+	}
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 }
@@ -574,65 +1417,203 @@ func clockToTime(hour, min, sec int) time.Time {
 	return time.Date(0, 0, 0, hour, min, sec, 0, time.UTC)
 }
 
+// occurrence is a single instance of the meeting
+type occurrence struct {
+	start    time.Time
+	duration time.Duration
+}
+
+func (o occurrence) end() time.Time {
+	return o.start.Add(o.duration)
+}
+
+// returns start time of the first occurrence ending after t
+// or zero time if there is no such occurrence
 func (m Meeting) meetingStartTimeAfter(t time.Time) time.Time {
-	if m.FirstOccurence.Add(m.Duration.Duration).After(t) {
+	o, _ := m.occurrenceAfter(t)
+	return o.start
+}
+
+// returns the earliest occurrence ending after t taking the cancelled
+// and moved occurrences into account. Reports false if there is none.
+func (m Meeting) occurrenceAfter(t time.Time) (occurrence, bool) {
+	var found occurrence
+	ok := false
+	for _, o := range m.Overrides {
+		if o.StartAt.Add(o.Duration.Duration).After(t) && (!ok || o.StartAt.Before(found.start)) {
+			found = occurrence{o.StartAt, o.Duration.Duration}
+			ok = true
+		}
+	}
+
+	// the occurrence ends after t if it starts after t - duration
+	after := t.Add(-m.Duration.Duration)
+	next := m.scheduledOccurrences(after)
+	for start := next(); !start.IsZero(); start = next() {
+		if !start.After(after) || m.isException(start) {
+			continue
+		}
+		if !ok || start.Before(found.start) {
+			found = occurrence{start, m.Duration.Duration}
+			ok = true
+		}
+		break
+	}
+	return found, ok
+}
+
+// returns occurrences overlapping the period [start, end) ordered by start time
+func (m Meeting) occurrencesBetween(start, end time.Time) []occurrence {
+	result := make([]occurrence, 0)
+	for _, o := range m.Overrides {
+		if o.StartAt.Before(end) && o.StartAt.Add(o.Duration.Duration).After(start) {
+			result = append(result, occurrence{o.StartAt, o.Duration.Duration})
+		}
+	}
+
+	after := start.Add(-m.Duration.Duration)
+	next := m.scheduledOccurrences(after)
+	for t := next(); !t.IsZero() && t.Before(end); t = next() {
+		if t.After(after) && !m.isException(t) {
+			result = append(result, occurrence{t, m.Duration.Duration})
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].start.Before(result[j].start) })
+	return result
+}
+
+// returns function listing start times of the occurrences by the meeting rule
+// beginning with the period containing after. Zero time means there are no more.
+func (m Meeting) scheduledOccurrences(after time.Time) func() time.Time {
+	if rule := m.recurrence(); rule != nil {
+		it := rule.occurrences(m.FirstOccurence.In(m.location()), after)
+		return func() time.Time {
+			return it.next().UTC()
+		}
+	}
+	done := false
+	return func() time.Time {
+		if done {
+			return time.Time{}
+		}
+		done = true
 		return m.FirstOccurence
 	}
+}
 
-	switch m.Repeat {
-	case Once:
-		return time.Time{}
-	case EveryDay:
-		firstMeetingEndTime := m.FirstOccurence.Add(m.Duration.Duration)
-		truncateFactor := time.Hour * 24
-		distance := t.Sub(firstMeetingEndTime).Truncate(truncateFactor) + truncateFactor
-		return m.FirstOccurence.Add(distance)
-	case EveryWeek:
-		firstMeetingEndTime := m.FirstOccurence.Add(m.Duration.Duration)
-		truncateFactor := time.Hour * 24 * 7
-		distance := t.Sub(firstMeetingEndTime).Truncate(truncateFactor) + truncateFactor
-		return m.FirstOccurence.Add(distance)
-	case EveryMonth:
-		yearPeriod, monthPeriod, _ := t.Date()
-		yearStart, monthStart, _ := m.FirstOccurence.Date()
-		monthDistance := (yearPeriod-yearStart)*12 + int(monthPeriod-monthStart)
-		if monthDistance > 0 {
-			monthDistance--
-		}
-		meetingEndTime := m.FirstOccurence.AddDate(0, monthDistance, 0).Add(m.Duration.Duration)
-		if !meetingEndTime.After(t) {
-			monthDistance++
-		}
-		meetingStartTime := m.FirstOccurence.AddDate(0, monthDistance, 0)
-		// skip month if no such day in the month or meeting ends before the search interval begins
-		for (12+int(meetingStartTime.Month())-int(monthStart))%12 != ((12+monthDistance)%12) ||
-			!meetingStartTime.Add(m.Duration.Duration).After(t) {
-			monthDistance++
-			meetingStartTime = m.FirstOccurence.AddDate(0, monthDistance, 0)
-		}
-		return meetingStartTime
-	case EveryYear:
-		yearPeriod := t.Year()
-		yearStart := m.FirstOccurence.Year()
-		yearDistance := yearPeriod - yearStart
-		if yearDistance > 0 {
-			yearDistance--
-		}
-		meetingEndTime := m.FirstOccurence.AddDate(yearDistance, 0, 0).Add(m.Duration.Duration)
-		if !meetingEndTime.After(t) {
-			yearDistance++
-		}
-		meetingStartTime := m.FirstOccurence.AddDate(yearDistance, 0, 0)
-		// skip year if no such day in the month (Feb 29 and not a leap year) or meeting ends before the search interval begins
-		for (meetingStartTime.Month() != m.FirstOccurence.Month() || meetingStartTime.Day() != m.FirstOccurence.Day()) ||
-			!meetingStartTime.Add(m.Duration.Duration).After(t) {
-			yearDistance++
-			meetingStartTime = m.FirstOccurence.AddDate(yearDistance, 0, 0)
-		}
-		return meetingStartTime
-	default:
-		return time.Time{}
+// reports whether the scheduled occurrence is cancelled or moved
+func (m Meeting) isException(start time.Time) bool {
+	for _, t := range m.ExDates {
+		if t.Equal(start) {
+			return true
+		}
+	}
+	for _, o := range m.Overrides {
+		if o.RecurrenceId.Equal(start) {
+			return true
+		}
+	}
+	return false
+}
+
+// reports whether the meeting rule schedules an occurrence at the given time
+func (m Meeting) isScheduledAt(start time.Time) bool {
+	next := m.scheduledOccurrences(start.Add(-time.Nanosecond))
+	for t := next(); !t.IsZero() && !t.After(start); t = next() {
+		if t.Equal(start) {
+			return true
+		}
+	}
+	return false
+}
+
+// returns the rule the meeting repeats by or nil if it takes place once
+func (m Meeting) recurrence() *Recurrence {
+	if m.Repeat == Custom {
+		return m.Rule
+	}
+	return m.Repeat.recurrence()
+}
+
+// returns the first occurrence time and the rule the meeting repeats by
+func (m Meeting) scheduleString() string {
+	s := m.FirstOccurence.Format(time.RFC3339)
+	if rule := m.recurrence(); rule != nil {
+		s += " " + rule.String() + " " + m.TimeZone
+	}
+	return s
+}
+
+// reports whether any occurrence of the meeting overlaps the period [start, end)
+func (m Meeting) takesPlaceBetween(start, end time.Time) bool {
+	meetingStartTime := m.meetingStartTimeAfter(start)
+	return !meetingStartTime.IsZero() && meetingStartTime.Before(end)
+}
+
+// parses the recurrence rule parameter. The rule is required for Custom period
+// and not allowed for the others. Returns nil if there is no rule.
+func parseRule(form url.Values, repeat Period) (*Recurrence, error) {
+	v, ok := form[ruleTag]
+	if !ok {
+		if repeat == Custom {
+			return nil, fmt.Errorf("%q is required for %v period: %w", ruleTag, repeat, ErrParse)
+		}
+		return nil, nil
+	}
+	if _, ok := form[periodTag]; ok && repeat != Custom {
+		return nil, fmt.Errorf("%q can't be used with %v period: %w", ruleTag, repeat, ErrParse)
+	}
+	rule, err := ParseRecurrence(v[0])
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (m Meeting) hasMember(id UID) bool {
+	for _, member := range m.Members {
+		if member.UserId == id {
+			return true
+		}
+	}
+	return false
+}
+
+// adds the user to the meeting or changes the role of the member
+func (m *Meeting) setMember(id UID, role Role) {
+	for i := range m.Members {
+		if m.Members[i].UserId == id {
+			m.Members[i].Role = role
+			return
+		}
+	}
+	m.Members = append(m.Members, Participant{UserId: id, Status: Unknown, Role: role})
+}
+
+// returns presence of the member or Unknown if the user is not a member
+func (m Meeting) memberStatus(id UID) Presence {
+	for _, member := range m.Members {
+		if member.UserId == id {
+			return member.Status
+		}
+	}
+	return Unknown
+}
+
+// parses list of ids given as several values and/or separated with a comma
+func parseIdList(values []string) ([]UID, error) {
+	ids := make([]UID, 0, len(values))
+	for _, value := range values {
+		for _, num := range strings.Split(value, ",") {
+			id, err := strconv.ParseUint(num, 10, 32)
+			if err != nil {
+				return nil, err
+			}
+			ids = append(ids, UID(id))
+		}
 	}
+	return ids, nil
 }
 
 type parameterOptions uint
@@ -646,42 +1627,38 @@ const (
 
 type parameters map[string]parameterOptions
 
-func checkArgs(args *url.Values, params parameters) bool {
+// returns ParamError for the first missing, unknown or repeated parameter
+func checkArgs(args *url.Values, params parameters) error {
 	for k, opt := range params {
 		val, ok := (*args)[k]
 		if !ok {
 			if opt&parameterRequired == 0 {
 				continue
 			} else {
-				log.Printf("error: check parameters: required %q not found\n", k)
-				return false
+				return &ParamError{Param: k, Err: fmt.Errorf("required parameter not found: %w", ErrParse)}
 			}
 		}
 		switch len(val) {
 		case 0:
 			if opt&emptyValue == 0 {
-				log.Printf("error: check parameters: %q: value required\n", k)
-				return false
+				return &ParamError{Param: k, Err: fmt.Errorf("value required: %w", ErrParse)}
 			}
 		case 1:
 			if opt&(singleValue|multipleValue) == 0 {
-				log.Printf("error: check parameters: %q: no value required (%q provided)\n", k, val[0])
-				return false
+				return &ParamError{Param: k, Err: fmt.Errorf("no value required (%q provided): %w", val[0], ErrParse)}
 			}
 		default:
 			if opt&multipleValue == 0 {
-				log.Printf("error: check parameters: %q: no multiple value allowed (%v provided)\n", k, val)
-				return false
+				return &ParamError{Param: k, Err: fmt.Errorf("no multiple value allowed (%v provided): %w", val, ErrParse)}
 			}
 		}
 	}
 
 	for k := range *args {
-		if k, ok := params[k]; !ok {
-			log.Printf("error: check parameters: unknown parameter: %q\n", k)
-			return false
+		if _, ok := params[k]; !ok {
+			return &ParamError{Param: k, Err: fmt.Errorf("unknown parameter: %w", ErrParse)}
 		}
 	}
 
-	return true
+	return nil
 }
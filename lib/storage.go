@@ -1,151 +1,722 @@
 package lib
 
 import (
+	"fmt"
+	"sort"
 	"sync"
 	"time"
 )
 
+// Storage is a backend keeping users and meetings.
+// Implementations must be safe for concurrent use.
+type Storage interface {
+	// returns list of users registered in the system
+	UserList() ([]User, error)
+	// looks up the user by given Id.
+	// Possible errors:
+	//
+	//	ErrNotExist User with given Id is not found.
+	UserFindById(id UID) (User, error)
+	// creates user and returns theirs id
+	UserAdd(u UserInfo) (UID, error)
+	// replaces information of the user with the same Id.
+	// Possible errors:
+	//
+	//	ErrNotExist User with given Id is not found.
+	UserUpdate(u User) error
+	// removes the user. Meetings the user takes part in are not changed.
+	// Possible errors:
+	//
+	//	ErrNotExist User with given Id is not found.
+	UserDelete(id UID) error
+	// removes the user from all meetings and then deactivates or deletes the user.
+	// Meetings created by the user are cancelled if cancelCreated is set,
+	// otherwise they are passed to reassignTo or to the first remaining member.
+	// Meetings left without members are cancelled.
+	// Possible errors:
+	//
+	//	ErrNotExist User with given Id is not found.
+	UserRemove(id UID, deactivate, cancelCreated bool, reassignTo UID) error
+	// replaces the access token of the user with the one of given hash.
+	// Empty hash revokes the token.
+	// Possible errors:
+	//
+	//	ErrNotExist User with given Id is not found.
+	UserSetToken(id UID, hash string) error
+	// looks up the user the access token of given hash is issued to.
+	// Possible errors:
+	//
+	//	ErrNotExist Token is not issued or revoked.
+	UserFindByToken(hash string) (User, error)
+
+	// returns list of scheduled meetings
+	MeetingList() ([]Meeting, error)
+	// looks up the meeting by given Id.
+	// Possible errors:
+	//
+	//	ErrNotExist Meeting with given Id is not found.
+	MeetingFindById(id MeetingId) (Meeting, error)
+	// creates meeting and returns its id.
+	// Possible errors:
+	//
+	//	ErrNotExist Resource booked by the meeting is not found.
+	//	ErrConflict Resource is booked by another meeting at the same time.
+	MeetingAdd(m MeetingInfo) (MeetingId, error)
+	// replaces stored meeting with the same Id.
+	// Possible errors:
+	//
+	//	ErrNotExist Meeting with given Id or resource booked by it is not found.
+	//	ErrConflict Resource is booked by another meeting at the same time.
+	MeetingUpdate(m Meeting) error
+	// removes the meeting on behalf of the user and leaves
+	// the cancellation record to every member.
+	// Possible errors:
+	//
+	//	ErrNotExist Meeting with given Id is not found.
+	MeetingDelete(id MeetingId, by UID) error
+
+	// returns all meetings the user is a member of.
+	// Possible errors:
+	//
+	//	ErrNotExist User with given Id is not found.
+	UserMeetings(id UID) ([]Meeting, error)
+	// returns meetings of the user taking place in the period [start, end).
+	// Possible errors:
+	//
+	//	ErrNotExist User with given Id is not found.
+	UserMeetingsBetween(id UID, start, end time.Time) ([]Meeting, error)
+	// returns cancellations of the meetings the user was a member of.
+	// Possible errors:
+	//
+	//	ErrNotExist User with given Id is not found.
+	UserCancellations(id UID) ([]Cancellation, error)
+
+	// returns list of the rooms and the equipment
+	ResourceList() ([]Resource, error)
+	// looks up the resource by given Id.
+	// Possible errors:
+	//
+	//	ErrNotExist Resource with given Id is not found.
+	ResourceFindById(id ResourceId) (Resource, error)
+	// creates resource and returns its id
+	ResourceAdd(r ResourceInfo) (ResourceId, error)
+	// replaces information of the resource with the same Id.
+	// Possible errors:
+	//
+	//	ErrNotExist Resource with given Id is not found.
+	ResourceUpdate(r Resource) error
+	// removes the resource.
+	// Possible errors:
+	//
+	//	ErrNotExist Resource with given Id is not found.
+	//	ErrConflict Resource is booked by a meeting.
+	ResourceDelete(id ResourceId) error
+	// returns meetings booking the resource taking place in the period [start, end).
+	// Possible errors:
+	//
+	//	ErrNotExist Resource with given Id is not found.
+	ResourceMeetingsBetween(id ResourceId, start, end time.Time) ([]Meeting, error)
+
+	// removes all users, resources and meetings
+	Reset() error
+}
+
 type userStorage struct {
 	sync.Mutex
-	m     map[UID]User
-	maxId UID
+	m             map[UID]User
+	maxId         UID
+	cancellations map[UID][]Cancellation
+	// users by the hashes of their access tokens
+	tokens map[string]UID
+}
+
+type resourceStorage struct {
+	sync.Mutex
+	m     map[ResourceId]Resource
+	maxId ResourceId
+}
+
+type meetingStorage struct {
+	sync.Mutex
+	m     map[MeetingId]Meeting
+	maxId MeetingId
+}
+
+// memoryStorage keeps everything in process memory.
+// The locks are always taken in order: users, resources, meetings.
+type memoryStorage struct {
+	users     userStorage
+	resources resourceStorage
+	meetings  meetingStorage
 }
 
-var users = userStorage{
-	m: make(map[UID]User),
+// NewMemoryStorage returns empty storage keeping the data in memory only.
+func NewMemoryStorage() Storage {
+	return newMemoryStorage()
 }
 
-// returns list of users registered in the system
-func userList() ([]User, error) {
-	users.Lock()
-	defer users.Unlock()
-	values := make([]User, 0, len(users.m))
-	for _, usr := range users.m {
+func newMemoryStorage() *memoryStorage {
+	return &memoryStorage{
+		users:     userStorage{m: make(map[UID]User), cancellations: make(map[UID][]Cancellation), tokens: make(map[string]UID)},
+		resources: resourceStorage{m: make(map[ResourceId]Resource)},
+		meetings:  meetingStorage{m: make(map[MeetingId]Meeting)},
+	}
+}
+
+func (s *memoryStorage) UserList() ([]User, error) {
+	s.users.Lock()
+	defer s.users.Unlock()
+	values := make([]User, 0, len(s.users.m))
+	for _, usr := range s.users.m {
 		values = append(values, usr)
 	}
 	return values, nil
 }
 
-// looks up the user by given Id.
-// Possible errors:
-//
-//	ErrNotExist User with given Id is not found.
-func userFindById(id UID) (User, error) {
-	users.Lock()
-	defer users.Unlock()
+func (s *memoryStorage) UserFindById(id UID) (User, error) {
+	s.users.Lock()
+	defer s.users.Unlock()
 	var e error
-	usr, ok := users.m[id]
+	usr, ok := s.users.m[id]
 	if !ok {
 		e = ErrNotExist
 	}
 	return usr, e
 }
 
-// creates user and returns theirs id.
 // This implementation does not return errors
-func userAdd(u UserInfo) (UID, error) {
-	users.Lock()
-	defer users.Unlock()
-	users.maxId++
-	id := users.maxId
-	users.m[id] = User{id, u, map[MeetingId]bool{}}
+func (s *memoryStorage) UserAdd(u UserInfo) (UID, error) {
+	s.users.Lock()
+	defer s.users.Unlock()
+	s.users.maxId++
+	id := s.users.maxId
+	s.users.m[id] = User{id, u, newMeetingIndex()}
 	return id, nil
 }
 
-func createUser(name string) (UID, error) {
-	return userAdd(UserInfo{name})
+func (s *memoryStorage) UserUpdate(u User) error {
+	s.users.Lock()
+	defer s.users.Unlock()
+	old, ok := s.users.m[u.Id]
+	if !ok {
+		return ErrNotExist
+	}
+	old.UserInfo = u.UserInfo
+	s.users.m[u.Id] = old
+	return nil
 }
 
-type meetingStorage struct {
-	sync.Mutex
-	m     map[MeetingId]Meeting
-	maxId MeetingId
+func (s *memoryStorage) UserDelete(id UID) error {
+	s.users.Lock()
+	defer s.users.Unlock()
+	if _, ok := s.users.m[id]; !ok {
+		return ErrNotExist
+	}
+	s.users.remove(id)
+	return nil
+}
+
+func (s *memoryStorage) UserRemove(id UID, deactivate, cancelCreated bool, reassignTo UID) error {
+	return s.removeUser(userRemoval{UserId: id, Deactivate: deactivate, CancelCreated: cancelCreated, ReassignTo: reassignTo, At: time.Now().UTC()})
+}
+
+// userRemoval describes the removal of the user together with the changes of their meetings
+type userRemoval struct {
+	UserId        UID
+	Deactivate    bool
+	CancelCreated bool
+	ReassignTo    UID
+	At            time.Time
+}
+
+func (s *memoryStorage) removeUser(rm userRemoval) error {
+	s.users.Lock()
+	defer s.users.Unlock()
+	s.resources.Lock()
+	defer s.resources.Unlock()
+	s.meetings.Lock()
+	defer s.meetings.Unlock()
+	usr, ok := s.users.m[rm.UserId]
+	if !ok {
+		return ErrNotExist
+	}
+	// the meetings are changed in order of their ids to restore the same state from the journal
+	ids := make([]MeetingId, 0)
+	for _, m := range s.meetings.m {
+		if m.CreatorId == rm.UserId || m.hasMember(rm.UserId) {
+			ids = append(ids, m.Id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, meetId := range ids {
+		old := s.meetings.m[meetId]
+		isCreator := old.CreatorId == rm.UserId
+		m := old.clone()
+		for i := range m.Members {
+			if m.Members[i].UserId == rm.UserId {
+				m.Members = append(m.Members[:i], m.Members[i+1:]...)
+				break
+			}
+		}
+		if len(m.Members) == 0 || isCreator && rm.CancelCreated {
+			s.dropMeeting(old, rm.UserId, rm.At)
+			continue
+		}
+		if isCreator {
+			m.CreatorId = rm.ReassignTo
+			if rm.ReassignTo == 0 {
+				m.CreatorId = m.Members[0].UserId
+			}
+		}
+		m.UpdatedAt = rm.At
+		s.storeMeeting(m)
+	}
+
+	if rm.Deactivate {
+		usr.Deactivated = true
+		s.users.m[rm.UserId] = usr
+	} else {
+		s.users.remove(rm.UserId)
+	}
+	return nil
+}
+
+// removes the user with their cancellations and access token
+func (s *userStorage) remove(id UID) {
+	delete(s.m, id)
+	delete(s.cancellations, id)
+	s.revokeToken(id)
+}
+
+func (s *memoryStorage) UserSetToken(id UID, hash string) error {
+	s.users.Lock()
+	defer s.users.Unlock()
+	if _, ok := s.users.m[id]; !ok {
+		return ErrNotExist
+	}
+	s.users.revokeToken(id)
+	if hash != "" {
+		s.users.tokens[hash] = id
+	}
+	return nil
 }
 
-var meetings = meetingStorage{
-	m: make(map[MeetingId]Meeting),
+func (s *memoryStorage) UserFindByToken(hash string) (User, error) {
+	s.users.Lock()
+	defer s.users.Unlock()
+	id, ok := s.users.tokens[hash]
+	if !ok {
+		return User{}, ErrNotExist
+	}
+	return s.users.m[id], nil
 }
 
-// returns list of scheduled meetings
-func meetingList() ([]Meeting, error) {
-	meetings.Lock()
-	defer meetings.Unlock()
-	values := make([]Meeting, 0, len(meetings.m))
-	for _, m := range meetings.m {
-		values = append(values, m)
+func (s *userStorage) revokeToken(id UID) {
+	for hash, uid := range s.tokens {
+		if uid == id {
+			delete(s.tokens, hash)
+		}
+	}
+}
+
+func (s *memoryStorage) ResourceList() ([]Resource, error) {
+	s.resources.Lock()
+	defer s.resources.Unlock()
+	values := make([]Resource, 0, len(s.resources.m))
+	for _, res := range s.resources.m {
+		values = append(values, res.clone())
 	}
 	return values, nil
 }
 
-// looks up the meeting by given Id.
-// Possible errors:
-//
-//	ErrNotExist Meeting with given Id is not found.
-func meetingFindById(id MeetingId) (Meeting, error) {
-	meetings.Lock()
-	defer meetings.Unlock()
+func (s *memoryStorage) ResourceFindById(id ResourceId) (Resource, error) {
+	s.resources.Lock()
+	defer s.resources.Unlock()
 	var e error
-	m, ok := meetings.m[id]
+	res, ok := s.resources.m[id]
 	if !ok {
 		e = ErrNotExist
 	}
-	return m, e
+	return res.clone(), e
+}
+
+// This implementation does not return errors
+func (s *memoryStorage) ResourceAdd(r ResourceInfo) (ResourceId, error) {
+	s.resources.Lock()
+	defer s.resources.Unlock()
+	s.resources.maxId++
+	id := s.resources.maxId
+	s.resources.m[id] = Resource{id, r, newMeetingIndex()}.clone()
+	return id, nil
+}
+
+func (s *memoryStorage) ResourceUpdate(r Resource) error {
+	s.resources.Lock()
+	defer s.resources.Unlock()
+	old, ok := s.resources.m[r.Id]
+	if !ok {
+		return ErrNotExist
+	}
+	old.ResourceInfo = r.clone().ResourceInfo
+	s.resources.m[r.Id] = old
+	return nil
+}
+
+func (s *memoryStorage) ResourceDelete(id ResourceId) error {
+	s.resources.Lock()
+	defer s.resources.Unlock()
+	res, ok := s.resources.m[id]
+	if !ok {
+		return ErrNotExist
+	}
+	if ids := res.meetings.ids(); len(ids) != 0 {
+		return fmt.Errorf("resource %d is booked by meeting %d: %w", id, ids[0], ErrConflict)
+	}
+	delete(s.resources.m, id)
+	return nil
 }
 
-func meetingAdd(m MeetingInfo) (MeetingId, error) {
-	users.Lock()
-	defer users.Unlock()
-	meetings.Lock()
-	defer meetings.Unlock()
-	meetings.maxId++
-	id := meetings.maxId
-	meetings.m[id] = Meeting{id, m}
+func (s *memoryStorage) ResourceMeetingsBetween(id ResourceId, start, end time.Time) ([]Meeting, error) {
+	s.resources.Lock()
+	defer s.resources.Unlock()
+	s.meetings.Lock()
+	defer s.meetings.Unlock()
+	res, ok := s.resources.m[id]
+	if !ok {
+		return nil, ErrNotExist
+	}
+	return s.indexedBetween(res.meetings, start, end), nil
+}
+
+func (s *memoryStorage) MeetingList() ([]Meeting, error) {
+	s.meetings.Lock()
+	defer s.meetings.Unlock()
+	values := make([]Meeting, 0, len(s.meetings.m))
+	for _, m := range s.meetings.m {
+		values = append(values, m.clone())
+	}
+	return values, nil
+}
+
+func (s *memoryStorage) MeetingFindById(id MeetingId) (Meeting, error) {
+	s.meetings.Lock()
+	defer s.meetings.Unlock()
+	var e error
+	m, ok := s.meetings.m[id]
+	if !ok {
+		e = ErrNotExist
+	}
+	return m.clone(), e
+}
+
+func (s *memoryStorage) MeetingAdd(m MeetingInfo) (MeetingId, error) {
+	s.users.Lock()
+	defer s.users.Unlock()
+	s.resources.Lock()
+	defer s.resources.Unlock()
+	s.meetings.Lock()
+	defer s.meetings.Unlock()
+	meeting := Meeting{Id: s.meetings.maxId + 1, MeetingInfo: m, UpdatedAt: time.Now().UTC()}
+	if err := s.checkBooking(meeting); err != nil {
+		return 0, err
+	}
+	s.meetings.maxId++
+	s.storeMeeting(meeting)
+	return meeting.Id, nil
+}
+
+func (s *memoryStorage) MeetingUpdate(m Meeting) error {
+	s.users.Lock()
+	defer s.users.Unlock()
+	s.resources.Lock()
+	defer s.resources.Unlock()
+	s.meetings.Lock()
+	defer s.meetings.Unlock()
+	if _, ok := s.meetings.m[m.Id]; !ok {
+		return ErrNotExist
+	}
+	if err := s.checkBooking(m); err != nil {
+		return err
+	}
+	m.UpdatedAt = time.Now().UTC()
+	s.storeMeeting(m)
+	return nil
+}
+
+// saves the meeting and updates meeting sets of the members and
+// the resources added or removed. All the locks must be held.
+func (s *memoryStorage) storeMeeting(m Meeting) {
+	if old, ok := s.meetings.m[m.Id]; ok {
+		s.unindexMeeting(old)
+	}
+	s.meetings.m[m.Id] = m.clone()
 	for _, member := range m.Members {
-		if _, ok := users.m[member.UserId]; ok {
-			users.m[member.UserId].meetings[id] = true
+		if usr, ok := s.users.m[member.UserId]; ok {
+			usr.meetings.add(m)
+		}
+	}
+	for _, id := range m.Resources {
+		if res, ok := s.resources.m[id]; ok {
+			res.meetings.add(m)
 		}
 	}
-	return id, nil
 }
 
-func meetingUpdate(m Meeting) error {
-	meetings.Lock()
-	defer meetings.Unlock()
-	if _, ok := meetings.m[m.Id]; !ok {
-		return ErrNotExist
+// removes the meeting from meeting sets of its members and resources.
+// All the locks must be held.
+func (s *memoryStorage) unindexMeeting(m Meeting) {
+	for _, member := range m.Members {
+		if usr, ok := s.users.m[member.UserId]; ok {
+			usr.meetings.remove(m.Id)
+		}
+	}
+	for _, id := range m.Resources {
+		if res, ok := s.resources.m[id]; ok {
+			res.meetings.remove(m.Id)
+		}
+	}
+}
+
+// returns ErrConflict if any resource of the meeting is booked by another
+// meeting at the same time. The meetings are compared within their bookingPeriod.
+// Resources and meetings locks must be held.
+func (s *memoryStorage) checkBooking(m Meeting) error {
+	if len(m.Resources) == 0 {
+		return nil
+	}
+	now := time.Now().UTC()
+	from, to := m.span()
+	for _, id := range m.Resources {
+		res, ok := s.resources.m[id]
+		if !ok {
+			return fmt.Errorf("resource %d: %w", id, ErrNotExist)
+		}
+		for _, other := range s.indexedBetween(res.meetings, from, to) {
+			if other.Id == m.Id {
+				continue
+			}
+			start, end := bookingPeriod(now, m, other)
+			var at time.Time
+			overlapping(m.occurrencesBetween(start, end), other.occurrencesBetween(start, end), func(o, _ occurrence) {
+				if at.IsZero() {
+					at = o.start
+				}
+			})
+			if !at.IsZero() {
+				return fmt.Errorf("resource %d is booked by meeting %d at %v: %w", id, other.Id, at, ErrConflict)
+			}
+		}
 	}
-	meetings.m[m.Id] = m
-	// TODO: remove meetings from the User.meetings map if the Meeting.Members has been changed
 	return nil
 }
 
-func getUserMeetings(id UID) ([]Meeting, error) {
-	users.Lock()
-	defer users.Unlock()
-	meetings.Lock()
-	defer meetings.Unlock()
-	user, ok := users.m[id]
+func (s *memoryStorage) MeetingDelete(id MeetingId, by UID) error {
+	_, err := s.cancelMeeting(id, by, time.Now().UTC())
+	return err
+}
+
+func (s *memoryStorage) cancelMeeting(id MeetingId, by UID, at time.Time) (Cancellation, error) {
+	s.users.Lock()
+	defer s.users.Unlock()
+	s.resources.Lock()
+	defer s.resources.Unlock()
+	s.meetings.Lock()
+	defer s.meetings.Unlock()
+	m, ok := s.meetings.m[id]
+	if !ok {
+		return Cancellation{}, ErrNotExist
+	}
+	return s.dropMeeting(m, by, at), nil
+}
+
+// removes the meeting and leaves the cancellation record to every member.
+// All the locks must be held.
+func (s *memoryStorage) dropMeeting(m Meeting, by UID, at time.Time) Cancellation {
+	delete(s.meetings.m, m.Id)
+	s.unindexMeeting(m)
+	c := Cancellation{Meeting: m, CancelledBy: by, CancelledAt: at}
+	for _, member := range m.Members {
+		if _, ok := s.users.m[member.UserId]; ok {
+			s.users.cancellations[member.UserId] = append(s.users.cancellations[member.UserId], c)
+		}
+	}
+	return c
+}
+
+func (s *memoryStorage) UserMeetings(id UID) ([]Meeting, error) {
+	s.users.Lock()
+	defer s.users.Unlock()
+	s.meetings.Lock()
+	defer s.meetings.Unlock()
+	user, ok := s.users.m[id]
 	if !ok {
 		return nil, ErrNotExist
 	}
-	meetIds := user.meetings
+	meetIds := user.meetings.ids()
 	meets := make([]Meeting, 0, len(meetIds))
-	for meetId := range meetIds {
-		meets = append(meets, meetings.m[meetId])
+	for _, meetId := range meetIds {
+		meets = append(meets, s.meetings.m[meetId].clone())
 	}
 	return meets, nil
 }
 
-func createMeeting(creator UID, members []Participant, startAt time.Time, duration Duration, repeat Period) (MeetingId, error) {
-	return meetingAdd(MeetingInfo{CreatorId: creator, Members: members, FirstOccurence: startAt, Duration: duration, Repeat: repeat})
+func (s *memoryStorage) UserMeetingsBetween(id UID, start, end time.Time) ([]Meeting, error) {
+	s.users.Lock()
+	defer s.users.Unlock()
+	s.meetings.Lock()
+	defer s.meetings.Unlock()
+	user, ok := s.users.m[id]
+	if !ok {
+		return nil, ErrNotExist
+	}
+	return s.indexedBetween(user.meetings, start, end), nil
+}
+
+// returns meetings of the index taking place in the period [start, end).
+// Meetings lock must be held.
+func (s *memoryStorage) indexedBetween(x *meetingIndex, start, end time.Time) []Meeting {
+	meets := make([]Meeting, 0)
+	x.between(start, end,
+		func(id MeetingId) {
+			meets = append(meets, s.meetings.m[id].clone())
+		},
+		func(id MeetingId) {
+			m := s.meetings.m[id]
+			if m.takesPlaceBetween(start, end) {
+				meets = append(meets, m.clone())
+			}
+		})
+	return meets
+}
+
+func (s *memoryStorage) UserCancellations(id UID) ([]Cancellation, error) {
+	s.users.Lock()
+	defer s.users.Unlock()
+	if _, ok := s.users.m[id]; !ok {
+		return nil, ErrNotExist
+	}
+	cancellations := make([]Cancellation, 0, len(s.users.cancellations[id]))
+	for _, c := range s.users.cancellations[id] {
+		cancellations = append(cancellations, Cancellation{c.Meeting.clone(), c.CancelledBy, c.CancelledAt})
+	}
+	return cancellations, nil
 }
 
-func ResetStorage() error {
-	users.Lock()
-	defer users.Unlock()
-	meetings.Lock()
-	defer meetings.Unlock()
-	users.m = make(map[UID]User)
-	meetings.m = make(map[MeetingId]Meeting)
+func (s *memoryStorage) Reset() error {
+	s.users.Lock()
+	defer s.users.Unlock()
+	s.resources.Lock()
+	defer s.resources.Unlock()
+	s.meetings.Lock()
+	defer s.meetings.Unlock()
+	s.users.m = make(map[UID]User)
+	s.users.cancellations = make(map[UID][]Cancellation)
+	s.users.tokens = make(map[string]UID)
+	s.resources.m = make(map[ResourceId]Resource)
+	s.meetings.m = make(map[MeetingId]Meeting)
 	return nil
 }
+
+// inserts or replaces the user keeping its id.
+// It is used to restore the persisted state.
+func (s *memoryStorage) putUser(u User) {
+	s.users.Lock()
+	defer s.users.Unlock()
+	if old, ok := s.users.m[u.Id]; ok {
+		u.meetings = old.meetings
+	} else {
+		u.meetings = newMeetingIndex()
+	}
+	s.users.m[u.Id] = u
+	if s.users.maxId < u.Id {
+		s.users.maxId = u.Id
+	}
+	// meetings may be restored before their members
+	s.meetings.Lock()
+	defer s.meetings.Unlock()
+	for _, m := range s.meetings.m {
+		if m.hasMember(u.Id) {
+			u.meetings.add(m)
+		}
+	}
+}
+
+// inserts or replaces the resource keeping its id.
+// It is used to restore the persisted state.
+func (s *memoryStorage) putResource(r Resource) {
+	s.resources.Lock()
+	defer s.resources.Unlock()
+	if old, ok := s.resources.m[r.Id]; ok {
+		r.meetings = old.meetings
+	} else {
+		r.meetings = newMeetingIndex()
+	}
+	s.resources.m[r.Id] = r
+	if s.resources.maxId < r.Id {
+		s.resources.maxId = r.Id
+	}
+	// meetings may be restored before their resources
+	s.meetings.Lock()
+	defer s.meetings.Unlock()
+	for _, m := range s.meetings.m {
+		if m.hasResource(r.Id) {
+			r.meetings.add(m)
+		}
+	}
+}
+
+// inserts or replaces the meeting keeping its id.
+// It is used to restore the persisted state.
+func (s *memoryStorage) putMeeting(m Meeting) {
+	s.users.Lock()
+	defer s.users.Unlock()
+	s.resources.Lock()
+	defer s.resources.Unlock()
+	s.meetings.Lock()
+	defer s.meetings.Unlock()
+	s.storeMeeting(m)
+	if s.meetings.maxId < m.Id {
+		s.meetings.maxId = m.Id
+	}
+}
+
+// returns a copy of the meeting not sharing the member list with the original
+func (m Meeting) clone() Meeting {
+	if m.Members != nil {
+		m.Members = append([]Participant(nil), m.Members...)
+	}
+	if m.ExDates != nil {
+		m.ExDates = append([]time.Time(nil), m.ExDates...)
+	}
+	if m.Overrides != nil {
+		m.Overrides = append([]Override(nil), m.Overrides...)
+	}
+	if m.Resources != nil {
+		m.Resources = append([]ResourceId(nil), m.Resources...)
+	}
+	return m
+}
+
+// returns a copy of the resource not sharing the attribute list with the original
+func (r Resource) clone() Resource {
+	if r.Attributes != nil {
+		r.Attributes = append([]string(nil), r.Attributes...)
+	}
+	return r
+}
+
+func (s *Service) createUser(name, email string) (UID, error) {
+	return s.storage.UserAdd(UserInfo{Name: name, Email: email})
+}
+
+// looks up the user who may take part in meetings.
+// Possible errors:
+//
+//	ErrNotExist User with given Id is not found or deactivated.
+func (s *Service) findActiveUser(id UID) (User, error) {
+	usr, err := s.storage.UserFindById(id)
+	if err == nil && usr.Deactivated {
+		err = fmt.Errorf("user %d is deactivated: %w", id, ErrNotExist)
+	}
+	return usr, err
+}
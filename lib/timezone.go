@@ -0,0 +1,39 @@
+package lib
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	// the service must not depend on the zone database of the host
+	_ "time/tzdata"
+)
+
+// loaded locations by IANA name, loading reads the zone database every time
+var locations sync.Map
+
+// returns location by IANA time zone name, UTC for the empty name
+func loadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("time zone %q: %v: %w", name, err, ErrParse)
+	}
+	locations.Store(name, loc)
+	return loc, nil
+}
+
+// returns location the meeting recurrence is expanded in
+func (m Meeting) location() *time.Location {
+	loc, err := loadLocation(m.TimeZone)
+	if err != nil {
+		// time zones are checked when the meeting is saved
+		return time.UTC
+	}
+	return loc
+}
@@ -10,13 +10,18 @@ import (
 type UID uint32
 
 type UserInfo struct {
-	Name string
+	Name  string
+	Email string
+	// deactivated users are kept for the history but can't take part in meetings
+	Deactivated bool
+	// the user is available any time if there are no working hours
+	WorkingHours *WorkingHours `json:",omitempty"`
 }
 
 type User struct {
 	Id UID `json:"UserId"`
 	UserInfo
-	meetings map[MeetingId]bool
+	meetings *meetingIndex
 }
 
 type Presence int
@@ -25,11 +30,49 @@ const (
 	Unknown Presence = iota
 	Accepted
 	Rejected
+	// the member may come, the time is kept but not blocked
+	Tentative
+)
+
+// Role tells whether the meeting needs the participant
+type Role int
+
+const (
+	Required Role = iota
+	Optional
 )
 
+var roleToNames = map[Role]string{
+	Required: "Required",
+	Optional: "Optional",
+}
+
+func (r Role) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(r.String())), nil
+}
+
+func (r *Role) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	for role, name := range roleToNames {
+		if name == s {
+			*r = role
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown value: %s: %w", s, ErrParse)
+}
+
+func (r Role) String() string {
+	return roleToNames[r]
+}
+
 type Participant struct {
 	UserId UID
 	Status Presence
+	Role   Role
 }
 
 var presenceToNames map[Presence]string
@@ -37,14 +80,16 @@ var namesToPresence map[string]Presence
 
 func init() {
 	presenceToNames = map[Presence]string{
-		Unknown:  "Unknown",
-		Accepted: "Accepted",
-		Rejected: "Rejected",
+		Unknown:   "Unknown",
+		Accepted:  "Accepted",
+		Rejected:  "Rejected",
+		Tentative: "Tentative",
 	}
 	namesToPresence = map[string]Presence{
-		"Unknown":  Unknown,
-		"Accepted": Accepted,
-		"Rejected": Rejected,
+		"Unknown":   Unknown,
+		"Accepted":  Accepted,
+		"Rejected":  Rejected,
+		"Tentative": Tentative,
 	}
 }
 
@@ -87,6 +132,8 @@ const (
 	EveryWeek
 	EveryMonth
 	EveryYear
+	// the meeting repeats according to MeetingInfo.Rule
+	Custom
 )
 
 var periodToNames map[Period]string
@@ -99,6 +146,7 @@ func init() {
 		EveryWeek:  "EveryWeek",
 		EveryMonth: "EveryMonth",
 		EveryYear:  "EveryYear",
+		Custom:     "Custom",
 	}
 	namesToPeriod = map[string]Period{
 		"Once":       Once,
@@ -106,6 +154,7 @@ func init() {
 		"EveryWeek":  EveryWeek,
 		"EveryMonth": EveryMonth,
 		"EveryYear":  EveryYear,
+		"Custom":     Custom,
 	}
 }
 
@@ -138,6 +187,24 @@ func ParsePeriod(s string) (Period, error) {
 
 type MeetingId uint32
 
+type ResourceId uint32
+
+type ResourceInfo struct {
+	Name string
+	// number of people the resource holds, 0 if it is not limited
+	Capacity int
+	// features of the resource, e.g. "room" or "projector"
+	Attributes []string `json:",omitempty"`
+}
+
+// Resource is a room or a piece of equipment booked for meetings.
+// A resource can't be booked by several meetings at the same time.
+type Resource struct {
+	Id ResourceId `json:"ResourceId"`
+	ResourceInfo
+	meetings *meetingIndex
+}
+
 type Duration struct {
 	time.Duration
 }
@@ -162,9 +229,70 @@ type MeetingInfo struct {
 	FirstOccurence time.Time
 	Duration       Duration
 	Repeat         Period
+	// recurrence rule of the Custom period
+	Rule *Recurrence `json:",omitempty" swaggertype:"string" example:"FREQ=WEEKLY;BYDAY=TU,TH"`
+	// IANA time zone the recurrence is expanded in keeping the local time, UTC if empty
+	TimeZone string `json:",omitempty" example:"Europe/Berlin"`
+	// original start times of the cancelled occurrences (EXDATE)
+	ExDates []time.Time `json:",omitempty"`
+	// occurrences taking place at another time (RECURRENCE-ID)
+	Overrides []Override `json:",omitempty"`
+	// rooms and equipment booked for the meeting
+	Resources []ResourceId `json:",omitempty"`
+}
+
+// Override moves a single occurrence of the recurring meeting.
+// The occurrence is identified by its original start time.
+type Override struct {
+	RecurrenceId time.Time
+	StartAt      time.Time
+	Duration     Duration
 }
 
 type Meeting struct {
 	Id MeetingId `json:"MeetingId"`
 	MeetingInfo
+	// time the meeting or a response to it was last saved
+	UpdatedAt time.Time
+}
+
+// Cancellation keeps the meeting removed by its creator,
+// so the members can find out what has happened to it.
+type Cancellation struct {
+	Meeting
+	CancelledBy UID
+	CancelledAt time.Time
+}
+
+// Occurrence is a single instance of the meeting as seen by a member
+type Occurrence struct {
+	MeetingId MeetingId
+	StartAt   time.Time
+	EndAt     time.Time
+	Presence  Presence
+}
+
+// Conflict is an occurrence of the meeting overlapping another meeting of the member
+type Conflict struct {
+	UserId    UID
+	MeetingId MeetingId
+	// start time of the occurrence of the checked meeting
+	Occurrence time.Time
+	// the overlapping occurrence of the other meeting
+	StartAt time.Time
+	EndAt   time.Time
+}
+
+// BusyInterval is a period the user is not available
+type BusyInterval struct {
+	StartAt time.Time
+	EndAt   time.Time
+}
+
+// FreeBusy keeps busy periods of the user without the meeting details
+type FreeBusy struct {
+	UserId UID
+	Busy   []BusyInterval
+	// periods of tentative meetings not covered by the busy ones
+	Tentative []BusyInterval
 }
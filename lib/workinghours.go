@@ -0,0 +1,362 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	weeklyTag    = "weekly"
+	exceptionTag = "exception"
+	dateLayout   = "2006-01-02"
+)
+
+// WorkingHours defines when the user may take part in meetings.
+// Times are given in the user's time zone.
+type WorkingHours struct {
+	TimeZone string        `json:",omitempty" example:"Europe/Berlin"`
+	Weekly   []WeeklyHours `swaggertype:"array,string" example:"MO 09:00-17:00"`
+	// hours of the specific dates replacing the weekly ones
+	Exceptions []DateHours `json:",omitempty" swaggertype:"array,string" example:"2023-12-25"`
+}
+
+// WeeklyHours keeps working periods of the week days, e.g. "MO,TU 09:00-17:00"
+type WeeklyHours struct {
+	Days  []time.Weekday
+	Hours []ClockRange
+}
+
+// DateHours keeps working periods of the date, e.g. "2023-12-24 09:00-12:00".
+// The date without periods is a day off.
+type DateHours struct {
+	Date  time.Time
+	Hours []ClockRange
+}
+
+// ClockRange is a period of the day given as offsets from the midnight
+type ClockRange struct {
+	Start, End time.Duration
+}
+
+func (c ClockRange) String() string {
+	format := func(d time.Duration) string {
+		return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+	}
+	return format(c.Start) + "-" + format(c.End)
+}
+
+func parseClock(s string) (time.Duration, error) {
+	var hour, min int
+	if n, err := fmt.Sscanf(s, "%d:%d", &hour, &min); err != nil || n != 2 || len(s) != 5 {
+		return 0, fmt.Errorf("time %q: %w", s, ErrParse)
+	}
+	if hour < 0 || min < 0 || min > 59 || hour > 24 || hour == 24 && min != 0 {
+		return 0, fmt.Errorf("time %q: %w", s, ErrParse)
+	}
+	return time.Duration(hour)*time.Hour + time.Duration(min)*time.Minute, nil
+}
+
+// parses comma separated list of periods like "09:00-12:00,13:00-17:00"
+func parseClockRanges(s string) ([]ClockRange, error) {
+	ranges := make([]ClockRange, 0)
+	for _, part := range strings.Split(s, ",") {
+		bounds := strings.Split(part, "-")
+		if len(bounds) != 2 {
+			return nil, fmt.Errorf("period %q: %w", part, ErrParse)
+		}
+		start, err := parseClock(bounds[0])
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseClock(bounds[1])
+		if err != nil {
+			return nil, err
+		}
+		if end <= start {
+			return nil, fmt.Errorf("period %q ends before it starts: %w", part, ErrParse)
+		}
+		ranges = append(ranges, ClockRange{start, end})
+	}
+	return ranges, nil
+}
+
+func formatClockRanges(ranges []ClockRange) string {
+	parts := make([]string, 0, len(ranges))
+	for _, r := range ranges {
+		parts = append(parts, r.String())
+	}
+	return strings.Join(parts, ",")
+}
+
+func (h WeeklyHours) String() string {
+	days := make([]string, 0, len(h.Days))
+	for _, day := range h.Days {
+		days = append(days, weekdayToNames[day])
+	}
+	return strings.Join(days, ",") + " " + formatClockRanges(h.Hours)
+}
+
+func ParseWeeklyHours(s string) (WeeklyHours, error) {
+	h := WeeklyHours{}
+	fields := strings.Fields(s)
+	if len(fields) != 2 {
+		return h, fmt.Errorf("weekly hours %q: %w", s, ErrParse)
+	}
+	var err error
+	if h.Days, err = parseWeekdays(fields[0]); err != nil {
+		return h, err
+	}
+	h.Hours, err = parseClockRanges(fields[1])
+	return h, err
+}
+
+// parses comma separated list of week days like "MO,TU"
+func parseWeekdays(s string) ([]time.Weekday, error) {
+	days := make([]time.Weekday, 0)
+	for _, name := range strings.Split(s, ",") {
+		wd, err := parseWeekdayNum(name)
+		if err != nil {
+			return nil, err
+		}
+		if wd.N != 0 {
+			return nil, fmt.Errorf("week day %q: %w", name, ErrParse)
+		}
+		days = append(days, wd.Day)
+	}
+	return days, nil
+}
+
+func (h WeeklyHours) MarshalText() ([]byte, error) {
+	return []byte(h.String()), nil
+}
+
+func (h *WeeklyHours) UnmarshalText(b []byte) error {
+	var err error
+	*h, err = ParseWeeklyHours(string(b))
+	return err
+}
+
+func (h DateHours) String() string {
+	s := h.Date.Format(dateLayout)
+	if len(h.Hours) != 0 {
+		s += " " + formatClockRanges(h.Hours)
+	}
+	return s
+}
+
+func ParseDateHours(s string) (DateHours, error) {
+	h := DateHours{}
+	fields := strings.Fields(s)
+	if len(fields) == 0 || len(fields) > 2 {
+		return h, fmt.Errorf("date hours %q: %w", s, ErrParse)
+	}
+	var err error
+	h.Date, err = time.Parse(dateLayout, fields[0])
+	if err != nil {
+		return h, fmt.Errorf("date %q: %w", fields[0], ErrParse)
+	}
+	if len(fields) == 2 {
+		h.Hours, err = parseClockRanges(fields[1])
+	}
+	return h, err
+}
+
+func (h DateHours) MarshalText() ([]byte, error) {
+	return []byte(h.String()), nil
+}
+
+func (h *DateHours) UnmarshalText(b []byte) error {
+	var err error
+	*h, err = ParseDateHours(string(b))
+	return err
+}
+
+// returns working periods of the date
+func (h *WorkingHours) hoursOf(year int, month time.Month, day int, weekday time.Weekday) []ClockRange {
+	for _, e := range h.Exceptions {
+		if y, m, d := e.Date.Date(); y == year && m == month && d == day {
+			return e.Hours
+		}
+	}
+	ranges := make([]ClockRange, 0)
+	for _, w := range h.Weekly {
+		for _, wd := range w.Days {
+			if wd == weekday {
+				ranges = append(ranges, w.Hours...)
+			}
+		}
+	}
+	return ranges
+}
+
+// returns periods out of working hours overlapping [start, end)
+func (h *WorkingHours) offHours(start, end time.Time) []interval {
+	loc, err := loadLocation(h.TimeZone)
+	if err != nil {
+		loc = time.UTC
+	}
+	working := make([]interval, 0)
+	// the day before covers periods going on after the midnight
+	y, m, d := start.In(loc).AddDate(0, 0, -1).Date()
+	for day := time.Date(y, m, d, 0, 0, 0, 0, loc); day.Before(end); {
+		y, m, d := day.Date()
+		for _, r := range h.hoursOf(y, m, d, day.Weekday()) {
+			from := time.Date(y, m, d, int(r.Start.Hours()), int(r.Start.Minutes())%60, 0, 0, loc)
+			to := time.Date(y, m, d, int(r.End.Hours()), int(r.End.Minutes())%60, 0, 0, loc)
+			working = append(working, interval{from.UTC(), to.UTC()})
+		}
+		day = time.Date(y, m, d+1, 0, 0, 0, 0, loc)
+	}
+	sort.Slice(working, func(i, j int) bool { return working[i].start.Before(working[j].start) })
+
+	off := make([]interval, 0)
+	free := start
+	for _, w := range working {
+		if w.start.After(free) {
+			off = append(off, interval{free, w.start})
+		}
+		if w.end.After(free) {
+			free = w.end
+		}
+	}
+	if free.Before(end) {
+		off = append(off, interval{free, end})
+	}
+	return off
+}
+
+// general handler for /working_hours path
+func (s *Service) WorkingHoursHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPut:
+		s.workingHoursPutHandler(w, r)
+	case http.MethodDelete:
+		s.workingHoursDeleteHandler(w, r)
+	default:
+		writeError(w, r, fmt.Errorf("%s: %w", r.Method, errNotImplemented))
+	}
+}
+
+// @Summary     set working hours
+// @Description replace working hours of the user. The free time search proposes slots in working hours of every user only.
+// @Accept      application/x-www-form-urlencoded
+// @Produce     application/json
+// @Param       id        path     uint32      true  "User ID"
+// @Param       time_zone path     string      false "IANA time zone of the working hours. UTC by default."
+// @Param       weekly    path     []string    true  "Working hours of the week days, e.g. 'MO,TU,WE,TH,FR 09:00-12:00,13:00-17:00'"
+// @Param       exception path     []string    false "Working hours of the date replacing the weekly ones, e.g. '2023-12-24 09:00-12:00' or '2023-12-25' for a day off"
+// @Success     200       {object} lib.User    "Changed user information"
+// @Failure     400       {object} lib.Problem "error description"
+// @Failure     404       {object} lib.Problem "error description"
+// @Failure     500       {object} lib.Problem "error description"
+// @Router      /working_hours [put]
+func (s *Service) workingHoursPutHandler(w http.ResponseWriter, r *http.Request) {
+	if err := parseForm(r); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	params := parameters{
+		idTag:        singleValue | parameterRequired,
+		timeZoneTag:  singleValue,
+		weeklyTag:    multipleValue | parameterRequired,
+		exceptionTag: multipleValue,
+	}
+	if err := checkArgs(&r.Form, params); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	id, err := strconv.ParseUint(r.FormValue(idTag), 10, 32)
+	if err != nil {
+		writeError(w, r, paramError(idTag, err))
+		return
+	}
+
+	hours := &WorkingHours{TimeZone: r.FormValue(timeZoneTag)}
+	if _, err := loadLocation(hours.TimeZone); err != nil {
+		writeError(w, r, paramError(timeZoneTag, err))
+		return
+	}
+	for _, value := range r.Form[weeklyTag] {
+		weekly, err := ParseWeeklyHours(value)
+		if err != nil {
+			writeError(w, r, paramError(weeklyTag, err))
+			return
+		}
+		hours.Weekly = append(hours.Weekly, weekly)
+	}
+	for _, value := range r.Form[exceptionTag] {
+		date, err := ParseDateHours(value)
+		if err != nil {
+			writeError(w, r, paramError(exceptionTag, err))
+			return
+		}
+		hours.Exceptions = append(hours.Exceptions, date)
+	}
+
+	s.updateWorkingHours(w, r, UID(id), hours)
+}
+
+// @Summary     remove working hours
+// @Description remove working hours of the user, so the user may take part in meetings any time
+// @Accept      application/x-www-form-urlencoded
+// @Produce     application/json
+// @Param       id  path     uint32      true "User ID"
+// @Success     200 {object} lib.User    "Changed user information"
+// @Failure     400 {object} lib.Problem "error description"
+// @Failure     404 {object} lib.Problem "error description"
+// @Failure     500 {object} lib.Problem "error description"
+// @Router      /working_hours [delete]
+func (s *Service) workingHoursDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	if err := parseForm(r); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	params := parameters{
+		idTag: singleValue | parameterRequired,
+	}
+	if err := checkArgs(&r.Form, params); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	id, err := strconv.ParseUint(r.FormValue(idTag), 10, 32)
+	if err != nil {
+		writeError(w, r, paramError(idTag, err))
+		return
+	}
+
+	s.updateWorkingHours(w, r, UID(id), nil)
+}
+
+// saves working hours of the user and writes the changed user
+func (s *Service) updateWorkingHours(w http.ResponseWriter, r *http.Request, id UID, hours *WorkingHours) {
+	if err := s.authorize(r, idTag, id); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	usr, err := s.storage.UserFindById(id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	usr.WorkingHours = hours
+
+	if err = s.storage.UserUpdate(usr); err != nil {
+		writeError(w, r, fmt.Errorf("update id=%v: %w", id, err))
+		return
+	}
+
+	result, err := json.MarshalIndent(usr, "", "  ")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.Header().Set(contentTypeTag, mimeJson)
+	w.Write(result)
+}
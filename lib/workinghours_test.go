@@ -0,0 +1,42 @@
+package lib
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestOffHours(t *testing.T) {
+	weekly, err := ParseWeeklyHours("SA,SU 22:00-24:00")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	night, err := ParseWeeklyHours("MO 00:00-02:00")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	h := WorkingHours{TimeZone: "Europe/Berlin", Weekly: []WeeklyHours{weekly, night}}
+
+	// summer time starts on Sunday 2023-03-26
+	off := h.offHours(getTestTime("2023-03-25T00:00:00Z"), getTestTime("2023-03-28T00:00:00Z"))
+	expected := []interval{
+		{getTestTime("2023-03-25T00:00:00Z"), getTestTime("2023-03-25T21:00:00Z")},
+		{getTestTime("2023-03-25T23:00:00Z"), getTestTime("2023-03-26T20:00:00Z")},
+		{getTestTime("2023-03-27T00:00:00Z"), getTestTime("2023-03-28T00:00:00Z")},
+	}
+	if fmt.Sprint(off) != fmt.Sprint(expected) {
+		t.Errorf("off hours: expected: %v, actual: %v", expected, off)
+	}
+}
+
+func TestParseWorkingHoursErrors(t *testing.T) {
+	for _, s := range []string{"", "MO", "MO 09:00", "1MO 09:00-17:00", "MO 09:00-24:30", "MO 9:00-17:00", "MO 09:60-17:00", "MO 09:00-17:00 extra"} {
+		if _, err := ParseWeeklyHours(s); err == nil {
+			t.Errorf("%q: expected parse error", s)
+		}
+	}
+	for _, s := range []string{"", "2023-02-30", "2023-02-01 17:00-09:00"} {
+		if _, err := ParseDateHours(s); err == nil {
+			t.Errorf("%q: expected parse error", s)
+		}
+	}
+}
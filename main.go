@@ -6,19 +6,51 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"os"
 
 	_ "github.com/lev69/schedule/docs"
+	schedule "github.com/lev69/schedule/lib"
 )
 
+// the environment variable keeping the admin token
+const adminTokenEnv = "SCHEDULE_ADMIN_TOKEN"
+
 // @title        Schedule API
 // @version      0.9
 // @description  Schedule is simple calendare service
 // @license.name WTFPL
 // @host         localhost:8000
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		importCommand(os.Args[2:])
+		return
+	}
+
 	port := flag.Uint("p", 8000, "Listen on the port")
 	address := flag.String("a", "localhost", "Bind to the local address")
+	dataDir := flag.String("data", "", "Keep the data in the directory, in memory only if empty")
+	auth := flag.Bool("auth", false, "Require bearer tokens, the admin token is taken from "+adminTokenEnv)
+
+	flag.Parse()
+
+	storage := schedule.NewMemoryStorage()
+	if *dataDir != "" {
+		var err error
+		storage, err = schedule.NewFileStorage(*dataDir)
+		if err != nil {
+			log.Fatalf("open storage %q: %v", *dataDir, err)
+		}
+	}
+
+	service := schedule.NewService(storage)
+	if *auth {
+		adminToken := os.Getenv(adminTokenEnv)
+		if adminToken == "" {
+			log.Fatalf("%s must be set to enable authentication", adminTokenEnv)
+		}
+		service.EnableAuth(adminToken)
+	}
 
-	initRouter()
+	initRouter(http.DefaultServeMux, service)
 	log.Fatal(http.ListenAndServe(fmt.Sprintf("%s:%d", *address, *port), nil))
 }
@@ -3,10 +3,13 @@ package main
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"strings"
 	"testing"
@@ -15,18 +18,20 @@ import (
 	"github.com/lev69/schedule/lib"
 )
 
+var service = lib.NewService(lib.NewMemoryStorage())
+
 func TestMain(m *testing.M) {
 	f, _ := os.Create("service.log")
 	log.Default().SetOutput(f)
-	initRouter()
+	initRouter(http.DefaultServeMux, service)
 	code := m.Run()
-	lib.ResetStorage()
+	resetStorage()
 	f.Close()
 	os.Exit(code)
 }
 
 func TestGetUserListEmptyStorage(t *testing.T) {
-	lib.ResetStorage()
+	resetStorage()
 	response := getUserList()
 	if expected := http.StatusOK; response.Code != expected {
 		t.Errorf("response code: expected: %d, actual: %d\n", expected, response.Code)
@@ -37,30 +42,55 @@ func TestGetUserListEmptyStorage(t *testing.T) {
 }
 
 func TestGetUserWrongTag(t *testing.T) {
-	lib.ResetStorage()
+	resetStorage()
 	req, _ := http.NewRequest("GET", "/user?user_id=2", nil)
 	response := executeRequest(req)
 	if expected := http.StatusBadRequest; response.Code != expected {
 		t.Errorf("response code: expected: %d, actual: %d\n", expected, response.Code)
 	}
-	if expected := 0; response.Body.Len() != expected {
-		t.Errorf("response body: expected: %d, actual: %d\n", expected, response.Body.Len())
+	expectProblem(t, response, "invalid_parameter", "user_id")
+}
+
+func TestErrorResponses(t *testing.T) {
+	resetStorage()
+	createUser("John")
+	for _, test := range []struct {
+		method, target, body string
+		status               int
+		code, param          string
+	}{
+		{"GET", "/user?id=x", "", http.StatusBadRequest, "invalid_parameter", "id"},
+		{"GET", "/user?id=1&id=2", "", http.StatusBadRequest, "invalid_parameter", "id"},
+		{"POST", "/user", "email=john@example.com", http.StatusBadRequest, "invalid_parameter", "name"},
+		{"POST", "/meeting", "creator_id=1&member_ids=1&start_at=2023-09-11T10:00:00Z&duration=bad", http.StatusBadRequest, "invalid_parameter", "duration"},
+		{"PUT", "/user", "id=7&name=Jack", http.StatusNotFound, "not_found", ""},
+		{"PATCH", "/user", "", http.StatusNotImplemented, "not_implemented", ""},
+	} {
+		req, _ := http.NewRequest(test.method, test.target, strings.NewReader(test.body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		response := executeRequest(req)
+		if response.Code != test.status {
+			t.Errorf("%s %s: response code: expected: %d, actual: %d\n", test.method, test.target, test.status, response.Code)
+			continue
+		}
+		problem := expectProblem(t, response, test.code, test.param)
+		if problem.Title != http.StatusText(test.status) || problem.Detail == "" {
+			t.Errorf("%s %s: unexpected problem: %+v\n", test.method, test.target, problem)
+		}
 	}
 }
 
 func TestGetUserIdEmptyStorage(t *testing.T) {
-	lib.ResetStorage()
+	resetStorage()
 	response := getUser(2)
 	if expected := http.StatusNotFound; response.Code != expected {
 		t.Errorf("response code: expected: %d, actual: %d\n", expected, response.Code)
 	}
-	if expected := 0; response.Body.Len() != 0 {
-		t.Errorf("response body: expected: %d, actual: %d\n", expected, response.Body.Len())
-	}
+	expectProblem(t, response, "not_found", "")
 }
 
 func TestCreateAndGetUser(t *testing.T) {
-	lib.ResetStorage()
+	resetStorage()
 
 	//create users
 	names := []string{"John Doe", "Vincent Vega", "John McClane", "Rick Sanchez"}
@@ -127,13 +157,11 @@ func TestCreateAndGetUser(t *testing.T) {
 	if expected := http.StatusNotFound; response.Code != expected {
 		t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
 	}
-	if expected := 0; response.Body.Len() != expected {
-		t.Errorf("response body: expected: %d, actual: %d\n", expected, response.Body.Len())
-	}
+	expectProblem(t, response, "not_found", "")
 }
 
 func TestGetMeetingListEmptyStorage(t *testing.T) {
-	lib.ResetStorage()
+	resetStorage()
 	response := getMeetingList()
 	if expected := http.StatusOK; response.Code != expected {
 		t.Errorf("response code: expected: %d, actual: %d\n", expected, response.Code)
@@ -144,30 +172,26 @@ func TestGetMeetingListEmptyStorage(t *testing.T) {
 }
 
 func TestGetMeetingWrongTag(t *testing.T) {
-	lib.ResetStorage()
+	resetStorage()
 	req, _ := http.NewRequest("GET", "/meeting?meeting_id=2", nil)
 	response := executeRequest(req)
 	if expected := http.StatusBadRequest; response.Code != expected {
 		t.Errorf("response code: expected: %d, actual: %d\n", expected, response.Code)
 	}
-	if expected := 0; response.Body.Len() != expected {
-		t.Errorf("response body: expected: %d, actual: %d\n", expected, response.Body.Len())
-	}
+	expectProblem(t, response, "invalid_parameter", "meeting_id")
 }
 
 func TestGetMeetingIdEmptyStorage(t *testing.T) {
-	lib.ResetStorage()
+	resetStorage()
 	response := getMeeting(2)
 	if expected := http.StatusNotFound; response.Code != expected {
 		t.Errorf("response code: expected: %d, actual: %d\n", expected, response.Code)
 	}
-	if expected := 0; response.Body.Len() != 0 {
-		t.Errorf("response body: expected: %d, actual: %d\n", expected, response.Body.Len())
-	}
+	expectProblem(t, response, "not_found", "")
 }
 
 func TestCreateMeetingWithUnexistingUsers(t *testing.T) {
-	lib.ResetStorage()
+	resetStorage()
 	start := time.Date(2025, time.June, 3, 12, 46, 13, 0, time.UTC)
 	duration, _ := time.ParseDuration("2h45m")
 	params := meetingParams{creator: 1, members: []lib.UID{1, 2, 3, 4}, start: start, duration: duration, period: lib.Once}
@@ -175,13 +199,11 @@ func TestCreateMeetingWithUnexistingUsers(t *testing.T) {
 	if expected := http.StatusNotFound; response.Code != expected {
 		t.Errorf("response code: expected: %d, actual: %d\n", expected, response.Code)
 	}
-	if expected := 0; response.Body.Len() != 0 {
-		t.Errorf("response body: expected: %d, actual: %d\n", expected, response.Body.Len())
-	}
+	expectProblem(t, response, "not_found", "")
 }
 
 func TestCreateAndGetMeeting(t *testing.T) {
-	lib.ResetStorage()
+	resetStorage()
 
 	//create users
 	names := []string{"John Doe", "Vincent Vega", "John McClane", "Rick Sanchez"}
@@ -259,13 +281,11 @@ func TestCreateAndGetMeeting(t *testing.T) {
 	if expected := http.StatusNotFound; response.Code != expected {
 		t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
 	}
-	if expected := 0; response.Body.Len() != expected {
-		t.Errorf("response body: expected: %d, actual: %d\n", expected, response.Body.Len())
-	}
+	expectProblem(t, response, "not_found", "")
 }
 
 func TestSendResponse(t *testing.T) {
-	lib.ResetStorage()
+	resetStorage()
 
 	//create users
 	const (
@@ -356,7 +376,7 @@ func TestSendResponse(t *testing.T) {
 }
 
 func TestUserMeetings(t *testing.T) {
-	lib.ResetStorage()
+	resetStorage()
 
 	//create users
 	const (
@@ -435,8 +455,339 @@ func TestUserMeetings(t *testing.T) {
 	expectMeeting(t, meetingIds[5], ids[johnMcClane], getTime("2012-01-01T12:00:00Z"), getDuration("24h"))
 }
 
+func TestRecurrenceRule(t *testing.T) {
+	resetStorage()
+
+	response := createUser("John Doe")
+	var user idResult
+	if err := json.Unmarshal(response.Body.Bytes(), &user); err != nil {
+		t.Fatalf("parse response body: %v", err)
+	}
+
+	// wrong rules
+	for _, params := range []meetingParams{
+		{creator: user.Id, members: []lib.UID{user.Id}, start: getTime("2023-01-03T10:00:00Z"), duration: getDuration("1h"), period: lib.EveryWeek, rule: "FREQ=WEEKLY"},
+		{creator: user.Id, members: []lib.UID{user.Id}, start: getTime("2023-01-03T10:00:00Z"), duration: getDuration("1h"), period: lib.Custom},
+		{creator: user.Id, members: []lib.UID{user.Id}, start: getTime("2023-01-03T10:00:00Z"), duration: getDuration("1h"), period: lib.Custom, rule: "FREQ=HOURLY"},
+	} {
+		response := createMeeting(params)
+		if expected := http.StatusBadRequest; response.Code != expected {
+			t.Errorf("response code: expected: %d, actual: %d\n", expected, response.Code)
+		}
+	}
+
+	// every 2 weeks on Tuesday and Thursday, 4 times
+	params := meetingParams{creator: user.Id, members: []lib.UID{user.Id}, start: getTime("2023-01-03T10:00:00Z"), duration: getDuration("1h"), period: lib.Custom, rule: "FREQ=WEEKLY;INTERVAL=2;COUNT=4;BYDAY=TU,TH"}
+	response = createMeeting(params)
+	if expected := http.StatusOK; response.Code != expected {
+		t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+	var id meetingIdResult
+	if err := json.Unmarshal(response.Body.Bytes(), &id); err != nil {
+		t.Fatalf("parse response body: %v", err)
+	}
+
+	response = getMeeting(id.Id)
+	var meeting lib.Meeting
+	if err := json.Unmarshal(response.Body.Bytes(), &meeting); err != nil {
+		t.Fatalf("parse response body: %v", err)
+	}
+	if meeting.Repeat != lib.Custom || meeting.Rule == nil || meeting.Rule.String() != params.rule {
+		t.Errorf("meeting rule: expected: %v %q, actual: %v %v\n", lib.Custom, params.rule, meeting.Repeat, meeting.Rule)
+	}
+
+	for _, day := range []string{"2023-01-03", "2023-01-05", "2023-01-17", "2023-01-19"} {
+		expectMeeting(t, id.Id, user.Id, getTime(day+"T09:00:00Z"), getDuration("2h"))
+	}
+	for _, day := range []string{"2023-01-04", "2023-01-10", "2023-01-12", "2023-01-31"} {
+		expectNoMeeting(t, id.Id, user.Id, getTime(day+"T09:00:00Z"), getDuration("2h"))
+	}
+
+	// the period replaces the rule
+	response = patchMeeting(id.Id, user.Id, "period=EveryDay")
+	if expected := http.StatusOK; response.Code != expected {
+		t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+	expectMeeting(t, id.Id, user.Id, getTime("2023-01-31T09:00:00Z"), getDuration("2h"))
+	response = patchMeeting(id.Id, user.Id, "rrule="+url.QueryEscape("FREQ=MONTHLY;BYDAY=1TU"))
+	if expected := http.StatusOK; response.Code != expected {
+		t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+	expectNoMeeting(t, id.Id, user.Id, getTime("2023-01-31T09:00:00Z"), getDuration("2h"))
+	expectMeeting(t, id.Id, user.Id, getTime("2023-02-07T09:00:00Z"), getDuration("2h"))
+}
+
+func TestMeetingException(t *testing.T) {
+	resetStorage()
+
+	userIds := make([]lib.UID, 0, 2)
+	for _, name := range []string{"John Doe", "Vincent Vega"} {
+		response := createUser(name)
+		var user idResult
+		if err := json.Unmarshal(response.Body.Bytes(), &user); err != nil {
+			t.Fatalf("parse response body: %v", err)
+		}
+		userIds = append(userIds, user.Id)
+	}
+	creator, member := userIds[0], userIds[1]
+
+	// every Tuesday 10:00 - 11:00
+	response := createMeeting(meetingParams{creator: creator, members: userIds, start: getTime("2023-01-03T10:00:00Z"), duration: getDuration("1h"), period: lib.EveryWeek})
+	var id meetingIdResult
+	if err := json.Unmarshal(response.Body.Bytes(), &id); err != nil {
+		t.Fatalf("parse response body: %v", err)
+	}
+	response = createMeeting(meetingParams{creator: creator, members: userIds, start: getTime("2023-01-03T12:00:00Z"), duration: getDuration("1h"), period: lib.Once})
+	var onceId meetingIdResult
+	if err := json.Unmarshal(response.Body.Bytes(), &onceId); err != nil {
+		t.Fatalf("parse response body: %v", err)
+	}
+
+	// wrong requests
+	for _, test := range []struct {
+		id       lib.MeetingId
+		user     lib.UID
+		params   string
+		expected int
+	}{
+		{id.Id, creator, "occurrence=2023-01-10T10:00:00Z", http.StatusBadRequest},
+		{id.Id, creator, "occurrence=2023-01-10T10:00:00Z&cancelled=true&duration=2h", http.StatusBadRequest},
+		{id.Id, creator, "occurrence=2023-01-10T11:00:00Z&cancelled=true", http.StatusNotFound},
+		{id.Id, member, "occurrence=2023-01-10T10:00:00Z&cancelled=true", http.StatusForbidden},
+		{onceId.Id, creator, "occurrence=2023-01-03T12:00:00Z&cancelled=true", http.StatusBadRequest},
+		{onceId.Id + 1, creator, "occurrence=2023-01-03T12:00:00Z&cancelled=true", http.StatusNotFound},
+	} {
+		response := putException(test.id, test.user, test.params)
+		if response.Code != test.expected {
+			t.Errorf("%s: response code: expected: %d, actual: %d\n", test.params, test.expected, response.Code)
+		}
+	}
+
+	// cancel one occurrence and move another one
+	response = putException(id.Id, creator, "occurrence=2023-01-10T10:00:00Z&cancelled=true")
+	if expected := http.StatusOK; response.Code != expected {
+		t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+	response = putException(id.Id, creator, "occurrence=2023-01-17T10:00:00Z&start_at=2023-01-17T14:00:00Z&duration=2h")
+	if expected := http.StatusOK; response.Code != expected {
+		t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+	var meeting lib.Meeting
+	if err := json.Unmarshal(response.Body.Bytes(), &meeting); err != nil {
+		t.Fatalf("parse response body: %v", err)
+	}
+	if len(meeting.ExDates) != 1 || len(meeting.Overrides) != 1 {
+		t.Errorf("meeting exceptions: expected: 1 and 1, actual: %v and %v\n", meeting.ExDates, meeting.Overrides)
+	}
+
+	for _, userId := range userIds {
+		expectMeeting(t, id.Id, userId, getTime("2023-01-03T09:00:00Z"), getDuration("2h"))
+		expectNoMeeting(t, id.Id, userId, getTime("2023-01-10T09:00:00Z"), getDuration("2h"))
+		expectNoMeeting(t, id.Id, userId, getTime("2023-01-17T09:00:00Z"), getDuration("2h"))
+		expectMeeting(t, id.Id, userId, getTime("2023-01-17T15:30:00Z"), getDuration("1h"))
+		expectMeeting(t, id.Id, userId, getTime("2023-01-24T09:00:00Z"), getDuration("2h"))
+	}
+
+	for _, test := range []struct {
+		start, expected string
+	}{
+		{"2023-01-10T10:00:00Z", "2023-01-10T10:00:00Z"},
+		{"2023-01-17T10:00:00Z", "2023-01-17T10:00:00Z"},
+		{"2023-01-17T13:30:00Z", "2023-01-17T16:00:00Z"},
+		{"2023-01-24T10:00:00Z", "2023-01-24T11:00:00Z"},
+	} {
+		response = findFreeTime(userIds, getTime(test.start), getDuration("1h"))
+		if expected := http.StatusOK; response.Code != expected {
+			t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+		}
+		var foundTime time.Time
+		if err := json.Unmarshal(response.Body.Bytes(), &foundTime); err != nil {
+			t.Fatalf("parse response body: %v", err)
+		}
+		if !foundTime.Equal(getTime(test.expected)) {
+			t.Errorf("free time after %s: expected: %s, actual: %v", test.start, test.expected, foundTime)
+		}
+	}
+
+	// restore the cancelled occurrence
+	response = deleteException(id.Id, creator, "2023-01-10T10:00:00Z")
+	if expected := http.StatusOK; response.Code != expected {
+		t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+	expectMeeting(t, id.Id, creator, getTime("2023-01-10T09:00:00Z"), getDuration("2h"))
+	response = deleteException(id.Id, creator, "2023-01-10T10:00:00Z")
+	if expected := http.StatusNotFound; response.Code != expected {
+		t.Errorf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+
+	// the series time change drops the exceptions
+	response = patchMeeting(id.Id, creator, "start_at=2023-01-03T09:00:00Z")
+	if expected := http.StatusOK; response.Code != expected {
+		t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+	meeting = lib.Meeting{}
+	if err := json.Unmarshal(response.Body.Bytes(), &meeting); err != nil {
+		t.Fatalf("parse response body: %v", err)
+	}
+	if len(meeting.ExDates) != 0 || len(meeting.Overrides) != 0 {
+		t.Errorf("meeting exceptions: expected none, actual: %v and %v\n", meeting.ExDates, meeting.Overrides)
+	}
+	expectMeeting(t, id.Id, creator, getTime("2023-01-17T09:00:00Z"), getDuration("1h"))
+}
+
+func TestTimeZone(t *testing.T) {
+	resetStorage()
+
+	response := createUser("John Doe")
+	var user idResult
+	if err := json.Unmarshal(response.Body.Bytes(), &user); err != nil {
+		t.Fatalf("parse response body: %v", err)
+	}
+
+	response = createMeeting(meetingParams{creator: user.Id, members: []lib.UID{user.Id}, start: getTime("2023-03-20T09:00:00Z"), duration: getDuration("30m"), period: lib.EveryDay, timeZone: "Mars/Olympus_Mons"})
+	if expected := http.StatusBadRequest; response.Code != expected {
+		t.Errorf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+
+	// 10:00 standup in Berlin and the same one in UTC
+	ids := make([]lib.MeetingId, 0, 2)
+	for _, timeZone := range []string{"Europe/Berlin", ""} {
+		response = createMeeting(meetingParams{creator: user.Id, members: []lib.UID{user.Id}, start: getTime("2023-03-20T09:00:00Z"), duration: getDuration("30m"), period: lib.EveryDay, timeZone: timeZone})
+		if expected := http.StatusOK; response.Code != expected {
+			t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+		}
+		var id meetingIdResult
+		if err := json.Unmarshal(response.Body.Bytes(), &id); err != nil {
+			t.Fatalf("parse response body: %v", err)
+		}
+		ids = append(ids, id.Id)
+	}
+	berlin, utc := ids[0], ids[1]
+
+	response = getMeeting(berlin)
+	var meeting lib.Meeting
+	if err := json.Unmarshal(response.Body.Bytes(), &meeting); err != nil {
+		t.Fatalf("parse response body: %v", err)
+	}
+	if meeting.TimeZone != "Europe/Berlin" {
+		t.Errorf("meeting time zone: expected: %q, actual: %q\n", "Europe/Berlin", meeting.TimeZone)
+	}
+
+	// summer time starts on 2023-03-26 and ends on 2023-10-29
+	for _, test := range []struct {
+		id   lib.MeetingId
+		at   string
+		none string
+	}{
+		{berlin, "2023-03-25T09:00:00Z", "2023-03-25T08:00:00Z"},
+		{berlin, "2023-03-26T08:00:00Z", "2023-03-26T09:00:00Z"},
+		{berlin, "2023-03-27T08:00:00Z", "2023-03-27T09:00:00Z"},
+		{berlin, "2023-10-28T08:00:00Z", "2023-10-28T09:00:00Z"},
+		{berlin, "2023-10-29T09:00:00Z", "2023-10-29T08:00:00Z"},
+		{berlin, "2023-10-30T09:00:00Z", "2023-10-30T08:00:00Z"},
+		{utc, "2023-03-27T09:00:00Z", "2023-03-27T08:00:00Z"},
+		{utc, "2023-10-30T09:00:00Z", "2023-10-30T08:00:00Z"},
+	} {
+		expectMeeting(t, test.id, user.Id, getTime(test.at), getDuration("30m"))
+		expectNoMeeting(t, test.id, user.Id, getTime(test.none), getDuration("30m"))
+	}
+
+	response = findFreeTime([]lib.UID{user.Id}, getTime("2023-03-27T07:30:00Z"), getDuration("1h"))
+	if expected := http.StatusOK; response.Code != expected {
+		t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+	var foundTime time.Time
+	if err := json.Unmarshal(response.Body.Bytes(), &foundTime); err != nil {
+		t.Fatalf("parse response body: %v", err)
+	}
+	if expected := getTime("2023-03-27T09:30:00Z"); !foundTime.Equal(expected) {
+		t.Errorf("free time: expected: %v, actual: %v", expected, foundTime)
+	}
+
+	// the time zone change moves the meeting
+	response = patchMeeting(utc, user.Id, "time_zone=Europe/Berlin")
+	if expected := http.StatusOK; response.Code != expected {
+		t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+	expectMeeting(t, utc, user.Id, getTime("2023-03-27T08:00:00Z"), getDuration("30m"))
+	response = patchMeeting(utc, user.Id, "time_zone=Nowhere")
+	if expected := http.StatusBadRequest; response.Code != expected {
+		t.Errorf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+}
+
+func TestOccurrences(t *testing.T) {
+	resetStorage()
+
+	response := createUser("John Doe")
+	var user idResult
+	if err := json.Unmarshal(response.Body.Bytes(), &user); err != nil {
+		t.Fatalf("parse response body: %v", err)
+	}
+
+	ids := make([]lib.MeetingId, 0, 3)
+	for _, params := range []meetingParams{
+		{creator: user.Id, members: []lib.UID{user.Id}, start: getTime("2023-01-04T12:00:00Z"), duration: getDuration("1h"), period: lib.Once},
+		{creator: user.Id, members: []lib.UID{user.Id}, start: getTime("2023-01-02T10:00:00Z"), duration: getDuration("30m"), period: lib.EveryWeek},
+		{creator: user.Id, members: []lib.UID{user.Id}, start: getTime("2023-01-03T09:00:00Z"), duration: getDuration("2h"), period: lib.Custom, rule: "FREQ=DAILY;COUNT=3"},
+	} {
+		response := createMeeting(params)
+		if expected := http.StatusOK; response.Code != expected {
+			t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+		}
+		var id meetingIdResult
+		if err := json.Unmarshal(response.Body.Bytes(), &id); err != nil {
+			t.Fatalf("parse response body: %v", err)
+		}
+		ids = append(ids, id.Id)
+	}
+	once, weekly, daily := ids[0], ids[1], ids[2]
+	sendPresence(user.Id, weekly, lib.Accepted)
+	putException(daily, user.Id, "occurrence=2023-01-04T09:00:00Z&start_at=2023-01-04T13:00:00Z&duration=1h")
+	putException(daily, user.Id, "occurrence=2023-01-05T09:00:00Z&cancelled=true")
+
+	response = getOccurrences(user.Id, "2023-01-02T10:15:00Z", "2023-01-10T00:00:00Z")
+	if expected := http.StatusOK; response.Code != expected {
+		t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+	var occurrences []lib.Occurrence
+	if err := json.Unmarshal(response.Body.Bytes(), &occurrences); err != nil {
+		t.Fatalf("parse response body: %v", err)
+	}
+	expected := []lib.Occurrence{
+		{MeetingId: weekly, StartAt: getTime("2023-01-02T10:00:00Z"), EndAt: getTime("2023-01-02T10:30:00Z"), Presence: lib.Accepted},
+		{MeetingId: daily, StartAt: getTime("2023-01-03T09:00:00Z"), EndAt: getTime("2023-01-03T11:00:00Z"), Presence: lib.Unknown},
+		{MeetingId: once, StartAt: getTime("2023-01-04T12:00:00Z"), EndAt: getTime("2023-01-04T13:00:00Z"), Presence: lib.Unknown},
+		{MeetingId: daily, StartAt: getTime("2023-01-04T13:00:00Z"), EndAt: getTime("2023-01-04T14:00:00Z"), Presence: lib.Unknown},
+		{MeetingId: weekly, StartAt: getTime("2023-01-09T10:00:00Z"), EndAt: getTime("2023-01-09T10:30:00Z"), Presence: lib.Accepted},
+	}
+	if fmt.Sprint(occurrences) != fmt.Sprint(expected) {
+		t.Errorf("occurrences: expected: %v, actual: %v\n", expected, occurrences)
+	}
+
+	for _, test := range []struct {
+		id         lib.UID
+		start, end string
+		expected   int
+	}{
+		{user.Id, "2023-01-02T00:00:00Z", "2023-01-02T00:00:00Z", http.StatusBadRequest},
+		{user.Id, "2023-01-02T00:00:00Z", "yesterday", http.StatusBadRequest},
+		{user.Id, "2023-01-02T00:00:00Z", "2024-01-04T00:00:00Z", http.StatusBadRequest},
+		{user.Id + 1, "2023-01-02T00:00:00Z", "2023-01-03T00:00:00Z", http.StatusNotFound},
+	} {
+		response := getOccurrences(test.id, test.start, test.end)
+		if response.Code != test.expected {
+			t.Errorf("response code: expected: %d, actual: %d\n", test.expected, response.Code)
+		}
+	}
+	expectProblem(t, getOccurrences(user.Id, "2023-01-02T00:00:00Z", "2024-01-04T00:00:00Z"), "invalid_parameter", "end_at")
+	if response = getOccurrences(user.Id, "2023-01-02T00:00:00Z", "2024-01-02T00:00:00Z"); response.Code != http.StatusOK {
+		t.Errorf("year long period: response code: expected: %d, actual: %d\n", http.StatusOK, response.Code)
+	}
+}
+
 func TestFindFreeTime(t *testing.T) {
-	lib.ResetStorage()
+	resetStorage()
 
 	//create users
 	const (
@@ -519,97 +870,2208 @@ func TestFindFreeTime(t *testing.T) {
 	}
 }
 
-//
-// helper functions
-//
+func TestFindFreeSlots(t *testing.T) {
+	resetStorage()
 
-func expectMeeting(t *testing.T, meetingId lib.MeetingId, userId lib.UID, start time.Time, duration time.Duration) {
-	response := getUserMeetings(userId, start, duration)
-	if expected := http.StatusOK; response.Code != expected {
-		t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
-	}
-	var meets []lib.MeetingId
-	if err := json.Unmarshal(response.Body.Bytes(), &meets); err != nil {
+	response := createUser("John Doe")
+	var user idResult
+	if err := json.Unmarshal(response.Body.Bytes(), &user); err != nil {
 		t.Fatalf("parse response body: %v", err)
 	}
-	found := false
-	for _, meet := range meets {
-		if meet == meetingId {
-			found = true
+	for _, params := range []meetingParams{
+		{creator: user.Id, members: []lib.UID{user.Id}, start: getTime("2023-02-01T09:00:00Z"), duration: getDuration("1h"), period: lib.Once},
+		{creator: user.Id, members: []lib.UID{user.Id}, start: getTime("2023-02-01T10:00:00Z"), duration: getDuration("50m"), period: lib.Once},
+		{creator: user.Id, members: []lib.UID{user.Id}, start: getTime("2023-02-01T13:00:00Z"), duration: getDuration("1h"), period: lib.Once},
+	} {
+		if response := createMeeting(params); response.Code != http.StatusOK {
+			t.Fatalf("response code: expected: %d, actual: %d\n", http.StatusOK, response.Code)
 		}
 	}
-	if !found {
-		t.Errorf("meeting %d should be listed in period %v - %v", meetingId, start, start.Add(duration))
-	}
-}
 
-func expectNoMeeting(t *testing.T, meetingId lib.MeetingId, userId lib.UID, start time.Time, duration time.Duration) {
-	response := getUserMeetings(userId, start, duration)
-	if expected := http.StatusOK; response.Code != expected {
-		t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	for _, test := range []struct {
+		params   string
+		expected []string
+	}{
+		{"limit=3", []string{"10:50", "12:00", "14:00"}},
+		{"limit=3&rank=earliest", []string{"10:50", "12:00", "14:00"}},
+		{"limit=3&rank=score", []string{"11:30", "15:00", "16:00"}},
+		{"limit=1&rank=score", []string{"11:30"}},
+	} {
+		response := findFreeSlots(user.Id, "2023-02-01T09:00:00Z", "1h", test.params)
+		if expected := http.StatusOK; response.Code != expected {
+			t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+		}
+		var slots []lib.Slot
+		if err := json.Unmarshal(response.Body.Bytes(), &slots); err != nil {
+			t.Fatalf("parse response body: %v", err)
+		}
+		actual := make([]string, 0, len(slots))
+		for _, slot := range slots {
+			if slot.EndAt.Sub(slot.StartAt) != time.Hour {
+				t.Errorf("%s: slot duration: expected: 1h, actual: %v", test.params, slot.EndAt.Sub(slot.StartAt))
+			}
+			actual = append(actual, slot.StartAt.Format("15:04"))
+		}
+		if fmt.Sprint(actual) != fmt.Sprint(test.expected) {
+			t.Errorf("%s: slots: expected: %v, actual: %v", test.params, test.expected, actual)
+		}
 	}
-	var meets []lib.MeetingId
-	if err := json.Unmarshal(response.Body.Bytes(), &meets); err != nil {
+
+	// the best slot without limit
+	response = findFreeSlots(user.Id, "2023-02-01T09:00:00Z", "1h", "rank=score")
+	var foundTime time.Time
+	if err := json.Unmarshal(response.Body.Bytes(), &foundTime); err != nil {
 		t.Fatalf("parse response body: %v", err)
 	}
-	for _, meet := range meets {
-		if meet == meetingId {
-			t.Errorf("meeting %d shouldn't be listed in period %v - %v", meetingId, start, start.Add(duration))
-		}
+	if expected := getTime("2023-02-01T11:30:00Z"); !foundTime.Equal(expected) {
+		t.Errorf("free time: expected: %v, actual: %v", expected, foundTime)
 	}
-}
-
-func createUser(name string) *httptest.ResponseRecorder {
-	var payload bytes.Buffer
-	fmt.Fprintf(&payload, "name=%s", name)
-	req, _ := http.NewRequest("POST", "/user", &payload)
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	return executeRequest(req)
-}
 
-func getUser(id lib.UID) *httptest.ResponseRecorder {
-	req, _ := http.NewRequest("GET", fmt.Sprintf("/user?id=%d", id), nil)
-	return executeRequest(req)
+	for _, params := range []string{"limit=0", "limit=1000", "limit=many", "rank=best"} {
+		response := findFreeSlots(user.Id, "2023-02-01T09:00:00Z", "1h", params)
+		if expected := http.StatusBadRequest; response.Code != expected {
+			t.Errorf("%s: response code: expected: %d, actual: %d\n", params, expected, response.Code)
+		}
+	}
 }
 
-func getUserList() *httptest.ResponseRecorder {
-	req, _ := http.NewRequest("GET", "/user", nil)
-	return executeRequest(req)
-}
+func TestWorkingHours(t *testing.T) {
+	resetStorage()
 
-type meetingParams struct {
-	creator  lib.UID
-	members  []lib.UID
-	start    time.Time
-	duration time.Duration
-	period   lib.Period
-}
+	userIds := make([]lib.UID, 0, 2)
+	for _, name := range []string{"John Doe", "Vincent Vega"} {
+		response := createUser(name)
+		var user idResult
+		if err := json.Unmarshal(response.Body.Bytes(), &user); err != nil {
+			t.Fatalf("parse response body: %v", err)
+		}
+		userIds = append(userIds, user.Id)
+	}
+	john, vincent := userIds[0], userIds[1]
 
-func createMeeting(p meetingParams) *httptest.ResponseRecorder {
-	var payload bytes.Buffer
-	fmt.Fprintf(&payload, "creator_id=%d&member_ids=", p.creator)
-	for i, id := range p.members {
-		if i > 0 {
-			fmt.Fprintf(&payload, ",")
+	for _, params := range []string{
+		"weekly=" + url.QueryEscape("MO,TU 9:00-17:00"),
+		"weekly=" + url.QueryEscape("MO 17:00-09:00"),
+		"weekly=" + url.QueryEscape("XX 09:00-17:00"),
+		"weekly=" + url.QueryEscape("MO 09:00-17:00") + "&exception=2023-13-01",
+		"weekly=" + url.QueryEscape("MO 09:00-17:00") + "&time_zone=Nowhere",
+		"exception=2023-03-29",
+	} {
+		response := putWorkingHours(john, params)
+		if expected := http.StatusBadRequest; response.Code != expected {
+			t.Errorf("%s: response code: expected: %d, actual: %d\n", params, expected, response.Code)
 		}
-		fmt.Fprintf(&payload, "%d", id)
 	}
-	fmt.Fprintf(&payload, "&start_at=%s", p.start.Format(time.RFC3339))
-	fmt.Fprintf(&payload, "&duration=%v", p.duration)
-	fmt.Fprintf(&payload, "&period=%v", p.period)
 
-	req, _ := http.NewRequest("POST", "/meeting", &payload)
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	return executeRequest(req)
-}
+	// office hours in Berlin, a day off on Wednesday and a short Thursday
+	params := "time_zone=Europe/Berlin&weekly=" + url.QueryEscape("MO,TU,WE,TH,FR 09:00-17:00") +
+		"&exception=2023-03-29&exception=" + url.QueryEscape("2023-03-30 13:00-15:00")
+	response := putWorkingHours(john, params)
+	if expected := http.StatusOK; response.Code != expected {
+		t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+	response = getUser(john)
+	var user lib.User
+	if err := json.Unmarshal(response.Body.Bytes(), &user); err != nil {
+		t.Fatalf("parse response body: %v", err)
+	}
+	if user.WorkingHours == nil || user.WorkingHours.TimeZone != "Europe/Berlin" ||
+		fmt.Sprint(user.WorkingHours.Weekly) != "[MO,TU,WE,TH,FR 09:00-17:00]" ||
+		fmt.Sprint(user.WorkingHours.Exceptions) != "[2023-03-29 2023-03-30 13:00-15:00]" {
+		t.Errorf("working hours: actual: %+v\n", user.WorkingHours)
+	}
 
-func getMeeting(id lib.MeetingId) *httptest.ResponseRecorder {
-	req, _ := http.NewRequest("GET", fmt.Sprintf("/meeting?id=%d", id), nil)
-	return executeRequest(req)
-}
+	response = createMeeting(meetingParams{creator: vincent, members: []lib.UID{vincent}, start: getTime("2023-03-27T07:00:00Z"), duration: getDuration("1h"), period: lib.Once})
+	if expected := http.StatusOK; response.Code != expected {
+		t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
 
-func getMeetingList() *httptest.ResponseRecorder {
-	req, _ := http.NewRequest("GET", "/meeting", nil)
+	for _, test := range []struct {
+		start    string
+		expected string
+	}{
+		// weekend, then the meeting
+		{"2023-03-25T00:00:00Z", "2023-03-27T08:00:00Z"},
+		// evening, the day off and the short day
+		{"2023-03-28T15:30:00Z", "2023-03-30T11:00:00Z"},
+		{"2023-03-30T12:30:00Z", "2023-03-31T07:00:00Z"},
+	} {
+		response := findFreeTime(userIds, getTime(test.start), getDuration("1h"))
+		if expected := http.StatusOK; response.Code != expected {
+			t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+		}
+		var foundTime time.Time
+		if err := json.Unmarshal(response.Body.Bytes(), &foundTime); err != nil {
+			t.Fatalf("parse response body: %v", err)
+		}
+		if !foundTime.Equal(getTime(test.expected)) {
+			t.Errorf("free time after %s: expected: %s, actual: %v", test.start, test.expected, foundTime)
+		}
+	}
+
+	// the user without working hours is available any time
+	response = deleteWorkingHours(john)
+	if expected := http.StatusOK; response.Code != expected {
+		t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+	response = findFreeTime(userIds, getTime("2023-03-25T00:00:00Z"), getDuration("1h"))
+	var foundTime time.Time
+	if err := json.Unmarshal(response.Body.Bytes(), &foundTime); err != nil {
+		t.Fatalf("parse response body: %v", err)
+	}
+	if expected := getTime("2023-03-25T00:00:00Z"); !foundTime.Equal(expected) {
+		t.Errorf("free time: expected: %v, actual: %v", expected, foundTime)
+	}
+	if response := deleteWorkingHours(vincent + 1); response.Code != http.StatusNotFound {
+		t.Errorf("response code: expected: %d, actual: %d\n", http.StatusNotFound, response.Code)
+	}
+}
+
+func TestPresenceAwareFreeTime(t *testing.T) {
+	resetStorage()
+
+	response := createUser("John Doe")
+	var user idResult
+	if err := json.Unmarshal(response.Body.Bytes(), &user); err != nil {
+		t.Fatalf("parse response body: %v", err)
+	}
+
+	// rejected, tentative, unanswered and accepted meetings one after another
+	presences := []lib.Presence{lib.Rejected, lib.Tentative, lib.Unknown, lib.Accepted}
+	for i, presence := range presences {
+		start := getTime("2023-04-03T09:00:00Z").Add(time.Duration(i) * time.Hour)
+		response := createMeeting(meetingParams{creator: user.Id, members: []lib.UID{user.Id}, start: start, duration: getDuration("1h"), period: lib.Once})
+		var id meetingIdResult
+		if err := json.Unmarshal(response.Body.Bytes(), &id); err != nil {
+			t.Fatalf("parse response body: %v", err)
+		}
+		if presence != lib.Unknown {
+			if response := sendPresence(user.Id, id.Id, presence); response.Code != http.StatusOK {
+				t.Fatalf("response code: expected: %d, actual: %d\n", http.StatusOK, response.Code)
+			}
+		}
+	}
+
+	for _, test := range []struct {
+		start    string
+		params   string
+		expected string
+	}{
+		{"09:00", "", "09:00"},
+		{"10:00", "", "13:00"},
+		{"10:00", "tentative=free", "10:00"},
+		{"11:00", "tentative=free", "13:00"},
+		{"11:00", "unknown_presence=tentative", "13:00"},
+		{"11:00", "unknown_presence=tentative&tentative=free", "11:00"},
+		{"11:00", "unknown_presence=busy&tentative=busy", "13:00"},
+	} {
+		response := findFreeSlots(user.Id, "2023-04-03T"+test.start+":00Z", "1h", test.params)
+		if expected := http.StatusOK; response.Code != expected {
+			t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+		}
+		var foundTime time.Time
+		if err := json.Unmarshal(response.Body.Bytes(), &foundTime); err != nil {
+			t.Fatalf("parse response body: %v", err)
+		}
+		if foundTime.Format("15:04") != test.expected {
+			t.Errorf("free time after %s with %q: expected: %s, actual: %v", test.start, test.params, test.expected, foundTime)
+		}
+	}
+
+	for _, params := range []string{"unknown_presence=free", "tentative=maybe"} {
+		response := findFreeSlots(user.Id, "2023-04-03T09:00:00Z", "1h", params)
+		if expected := http.StatusBadRequest; response.Code != expected {
+			t.Errorf("%s: response code: expected: %d, actual: %d\n", params, expected, response.Code)
+		}
+	}
+}
+
+func TestFindFreeTimeWindow(t *testing.T) {
+	resetStorage()
+
+	response := createUser("John Doe")
+	var user idResult
+	if err := json.Unmarshal(response.Body.Bytes(), &user); err != nil {
+		t.Fatalf("parse response body: %v", err)
+	}
+	// Monday
+	response = createMeeting(meetingParams{creator: user.Id, members: []lib.UID{user.Id}, start: getTime("2023-05-01T09:00:00Z"), duration: getDuration("1h10m"), period: lib.Once})
+	if expected := http.StatusOK; response.Code != expected {
+		t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+
+	for _, test := range []struct {
+		start    string
+		params   string
+		expected string
+	}{
+		{"2023-05-01T09:00:00Z", "granularity=30m", "2023-05-01T10:30:00Z"},
+		{"2023-05-01T09:00:00Z", "granularity=1h&time_zone=Asia/Kolkata", "2023-05-01T10:30:00Z"},
+		{"2023-05-01T09:00:00Z", "weekdays=TU,WE&window=09:00-17:00", "2023-05-02T09:00:00Z"},
+		{"2023-05-01T07:00:00Z", "window=09:00-12:00&time_zone=Europe/Berlin", "2023-05-01T07:00:00Z"},
+		{"2023-05-01T08:30:00Z", "window=09:00-12:00&time_zone=Europe/Berlin", "2023-05-02T07:00:00Z"},
+		{"2023-05-01T09:00:00Z", "horizon=3h", "2023-05-01T10:10:00Z"},
+		{"2023-05-01T09:00:00Z", "end_at=2023-05-01T11:10:00Z", "2023-05-01T10:10:00Z"},
+	} {
+		response := findFreeSlots(user.Id, test.start, "1h", test.params)
+		if expected := http.StatusOK; response.Code != expected {
+			t.Fatalf("%s: response code: expected: %d, actual: %d\n", test.params, expected, response.Code)
+		}
+		var foundTime time.Time
+		if err := json.Unmarshal(response.Body.Bytes(), &foundTime); err != nil {
+			t.Fatalf("parse response body: %v", err)
+		}
+		if !foundTime.Equal(getTime(test.expected)) {
+			t.Errorf("free time after %s with %q: expected: %s, actual: %v", test.start, test.params, test.expected, foundTime)
+		}
+	}
+
+	// no slot fits into the search period
+	response = findFreeSlots(user.Id, "2023-05-01T09:00:00Z", "1h", "end_at=2023-05-01T11:09:00Z")
+	if expected := http.StatusNotFound; response.Code != expected {
+		t.Errorf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+	response = findFreeSlots(user.Id, "2023-05-01T09:00:00Z", "1h", "end_at=2023-05-01T11:09:00Z&limit=5")
+	if expected := http.StatusOK; response.Code != expected {
+		t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+	var slots []lib.Slot
+	if err := json.Unmarshal(response.Body.Bytes(), &slots); err != nil {
+		t.Fatalf("parse response body: %v", err)
+	}
+	if len(slots) != 0 {
+		t.Errorf("slots: expected none, actual: %v", slots)
+	}
+
+	for _, params := range []string{
+		"end_at=2023-05-02T00:00:00Z&horizon=1h",
+		"end_at=2023-05-01T08:00:00Z",
+		"horizon=-1h",
+		"granularity=0s",
+		"granularity=25h",
+		"weekdays=XX",
+		"weekdays=1MO",
+		"window=9-17",
+		"time_zone=Nowhere",
+	} {
+		response := findFreeSlots(user.Id, "2023-05-01T09:00:00Z", "1h", params)
+		if expected := http.StatusBadRequest; response.Code != expected {
+			t.Errorf("%s: response code: expected: %d, actual: %d\n", params, expected, response.Code)
+		}
+	}
+
+	// the rejected parameter is reported
+	for _, test := range []struct {
+		params, param string
+	}{
+		{"end_at=2023-05-02T00:00:00Z&horizon=1h", "horizon"},
+		{"end_at=2024-05-02T00:00:00Z", "end_at"},
+		{"horizon=8785h", "horizon"},
+		{"horizon=-1h", "horizon"},
+	} {
+		response := findFreeSlots(user.Id, "2023-05-01T09:00:00Z", "1h", test.params)
+		if expected := http.StatusBadRequest; response.Code != expected {
+			t.Errorf("%s: response code: expected: %d, actual: %d\n", test.params, expected, response.Code)
+		}
+		expectProblem(t, response, "invalid_parameter", test.param)
+	}
+	if response := findFreeSlots(user.Id, "2023-05-01T09:00:00Z", "1h", "end_at=2024-05-01T09:00:00Z"); response.Code != http.StatusOK {
+		t.Errorf("year long period: response code: expected: %d, actual: %d\n", http.StatusOK, response.Code)
+	}
+}
+
+func TestOptionalAttendees(t *testing.T) {
+	resetStorage()
+
+	userIds := make([]lib.UID, 0, 4)
+	for _, name := range []string{"John Doe", "Vincent Vega", "John McClane", "Rick Sanchez"} {
+		response := createUser(name)
+		var user idResult
+		if err := json.Unmarshal(response.Body.Bytes(), &user); err != nil {
+			t.Fatalf("parse response body: %v", err)
+		}
+		userIds = append(userIds, user.Id)
+	}
+	required, optional := userIds[0], userIds[1:]
+
+	// optional members of the meeting
+	response := createMeeting(meetingParams{creator: required, members: []lib.UID{required}, optional: optional[:1], start: getTime("2023-06-05T09:00:00Z"), duration: getDuration("1h"), period: lib.Once})
+	if expected := http.StatusOK; response.Code != expected {
+		t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+	var id meetingIdResult
+	if err := json.Unmarshal(response.Body.Bytes(), &id); err != nil {
+		t.Fatalf("parse response body: %v", err)
+	}
+	response = patchMeeting(id.Id, required, fmt.Sprintf("add_optional_member_ids=%d,%d", optional[1], required))
+	if expected := http.StatusOK; response.Code != expected {
+		t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+	var meeting lib.Meeting
+	if err := json.Unmarshal(response.Body.Bytes(), &meeting); err != nil {
+		t.Fatalf("parse response body: %v", err)
+	}
+	if expected := fmt.Sprintf("[{%d Unknown Optional} {%d Unknown Optional} {%d Unknown Optional}]", required, optional[0], optional[1]); fmt.Sprint(meeting.Members) != expected {
+		t.Errorf("meeting members: expected: %s, actual: %v\n", expected, meeting.Members)
+	}
+	response = createMeeting(meetingParams{creator: required, members: []lib.UID{required}, optional: []lib.UID{required}, start: getTime("2023-06-05T09:00:00Z"), duration: getDuration("1h"), period: lib.Once})
+	if expected := http.StatusBadRequest; response.Code != expected {
+		t.Errorf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+	if response := deleteMeeting(id.Id, required); response.Code != http.StatusOK {
+		t.Fatalf("response code: expected: %d, actual: %d\n", http.StatusOK, response.Code)
+	}
+
+	// optional users are busy in the morning one after another
+	for i, params := range []meetingParams{
+		{start: getTime("2023-06-05T09:00:00Z"), duration: getDuration("1h")},
+		{start: getTime("2023-06-05T09:00:00Z"), duration: getDuration("2h")},
+		{start: getTime("2023-06-05T10:00:00Z"), duration: getDuration("1h")},
+	} {
+		params.creator, params.members, params.period = optional[i], []lib.UID{optional[i]}, lib.Once
+		if response := createMeeting(params); response.Code != http.StatusOK {
+			t.Fatalf("response code: expected: %d, actual: %d\n", http.StatusOK, response.Code)
+		}
+	}
+
+	ids := fmt.Sprintf("optional_ids=%d,%d,%d", optional[0], optional[1], optional[2])
+	for _, test := range []struct {
+		params   string
+		expected string
+	}{
+		{ids, fmt.Sprintf("[09:00 %v]", optional[:2])},
+		{ids + "&quorum=1", fmt.Sprintf("[09:00 %v]", optional[:2])},
+		{ids + "&quorum=2", "[11:00 []]"},
+		{ids + "&quorum=3", "[11:00 []]"},
+		{ids + "&quorum=1&limit=2", fmt.Sprintf("[09:00 %v 10:00 %v]", optional[:2], optional[1:])},
+		{ids + "&quorum=1&limit=2&rank=score", "[11:00 [] 12:00 []]"},
+	} {
+		response := findFreeSlots(required, "2023-06-05T09:00:00Z", "1h", test.params)
+		if expected := http.StatusOK; response.Code != expected {
+			t.Fatalf("%s: response code: expected: %d, actual: %d\n", test.params, expected, response.Code)
+		}
+		var slots []lib.Slot
+		if err := json.Unmarshal(response.Body.Bytes(), &slots); err != nil {
+			t.Fatalf("parse response body: %v", err)
+		}
+		actual := make([]string, 0, len(slots))
+		for _, slot := range slots {
+			actual = append(actual, slot.StartAt.Format("15:04"), fmt.Sprint(slot.Unavailable))
+		}
+		if fmt.Sprint(actual) != test.expected {
+			t.Errorf("%s: slots: expected: %s, actual: %v", test.params, test.expected, actual)
+		}
+	}
+
+	for _, test := range []struct {
+		params   string
+		expected int
+	}{
+		{ids + "&quorum=4", http.StatusBadRequest},
+		{"quorum=1", http.StatusBadRequest},
+		{"optional_ids=x", http.StatusBadRequest},
+		{fmt.Sprintf("optional_ids=%d", optional[2]+1), http.StatusNotFound},
+	} {
+		response := findFreeSlots(required, "2023-06-05T09:00:00Z", "1h", test.params)
+		if response.Code != test.expected {
+			t.Errorf("%s: response code: expected: %d, actual: %d\n", test.params, test.expected, response.Code)
+		}
+	}
+}
+
+func TestFreeBusy(t *testing.T) {
+	resetStorage()
+
+	users := make([]lib.UID, 0, 2)
+	for _, name := range []string{"John Doe", "Jane Doe"} {
+		response := createUser(name)
+		var user idResult
+		if err := json.Unmarshal(response.Body.Bytes(), &user); err != nil {
+			t.Fatalf("parse response body: %v", err)
+		}
+		users = append(users, user.Id)
+	}
+	john, jane := users[0], users[1]
+
+	ids := make([]lib.MeetingId, 0, 3)
+	for _, params := range []meetingParams{
+		{creator: john, members: []lib.UID{john, jane}, start: getTime("2023-01-02T10:00:00Z"), duration: getDuration("30m"), period: lib.EveryWeek},
+		{creator: john, members: []lib.UID{john}, start: getTime("2023-01-02T10:15:00Z"), duration: getDuration("1h"), period: lib.Once},
+		{creator: john, members: []lib.UID{john, jane}, start: getTime("2023-01-03T09:00:00Z"), duration: getDuration("2h"), period: lib.Once},
+	} {
+		response := createMeeting(params)
+		if expected := http.StatusOK; response.Code != expected {
+			t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+		}
+		var id meetingIdResult
+		if err := json.Unmarshal(response.Body.Bytes(), &id); err != nil {
+			t.Fatalf("parse response body: %v", err)
+		}
+		ids = append(ids, id.Id)
+	}
+	sendPresence(john, ids[0], lib.Accepted)
+	sendPresence(john, ids[1], lib.Accepted)
+	sendPresence(jane, ids[0], lib.Rejected)
+	sendPresence(john, ids[2], lib.Tentative)
+
+	for _, test := range []struct {
+		params   string
+		expected []lib.FreeBusy
+	}{
+		{"", []lib.FreeBusy{
+			{UserId: john, Busy: []lib.BusyInterval{
+				{StartAt: getTime("2023-01-02T10:00:00Z"), EndAt: getTime("2023-01-02T11:15:00Z")},
+				{StartAt: getTime("2023-01-09T10:00:00Z"), EndAt: getTime("2023-01-09T10:30:00Z")},
+			}, Tentative: []lib.BusyInterval{
+				{StartAt: getTime("2023-01-03T09:00:00Z"), EndAt: getTime("2023-01-03T11:00:00Z")},
+			}},
+			{UserId: jane, Busy: []lib.BusyInterval{
+				{StartAt: getTime("2023-01-03T09:00:00Z"), EndAt: getTime("2023-01-03T11:00:00Z")},
+			}, Tentative: []lib.BusyInterval{}},
+		}},
+		{"unknown_presence=tentative", []lib.FreeBusy{
+			{UserId: john, Busy: []lib.BusyInterval{
+				{StartAt: getTime("2023-01-02T10:00:00Z"), EndAt: getTime("2023-01-02T11:15:00Z")},
+				{StartAt: getTime("2023-01-09T10:00:00Z"), EndAt: getTime("2023-01-09T10:30:00Z")},
+			}, Tentative: []lib.BusyInterval{
+				{StartAt: getTime("2023-01-03T09:00:00Z"), EndAt: getTime("2023-01-03T11:00:00Z")},
+			}},
+			{UserId: jane, Busy: []lib.BusyInterval{}, Tentative: []lib.BusyInterval{
+				{StartAt: getTime("2023-01-03T09:00:00Z"), EndAt: getTime("2023-01-03T11:00:00Z")},
+			}},
+		}},
+	} {
+		response := getFreeBusy(fmt.Sprintf("%d,%d", john, jane), "2023-01-02T00:00:00Z", "2023-01-10T00:00:00Z", test.params)
+		if expected := http.StatusOK; response.Code != expected {
+			t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+		}
+		var result []lib.FreeBusy
+		if err := json.Unmarshal(response.Body.Bytes(), &result); err != nil {
+			t.Fatalf("parse response body: %v", err)
+		}
+		if fmt.Sprint(result) != fmt.Sprint(test.expected) {
+			t.Errorf("free busy %q: expected: %v, actual: %v\n", test.params, test.expected, result)
+		}
+	}
+
+	// the busy periods are cut to the requested one
+	response := getFreeBusy(fmt.Sprint(john), "2023-01-02T10:15:00Z", "2023-01-02T11:00:00Z", "")
+	var result []lib.FreeBusy
+	if err := json.Unmarshal(response.Body.Bytes(), &result); err != nil {
+		t.Fatalf("parse response body: %v", err)
+	}
+	expected := []lib.BusyInterval{{StartAt: getTime("2023-01-02T10:15:00Z"), EndAt: getTime("2023-01-02T11:00:00Z")}}
+	if len(result) != 1 || fmt.Sprint(result[0].Busy) != fmt.Sprint(expected) {
+		t.Errorf("free busy: expected: %v, actual: %v\n", expected, result)
+	}
+
+	for _, test := range []struct {
+		ids, start, end, params string
+		expected                int
+	}{
+		{fmt.Sprint(john), "2023-01-02T00:00:00Z", "2023-01-02T00:00:00Z", "", http.StatusBadRequest},
+		{fmt.Sprint(john), "2023-01-02T00:00:00Z", "yesterday", "", http.StatusBadRequest},
+		{fmt.Sprint(john), "2023-01-02T00:00:00Z", "2024-01-04T00:00:00Z", "", http.StatusBadRequest},
+		{fmt.Sprint(john), "2023-01-02T00:00:00Z", "2023-01-03T00:00:00Z", "unknown_presence=free", http.StatusBadRequest},
+		{"john", "2023-01-02T00:00:00Z", "2023-01-03T00:00:00Z", "", http.StatusBadRequest},
+		{fmt.Sprintf("%d,%d", john, jane+1), "2023-01-02T00:00:00Z", "2023-01-03T00:00:00Z", "", http.StatusNotFound},
+	} {
+		response := getFreeBusy(test.ids, test.start, test.end, test.params)
+		if response.Code != test.expected {
+			t.Errorf("response code: expected: %d, actual: %d\n", test.expected, response.Code)
+		}
+	}
+	expectProblem(t, getFreeBusy(fmt.Sprint(john), "2023-01-02T00:00:00Z", "2024-01-04T00:00:00Z", ""), "invalid_parameter", "end_at")
+	if response = getFreeBusy(fmt.Sprint(john), "2023-01-02T00:00:00Z", "2024-01-02T00:00:00Z", ""); response.Code != http.StatusOK {
+		t.Errorf("year long period: response code: expected: %d, actual: %d\n", http.StatusOK, response.Code)
+	}
+}
+
+func TestResources(t *testing.T) {
+	resetStorage()
+
+	users := make([]lib.UID, 0, 3)
+	for _, name := range []string{"John Doe", "Jane Doe", "Richard Roe"} {
+		response := createUser(name)
+		var user idResult
+		if err := json.Unmarshal(response.Body.Bytes(), &user); err != nil {
+			t.Fatalf("parse response body: %v", err)
+		}
+		users = append(users, user.Id)
+	}
+	john, jane, richard := users[0], users[1], users[2]
+
+	resources := make([]lib.ResourceId, 0, 3)
+	for _, params := range []string{"name=Small&capacity=2&attributes=room", "name=Large&capacity=10&attributes=room,projector", "name=Projector&attributes=projector"} {
+		response := createResource(params)
+		if expected := http.StatusOK; response.Code != expected {
+			t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+		}
+		var id resourceIdResult
+		if err := json.Unmarshal(response.Body.Bytes(), &id); err != nil {
+			t.Fatalf("parse response body: %v", err)
+		}
+		resources = append(resources, id.Id)
+	}
+	small, large, projector := resources[0], resources[1], resources[2]
+
+	response := getResources("")
+	var list []lib.Resource
+	if err := json.Unmarshal(response.Body.Bytes(), &list); err != nil {
+		t.Fatalf("parse response body: %v", err)
+	}
+	expected := []lib.Resource{
+		{Id: small, ResourceInfo: lib.ResourceInfo{Name: "Small", Capacity: 2, Attributes: []string{"room"}}},
+		{Id: large, ResourceInfo: lib.ResourceInfo{Name: "Large", Capacity: 10, Attributes: []string{"room", "projector"}}},
+		{Id: projector, ResourceInfo: lib.ResourceInfo{Name: "Projector", Attributes: []string{"projector"}}},
+	}
+	if fmt.Sprint(list) != fmt.Sprint(expected) {
+		t.Errorf("resources: expected: %v, actual: %v\n", expected, list)
+	}
+
+	response = createMeeting(meetingParams{creator: john, members: []lib.UID{john, jane}, start: getTime("2023-07-03T10:00:00Z"), duration: getDuration("1h"), period: lib.EveryWeek, resources: []lib.ResourceId{small}})
+	if expected := http.StatusOK; response.Code != expected {
+		t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+	var weekly meetingIdResult
+	if err := json.Unmarshal(response.Body.Bytes(), &weekly); err != nil {
+		t.Fatalf("parse response body: %v", err)
+	}
+
+	for _, test := range []struct {
+		params   meetingParams
+		expected int
+	}{
+		// the second occurrence of the weekly meeting books the room
+		{meetingParams{creator: richard, members: []lib.UID{richard}, start: getTime("2023-07-10T10:30:00Z"), duration: getDuration("1h"), period: lib.Once, resources: []lib.ResourceId{small}}, http.StatusConflict},
+		{meetingParams{creator: richard, members: []lib.UID{richard}, start: getTime("2023-06-26T10:30:00Z"), duration: getDuration("1h"), period: lib.Once, resources: []lib.ResourceId{small}}, http.StatusOK},
+		{meetingParams{creator: richard, members: []lib.UID{john, jane, richard}, start: getTime("2023-07-04T10:00:00Z"), duration: getDuration("1h"), period: lib.Once, resources: []lib.ResourceId{small}}, http.StatusBadRequest},
+		{meetingParams{creator: richard, members: []lib.UID{richard}, start: getTime("2023-07-04T10:00:00Z"), duration: getDuration("1h"), period: lib.Once, resources: []lib.ResourceId{projector + 1}}, http.StatusNotFound},
+	} {
+		response := createMeeting(test.params)
+		if response.Code != test.expected {
+			t.Errorf("response code: expected: %d, actual: %d\n", test.expected, response.Code)
+		}
+	}
+
+	response = createMeeting(meetingParams{creator: richard, members: []lib.UID{richard}, start: getTime("2023-07-10T10:30:00Z"), duration: getDuration("1h"), period: lib.Once, resources: []lib.ResourceId{large}})
+	var once meetingIdResult
+	if err := json.Unmarshal(response.Body.Bytes(), &once); err != nil {
+		t.Fatalf("parse response body: %v", err)
+	}
+
+	for _, test := range []struct {
+		params   string
+		expected int
+	}{
+		{fmt.Sprintf("add_resource_ids=%d", small), http.StatusConflict},
+		{fmt.Sprintf("remove_resource_ids=%d", projector), http.StatusNotFound},
+		{fmt.Sprintf("add_resource_ids=%d", projector+1), http.StatusNotFound},
+		{fmt.Sprintf("start_at=2023-07-10T12:00:00Z&add_resource_ids=%d", small), http.StatusOK},
+	} {
+		response := patchMeeting(once.Id, richard, test.params)
+		if response.Code != test.expected {
+			t.Errorf("patch %q: response code: expected: %d, actual: %d\n", test.params, test.expected, response.Code)
+		}
+	}
+	response = getMeeting(once.Id)
+	var meeting lib.Meeting
+	if err := json.Unmarshal(response.Body.Bytes(), &meeting); err != nil {
+		t.Fatalf("parse response body: %v", err)
+	}
+	if expected := []lib.ResourceId{large, small}; fmt.Sprint(meeting.Resources) != fmt.Sprint(expected) {
+		t.Errorf("meeting resources: expected: %v, actual: %v\n", expected, meeting.Resources)
+	}
+
+	// the moved occurrence can't take the booked room
+	response = putException(weekly.Id, john, "occurrence=2023-07-10T10:00:00Z&start_at=2023-07-10T12:30:00Z")
+	if expected := http.StatusConflict; response.Code != expected {
+		t.Errorf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+
+	for _, test := range []struct {
+		id       lib.ResourceId
+		expected int
+	}{
+		{small, http.StatusConflict},
+		{projector, http.StatusOK},
+		{projector, http.StatusNotFound},
+	} {
+		response := deleteResource(test.id)
+		if response.Code != test.expected {
+			t.Errorf("delete resource %d: response code: expected: %d, actual: %d\n", test.id, test.expected, response.Code)
+		}
+	}
+
+	response = putResource(fmt.Sprintf("id=%d&capacity=3&attributes=", large))
+	var res lib.Resource
+	if err := json.Unmarshal(response.Body.Bytes(), &res); err != nil {
+		t.Fatalf("parse response body: %v", err)
+	}
+	if expected := (lib.Resource{Id: large, ResourceInfo: lib.ResourceInfo{Name: "Large", Capacity: 3}}); fmt.Sprint(res) != fmt.Sprint(expected) {
+		t.Errorf("resource: expected: %v, actual: %v\n", expected, res)
+	}
+
+	// the resource of the cancelled meeting is released
+	deleteMeeting(once.Id, richard)
+	if response := deleteResource(large); response.Code != http.StatusOK {
+		t.Errorf("response code: expected: %d, actual: %d\n", http.StatusOK, response.Code)
+	}
+}
+
+func TestBookingOfLongSeries(t *testing.T) {
+	storage := lib.NewMemoryStorage()
+	user, _ := storage.UserAdd(lib.UserInfo{Name: "John Doe"})
+	members := []lib.Participant{{UserId: user, Status: lib.Unknown}}
+	hour := lib.Duration{Duration: getDuration("1h")}
+	// 10:00 in a week, the series below take place at the same time
+	nextWeek := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 7).Add(10 * time.Hour)
+
+	// the series started two years ago
+	room, _ := storage.ResourceAdd(lib.ResourceInfo{Name: "Room"})
+	if _, err := storage.MeetingAdd(lib.MeetingInfo{Members: members, CreatorId: user, FirstOccurence: nextWeek, Duration: hour, Resources: []lib.ResourceId{room}}); err != nil {
+		t.Fatalf("add meeting: %v", err)
+	}
+	old := lib.MeetingInfo{Members: members, CreatorId: user, FirstOccurence: nextWeek.AddDate(0, 0, -7*105), Duration: hour, Repeat: lib.EveryWeek, Resources: []lib.ResourceId{room}}
+	if _, err := storage.MeetingAdd(old); !errors.Is(err, lib.ErrConflict) {
+		t.Errorf("old series: expected: %v, actual: %v", lib.ErrConflict, err)
+	}
+	old.FirstOccurence = old.FirstOccurence.Add(2 * time.Hour)
+	if _, err := storage.MeetingAdd(old); err != nil {
+		t.Errorf("old series at free time: %v", err)
+	}
+
+	// the room is booked two years ahead
+	hall, _ := storage.ResourceAdd(lib.ResourceInfo{Name: "Hall"})
+	later := nextWeek.AddDate(0, 0, 7*104)
+	if _, err := storage.MeetingAdd(lib.MeetingInfo{Members: members, CreatorId: user, FirstOccurence: later, Duration: hour, Resources: []lib.ResourceId{hall}}); err != nil {
+		t.Fatalf("add meeting: %v", err)
+	}
+	if _, err := storage.MeetingAdd(lib.MeetingInfo{Members: members, CreatorId: user, FirstOccurence: nextWeek, Duration: hour, Repeat: lib.EveryDay, Resources: []lib.ResourceId{hall}}); !errors.Is(err, lib.ErrConflict) {
+		t.Errorf("daily series: expected: %v, actual: %v", lib.ErrConflict, err)
+	}
+	if meets, _ := storage.ResourceMeetingsBetween(hall, later, later.Add(time.Hour)); len(meets) != 1 {
+		t.Errorf("hall meetings at %v: expected: 1, actual: %d", later, len(meets))
+	}
+	// the series ending before the booking doesn't hold the room then
+	rule, _ := lib.ParseRecurrence("FREQ=DAILY;COUNT=30")
+	if _, err := storage.MeetingAdd(lib.MeetingInfo{Members: members, CreatorId: user, FirstOccurence: nextWeek, Duration: hour, Repeat: lib.Custom, Rule: &rule, Resources: []lib.ResourceId{hall}}); err != nil {
+		t.Errorf("series ending earlier: %v", err)
+	}
+}
+
+func TestFindFreeTimeWithResources(t *testing.T) {
+	resetStorage()
+
+	users := make([]lib.UID, 0, 3)
+	for _, name := range []string{"John Doe", "Jane Doe", "Richard Roe"} {
+		response := createUser(name)
+		var user idResult
+		if err := json.Unmarshal(response.Body.Bytes(), &user); err != nil {
+			t.Fatalf("parse response body: %v", err)
+		}
+		users = append(users, user.Id)
+	}
+	john, jane, richard := users[0], users[1], users[2]
+
+	resources := make([]lib.ResourceId, 0, 2)
+	for _, params := range []string{"name=Small&capacity=2&attributes=room", "name=Large&capacity=8&attributes=room,projector"} {
+		response := createResource(params)
+		var id resourceIdResult
+		if err := json.Unmarshal(response.Body.Bytes(), &id); err != nil {
+			t.Fatalf("parse response body: %v", err)
+		}
+		resources = append(resources, id.Id)
+	}
+	small, large := resources[0], resources[1]
+
+	for _, params := range []meetingParams{
+		{creator: richard, members: []lib.UID{richard}, start: getTime("2023-08-01T09:00:00Z"), duration: getDuration("1h"), period: lib.Once, resources: []lib.ResourceId{small}},
+		{creator: richard, members: []lib.UID{richard}, start: getTime("2023-08-01T09:00:00Z"), duration: getDuration("2h"), period: lib.Once, resources: []lib.ResourceId{large}},
+	} {
+		if response := createMeeting(params); response.Code != http.StatusOK {
+			t.Fatalf("response code: expected: %d, actual: %d\n", http.StatusOK, response.Code)
+		}
+	}
+
+	ids := fmt.Sprintf("%d,%d", john, jane)
+	for _, test := range []struct {
+		params   string
+		expected []lib.Slot
+	}{
+		{"resource_attributes=room", []lib.Slot{{StartAt: getTime("2023-08-01T10:00:00Z"), EndAt: getTime("2023-08-01T11:00:00Z"), ResourceId: small}}},
+		{"resource_attributes=projector", []lib.Slot{{StartAt: getTime("2023-08-01T11:00:00Z"), EndAt: getTime("2023-08-01T12:00:00Z"), ResourceId: large}}},
+		{"resource_capacity=3&resource_attributes=room", []lib.Slot{{StartAt: getTime("2023-08-01T11:00:00Z"), EndAt: getTime("2023-08-01T12:00:00Z"), ResourceId: large}}},
+		{"resource_attributes=catering", []lib.Slot{}},
+		{fmt.Sprintf("resource_ids=%d&limit=1", small), []lib.Slot{{StartAt: getTime("2023-08-01T10:00:00Z"), EndAt: getTime("2023-08-01T11:00:00Z")}}},
+	} {
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/find_free_time?id=%s&start_at=2023-08-01T09:00:00Z&duration=1h&%s", ids, test.params), nil)
+		response := executeRequest(req)
+		if expected := http.StatusOK; response.Code != expected {
+			t.Fatalf("%q: response code: expected: %d, actual: %d\n", test.params, expected, response.Code)
+		}
+		var slots []lib.Slot
+		if err := json.Unmarshal(response.Body.Bytes(), &slots); err != nil {
+			t.Fatalf("parse response body: %v", err)
+		}
+		if fmt.Sprint(slots) != fmt.Sprint(test.expected) {
+			t.Errorf("%q: slots: expected: %v, actual: %v\n", test.params, test.expected, slots)
+		}
+	}
+
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/find_free_time?id=%s&start_at=2023-08-01T09:00:00Z&duration=1h&resource_ids=%d", ids, large+1), nil)
+	if response := executeRequest(req); response.Code != http.StatusNotFound {
+		t.Errorf("response code: expected: %d, actual: %d\n", http.StatusNotFound, response.Code)
+	}
+}
+
+func TestConflictPolicy(t *testing.T) {
+	resetStorage()
+
+	users := make([]lib.UID, 0, 3)
+	for _, name := range []string{"John Doe", "Jane Doe", "Richard Roe"} {
+		response := createUser(name)
+		var user idResult
+		if err := json.Unmarshal(response.Body.Bytes(), &user); err != nil {
+			t.Fatalf("parse response body: %v", err)
+		}
+		users = append(users, user.Id)
+	}
+	john, jane, richard := users[0], users[1], users[2]
+
+	ids := make([]lib.MeetingId, 0, 3)
+	for _, params := range []meetingParams{
+		{creator: john, members: []lib.UID{john, jane}, start: getTime("2023-09-04T10:00:00Z"), duration: getDuration("1h"), period: lib.EveryWeek},
+		{creator: richard, members: []lib.UID{richard}, start: getTime("2023-09-11T11:00:00Z"), duration: getDuration("30m"), period: lib.Custom, rule: "FREQ=DAILY;COUNT=3"},
+		{creator: john, members: []lib.UID{john, richard}, start: getTime("2023-09-18T11:00:00Z"), duration: getDuration("1h"), period: lib.Once},
+	} {
+		response := createMeeting(params)
+		if expected := http.StatusOK; response.Code != expected {
+			t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+		}
+		var id meetingIdResult
+		if err := json.Unmarshal(response.Body.Bytes(), &id); err != nil {
+			t.Fatalf("parse response body: %v", err)
+		}
+		ids = append(ids, id.Id)
+	}
+	weekly, daily, rejected := ids[0], ids[1], ids[2]
+	// rejected meetings don't block the time
+	sendPresence(richard, rejected, lib.Rejected)
+
+	params := meetingParams{creator: jane, members: []lib.UID{jane, richard}, start: getTime("2023-09-11T10:30:00Z"), duration: getDuration("1h"), period: lib.Custom, rule: "FREQ=WEEKLY;COUNT=2"}
+	expected := []lib.Conflict{
+		{UserId: jane, MeetingId: weekly, Occurrence: getTime("2023-09-11T10:30:00Z"), StartAt: getTime("2023-09-11T10:00:00Z"), EndAt: getTime("2023-09-11T11:00:00Z")},
+		{UserId: richard, MeetingId: daily, Occurrence: getTime("2023-09-11T10:30:00Z"), StartAt: getTime("2023-09-11T11:00:00Z"), EndAt: getTime("2023-09-11T11:30:00Z")},
+		{UserId: jane, MeetingId: weekly, Occurrence: getTime("2023-09-18T10:30:00Z"), StartAt: getTime("2023-09-18T10:00:00Z"), EndAt: getTime("2023-09-18T11:00:00Z")},
+	}
+
+	params.policy = "reject"
+	response := createMeeting(params)
+	if expected := http.StatusConflict; response.Code != expected {
+		t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+	conflicts := expectProblem(t, response, "conflict", "conflict_policy").Conflicts
+	if fmt.Sprint(conflicts) != fmt.Sprint(expected) {
+		t.Errorf("conflicts: expected: %v, actual: %v\n", expected, conflicts)
+	}
+	var meets []lib.Meeting
+	if err := json.Unmarshal(getMeetingList().Body.Bytes(), &meets); err != nil {
+		t.Fatalf("parse response body: %v", err)
+	}
+	if len(meets) != len(ids) {
+		t.Errorf("rejected meeting is stored: %v", meets)
+	}
+
+	params.policy = "warn"
+	response = createMeeting(params)
+	if expected := http.StatusOK; response.Code != expected {
+		t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+	var created struct {
+		Id        lib.MeetingId
+		Conflicts []lib.Conflict
+	}
+	if err := json.Unmarshal(response.Body.Bytes(), &created); err != nil {
+		t.Fatalf("parse response body: %v", err)
+	}
+	if fmt.Sprint(created.Conflicts) != fmt.Sprint(expected) {
+		t.Errorf("conflicts: expected: %v, actual: %v\n", expected, created.Conflicts)
+	}
+
+	for _, test := range []struct {
+		policy   string
+		expected int
+	}{
+		{"", http.StatusOK},
+		{"allow", http.StatusOK},
+		{"ignore", http.StatusBadRequest},
+	} {
+		params.policy = test.policy
+		response := createMeeting(params)
+		if response.Code != test.expected {
+			t.Errorf("policy %q: response code: expected: %d, actual: %d\n", test.policy, test.expected, response.Code)
+		}
+		if strings.Contains(response.Body.String(), "Conflicts") {
+			t.Errorf("policy %q: unexpected conflicts: %s", test.policy, response.Body.String())
+		}
+		var id meetingIdResult
+		if json.Unmarshal(response.Body.Bytes(), &id) == nil {
+			deleteMeeting(id.Id, jane)
+		}
+	}
+
+	for _, test := range []struct {
+		params    string
+		expected  int
+		conflicts int
+	}{
+		{"start_at=2023-09-11T12:00:00Z&conflict_policy=reject", http.StatusOK, 0},
+		{"start_at=2023-09-11T10:45:00Z&conflict_policy=reject", http.StatusConflict, 3},
+		// the second occurrence overlaps the meeting rejected by richard only
+		{fmt.Sprintf("start_at=2023-09-11T11:30:00Z&add_member_ids=%d&conflict_policy=warn", john), http.StatusOK, 1},
+	} {
+		response := patchMeeting(created.Id, jane, test.params)
+		if response.Code != test.expected {
+			t.Errorf("patch %q: response code: expected: %d, actual: %d\n", test.params, test.expected, response.Code)
+		}
+		var changed struct {
+			lib.Meeting
+			Conflicts []lib.Conflict
+		}
+		// both the meeting and the problem list the conflicts
+		json.Unmarshal(response.Body.Bytes(), &changed)
+		if len(changed.Conflicts) != test.conflicts {
+			t.Errorf("patch %q: conflicts: expected %d, actual: %v\n", test.params, test.conflicts, changed.Conflicts)
+		}
+	}
+	var meeting lib.Meeting
+	if err := json.Unmarshal(getMeeting(created.Id).Body.Bytes(), &meeting); err != nil {
+		t.Fatalf("parse response body: %v", err)
+	}
+	if expected := getTime("2023-09-11T11:30:00Z"); !meeting.FirstOccurence.Equal(expected) {
+		t.Errorf("meeting start: expected: %v, actual: %v\n", expected, meeting.FirstOccurence)
+	}
+}
+
+func TestConflictsOfOldSeries(t *testing.T) {
+	resetStorage()
+
+	var john idResult
+	if err := json.Unmarshal(createUser("John Doe").Body.Bytes(), &john); err != nil {
+		t.Fatalf("parse response body: %v", err)
+	}
+	nextWeek := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 7)
+	ids := make([]lib.MeetingId, 0, 2)
+	for _, params := range []meetingParams{
+		{creator: john.Id, members: []lib.UID{john.Id}, start: nextWeek.Add(15 * time.Hour), duration: getDuration("1h"), period: lib.Once},
+		// the series started two years ago
+		{creator: john.Id, members: []lib.UID{john.Id}, start: nextWeek.AddDate(0, 0, -7*105).Add(10 * time.Hour), duration: getDuration("1h"), period: lib.EveryWeek},
+	} {
+		response := createMeeting(params)
+		if expected := http.StatusOK; response.Code != expected {
+			t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+		}
+		var id meetingIdResult
+		if err := json.Unmarshal(response.Body.Bytes(), &id); err != nil {
+			t.Fatalf("parse response body: %v", err)
+		}
+		ids = append(ids, id.Id)
+	}
+	once, weekly := ids[0], ids[1]
+
+	start := nextWeek.AddDate(0, 0, -7*105).Add(15 * time.Hour)
+	response := patchMeeting(weekly, john.Id, fmt.Sprintf("start_at=%s&conflict_policy=reject", start.Format(time.RFC3339)))
+	if expected := http.StatusConflict; response.Code != expected {
+		t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+	conflicts := expectProblem(t, response, "conflict", "conflict_policy").Conflicts
+	expected := []lib.Conflict{{UserId: john.Id, MeetingId: once, Occurrence: nextWeek.Add(15 * time.Hour), StartAt: nextWeek.Add(15 * time.Hour), EndAt: nextWeek.Add(16 * time.Hour)}}
+	if fmt.Sprint(conflicts) != fmt.Sprint(expected) {
+		t.Errorf("conflicts: expected: %v, actual: %v\n", expected, conflicts)
+	}
+}
+
+func TestJsonRequests(t *testing.T) {
+	resetStorage()
+
+	response := sendJson("POST", "/user", `{"Name": "John", "Email": "john@example.com"}`)
+	if expected := http.StatusOK; response.Code != expected {
+		t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+	var john idResult
+	if err := json.Unmarshal(response.Body.Bytes(), &john); err != nil {
+		t.Fatalf("parse response body: %v", err)
+	}
+	var usr lib.User
+	if err := json.Unmarshal(getUser(john.Id).Body.Bytes(), &usr); err != nil {
+		t.Fatalf("parse response body: %v", err)
+	}
+	if usr.Name != "John" || usr.Email != "john@example.com" {
+		t.Errorf("user: unexpected: %+v\n", usr)
+	}
+	var jane idResult
+	json.Unmarshal(createUser("Jane").Body.Bytes(), &jane)
+
+	meeting := fmt.Sprintf(`{
+		"CreatorId": %d,
+		"Members": [{"UserId": %d}, {"UserId": %d, "Role": "Optional"}],
+		"FirstOccurence": "2023-09-11T10:00:00Z",
+		"Duration": "1h",
+		"Rule": "FREQ=WEEKLY;COUNT=3",
+		"TimeZone": "Europe/Berlin"
+	}`, john.Id, john.Id, jane.Id)
+	response = sendJson("POST", "/meeting", meeting)
+	if expected := http.StatusOK; response.Code != expected {
+		t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+	var created meetingIdResult
+	if err := json.Unmarshal(response.Body.Bytes(), &created); err != nil {
+		t.Fatalf("parse response body: %v", err)
+	}
+	var meet lib.Meeting
+	if err := json.Unmarshal(getMeeting(created.Id).Body.Bytes(), &meet); err != nil {
+		t.Fatalf("parse response body: %v", err)
+	}
+	expected := []lib.Participant{{UserId: john.Id, Role: lib.Required}, {UserId: jane.Id, Role: lib.Optional}}
+	if fmt.Sprint(meet.Members) != fmt.Sprint(expected) {
+		t.Errorf("members: expected: %v, actual: %v\n", expected, meet.Members)
+	}
+	if meet.Repeat != lib.Custom || meet.Rule == nil || meet.TimeZone != "Europe/Berlin" || !meet.FirstOccurence.Equal(getTime("2023-09-11T10:00:00Z")) {
+		t.Errorf("meeting: unexpected: %+v\n", meet)
+	}
+
+	response = sendJson("PUT", "/response", fmt.Sprintf(`{"UserId": %d, "MeetingId": %d, "Status": "Accepted"}`, jane.Id, created.Id))
+	if expected := http.StatusOK; response.Code != expected {
+		t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+	json.Unmarshal(getMeeting(created.Id).Body.Bytes(), &meet)
+	if meet.Members[1].Status != lib.Accepted {
+		t.Errorf("presence: expected: %v, actual: %v\n", lib.Accepted, meet.Members[1].Status)
+	}
+
+	// the same validation as for the form with the JSON field names reported
+	for _, test := range []struct {
+		target, body string
+		status       int
+		code, param  string
+	}{
+		{"/user", `{"Email": "jack@example.com"}`, http.StatusBadRequest, "invalid_parameter", "Name"},
+		{"/user", `{"Name": "Jack", "Deactivated": true}`, http.StatusBadRequest, "invalid_parameter", ""},
+		{"/user", `{"Name": 5}`, http.StatusBadRequest, "invalid_parameter", "Name"},
+		{"/user", `{"Name": "Jack"`, http.StatusBadRequest, "invalid_parameter", ""},
+		{"/meeting", fmt.Sprintf(`{"CreatorId": %d, "Members": [{"UserId": %d}], "FirstOccurence": "2023-09-11", "Duration": "1h"}`, john.Id, john.Id), http.StatusBadRequest, "invalid_parameter", "FirstOccurence"},
+		{"/meeting", fmt.Sprintf(`{"CreatorId": %d, "Members": [{"UserId": %d, "Role": "Optional"}], "FirstOccurence": "2023-09-11T10:00:00Z", "Duration": "1h"}`, john.Id, john.Id), http.StatusBadRequest, "invalid_parameter", "Members"},
+		{"/meeting", fmt.Sprintf(`{"CreatorId": %d, "Members": [{"UserId": %d, "Status": "Accepted"}], "FirstOccurence": "2023-09-11T10:00:00Z", "Duration": "1h"}`, john.Id, john.Id), http.StatusBadRequest, "invalid_parameter", "Members"},
+		{"/meeting", fmt.Sprintf(`{"CreatorId": %d, "Members": [{"UserId": 100}], "FirstOccurence": "2023-09-11T10:00:00Z", "Duration": "1h"}`, john.Id), http.StatusNotFound, "not_found", ""},
+		{"/meeting?conflict_policy=never", fmt.Sprintf(`{"CreatorId": %d, "Members": [{"UserId": %d}], "FirstOccurence": "2023-09-11T10:00:00Z", "Duration": "1h"}`, john.Id, john.Id), http.StatusBadRequest, "invalid_parameter", "conflict_policy"},
+	} {
+		response := sendJson("POST", test.target, test.body)
+		if response.Code != test.status {
+			t.Errorf("%s %s: response code: expected: %d, actual: %d\n", test.target, test.body, test.status, response.Code)
+			continue
+		}
+		expectProblem(t, response, test.code, test.param)
+	}
+}
+
+func TestCalendar(t *testing.T) {
+	resetStorage()
+	var john, jane idResult
+	json.Unmarshal(createUser("John").Body.Bytes(), &john)
+	json.Unmarshal(createUser("Jane").Body.Bytes(), &jane)
+	updateUser(jane.Id, "email=jane@example.com")
+
+	var weekly, once, cancelled meetingIdResult
+	json.Unmarshal(createMeeting(meetingParams{creator: jane.Id, members: []lib.UID{jane.Id, john.Id}, start: getTime("2023-03-20T09:00:00Z"), duration: getDuration("1h"), period: lib.EveryWeek, timeZone: "Europe/Berlin"}).Body.Bytes(), &weekly)
+	json.Unmarshal(createMeeting(meetingParams{creator: john.Id, members: []lib.UID{john.Id}, optional: []lib.UID{jane.Id}, start: getTime("2023-03-21T12:00:00Z"), duration: getDuration("30m"), period: lib.Once}).Body.Bytes(), &once)
+	json.Unmarshal(createMeeting(meetingParams{creator: jane.Id, members: []lib.UID{jane.Id, john.Id}, start: getTime("2023-03-22T12:00:00Z"), duration: getDuration("30m"), period: lib.Once}).Body.Bytes(), &cancelled)
+	putException(weekly.Id, jane.Id, "occurrence=2023-03-27T08:00:00Z&cancelled=true")
+	putException(weekly.Id, jane.Id, "occurrence=2023-04-03T08:00:00Z&start_at=2023-04-03T12:00:00Z&duration=2h")
+	sendPresence(john.Id, weekly.Id, lib.Accepted)
+	sendPresence(jane.Id, once.Id, lib.Tentative)
+	deleteMeeting(cancelled.Id, jane.Id)
+
+	response := getCalendar(john.Id, "")
+	if expected := http.StatusOK; response.Code != expected {
+		t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+	if expected := "text/calendar; charset=utf-8"; response.Header().Get("Content-Type") != expected {
+		t.Errorf("content type: expected: %q, actual: %q\n", expected, response.Header().Get("Content-Type"))
+	}
+	body := strings.ReplaceAll(response.Body.String(), "\r\n ", "")
+	for _, line := range []string{
+		"BEGIN:VCALENDAR",
+		"X-WR-CALNAME:John",
+		"TZID:Europe/Berlin",
+		"DTSTART:20230326T020000",
+		"TZOFFSETTO:+0200",
+		fmt.Sprintf("UID:meeting-%d@schedule", weekly.Id),
+		"ORGANIZER;CN=Jane:mailto:jane@example.com",
+		fmt.Sprintf("ATTENDEE;ROLE=REQ-PARTICIPANT;PARTSTAT=ACCEPTED;CN=John:urn:schedule:user:%d", john.Id),
+		"ATTENDEE;ROLE=REQ-PARTICIPANT;PARTSTAT=NEEDS-ACTION;CN=Jane:mailto:jane@example.com",
+		"DTSTART;TZID=Europe/Berlin:20230320T100000",
+		"DTEND;TZID=Europe/Berlin:20230320T110000",
+		"RRULE:FREQ=WEEKLY",
+		"EXDATE;TZID=Europe/Berlin:20230327T100000",
+		"RECURRENCE-ID;TZID=Europe/Berlin:20230403T100000",
+		"DTSTART;TZID=Europe/Berlin:20230403T140000",
+		fmt.Sprintf("UID:meeting-%d@schedule", once.Id),
+		"DTSTART:20230321T120000Z",
+		"ATTENDEE;ROLE=OPT-PARTICIPANT;PARTSTAT=TENTATIVE;CN=Jane:mailto:jane@example.com",
+		fmt.Sprintf("UID:meeting-%d@schedule", cancelled.Id),
+		"STATUS:CANCELLED",
+		"END:VCALENDAR",
+	} {
+		if !strings.Contains(body, "\r\n"+line+"\r\n") && !strings.HasPrefix(body, line+"\r\n") {
+			t.Errorf("line %q not found in:\n%s", line, body)
+		}
+	}
+	if expected := 4; strings.Count(body, "BEGIN:VEVENT") != expected {
+		t.Errorf("events: expected: %d, actual: %d\n", expected, strings.Count(body, "BEGIN:VEVENT"))
+	}
+
+	// the feed is stable until the meetings change
+	etag := response.Header().Get("ETag")
+	if etag == "" {
+		t.Fatalf("no ETag")
+	}
+	if response := getCalendar(john.Id, etag); response.Code != http.StatusNotModified {
+		t.Errorf("response code: expected: %d, actual: %d\n", http.StatusNotModified, response.Code)
+	}
+	sendPresence(john.Id, weekly.Id, lib.Rejected)
+	response = getCalendar(john.Id, etag)
+	if expected := http.StatusOK; response.Code != expected {
+		t.Errorf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+	if !strings.Contains(strings.ReplaceAll(response.Body.String(), "\r\n ", ""), "PARTSTAT=DECLINED;CN=John") {
+		t.Errorf("changed response is not in the feed:\n%s", response.Body.String())
+	}
+
+	if response := getCalendar(100, ""); response.Code != http.StatusNotFound {
+		t.Errorf("response code: expected: %d, actual: %d\n", http.StatusNotFound, response.Code)
+	}
+}
+
+func TestImportCalendar(t *testing.T) {
+	resetStorage()
+	var john, jane idResult
+	json.Unmarshal(createUser("John").Body.Bytes(), &john)
+	json.Unmarshal(createUser("Jane").Body.Bytes(), &jane)
+	updateUser(jane.Id, "email=jane@example.com")
+	var room struct{ Id lib.ResourceId }
+	json.Unmarshal(createResource("name=Blue room&capacity=4").Body.Bytes(), &room)
+
+	ics := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//Example//EN",
+		"BEGIN:VEVENT",
+		"UID:weekly@example.com",
+		"SUMMARY:Weekly\\, sync",
+		"DTSTART;TZID=Europe/Berlin:20230320T100000",
+		"DTEND;TZID=Europe/Berlin:20230320T110000",
+		"RRULE:FREQ=WEEKLY;BYDAY=MO",
+		"EXDATE;TZID=Europe/Berlin:20230327T100000",
+		"ORGANIZER;CN=Jane Doe:mailto:jane@example.com",
+		"ATTENDEE;CN=John;PARTSTAT=ACCEPTED:mailto:john@example.com",
+		"ATTENDEE;CN=\"Doe, Jane\";ROLE=OPT-PARTICIPANT:mailto:JANE@example.com",
+		"ATTENDEE;CN=Bob:mailto:bob@example.com",
+		"ATTENDEE;CUTYPE=ROOM;CN=Blue room:mailto:blue@example.com",
+		"BEGIN:VALARM",
+		"ACTION:DISPLAY",
+		"TRIGGER:-PT15M",
+		"END:VALARM",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:weekly@example.com",
+		"RECURRENCE-ID;TZID=Europe/Berlin:20230403T100000",
+		"DTSTART;TZID=Europe/Berlin:20230403T140000",
+		"DURATION:PT2H",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:biweekly@example.com",
+		"DTSTART:20230321T120000Z",
+		"DURATION:PT30M",
+		"RRULE:FREQ=WEEKLY;INTERVAL=2",
+		"ATTENDEE;CN=john:urn:x",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:holiday@example.com",
+		"DTSTART;VALUE=DATE:20230401",
+		"ATTENDEE;CN=John:urn:x",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:rdate@example.com",
+		"DTSTART:20230321T120000Z",
+		"DURATION:PT30M",
+		"RDATE:20230322T120000Z",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:strangers@example.com",
+		"DTSTART:20230321T120000Z",
+		"DURATION:PT30M",
+		"ATTENDEE;CN=Bob:mailto:bob@example.com",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+	// long lines are folded
+	ics = strings.Replace(ics, "RRULE:FREQ=WEEKLY;BYDAY=MO", "RRULE:FREQ=WEEKLY;\r\n BYDAY=MO", 1)
+
+	response := importCalendar(john.Id, ics)
+	if expected := http.StatusOK; response.Code != expected {
+		t.Fatalf("response code: expected: %d, actual: %d\n%s", expected, response.Code, response.Body.String())
+	}
+	var result lib.ImportResult
+	if err := json.Unmarshal(response.Body.Bytes(), &result); err != nil {
+		t.Fatalf("parse response body: %v", err)
+	}
+	if len(result.Imported) != 2 || result.Imported[0].Uid != "weekly@example.com" || result.Imported[1].Uid != "biweekly@example.com" {
+		t.Fatalf("imported: %+v", result.Imported)
+	}
+	if expected := "Weekly, sync"; result.Imported[0].Summary != expected {
+		t.Errorf("summary: expected: %q, actual: %q", expected, result.Imported[0].Summary)
+	}
+	if expected := []string{`attendee "Bob" doesn't match any user`}; fmt.Sprint(result.Imported[0].Warnings) != fmt.Sprint(expected) {
+		t.Errorf("warnings: expected: %q, actual: %q", expected, result.Imported[0].Warnings)
+	}
+	skipped := make(map[string]string)
+	for _, e := range result.Skipped {
+		skipped[e.Uid] = e.Reason
+	}
+	for uid, reason := range map[string]string{
+		"holiday@example.com":   "DTSTART: all-day events are not supported",
+		"rdate@example.com":     "RDATE is not supported",
+		"strangers@example.com": "none of the attendees matches a user",
+	} {
+		if skipped[uid] != reason {
+			t.Errorf("%s: expected: %q, actual: %q", uid, reason, skipped[uid])
+		}
+	}
+	if len(result.Skipped) != 3 {
+		t.Errorf("skipped: %+v", result.Skipped)
+	}
+
+	var weekly lib.Meeting
+	json.Unmarshal(getMeeting(result.Imported[0].MeetingId).Body.Bytes(), &weekly)
+	if weekly.CreatorId != jane.Id || weekly.Repeat != lib.EveryWeek || weekly.TimeZone != "Europe/Berlin" {
+		t.Errorf("meeting: %+v", weekly)
+	}
+	if expected := getTime("2023-03-20T09:00:00Z"); !weekly.FirstOccurence.Equal(expected) || weekly.Duration.Duration != time.Hour {
+		t.Errorf("meeting time: %v %v", weekly.FirstOccurence, weekly.Duration)
+	}
+	expectedMembers := []lib.Participant{{UserId: john.Id, Status: lib.Accepted}, {UserId: jane.Id, Role: lib.Optional}}
+	if fmt.Sprint(weekly.Members) != fmt.Sprint(expectedMembers) {
+		t.Errorf("members: expected: %v, actual: %v", expectedMembers, weekly.Members)
+	}
+	if fmt.Sprint(weekly.Resources) != fmt.Sprint([]lib.ResourceId{room.Id}) {
+		t.Errorf("resources: %v", weekly.Resources)
+	}
+	if len(weekly.ExDates) != 1 || !weekly.ExDates[0].Equal(getTime("2023-03-27T08:00:00Z")) {
+		t.Errorf("exdates: %v", weekly.ExDates)
+	}
+	expectedOverride := lib.Override{RecurrenceId: getTime("2023-04-03T08:00:00Z"), StartAt: getTime("2023-04-03T12:00:00Z"), Duration: lib.Duration{Duration: 2 * time.Hour}}
+	if len(weekly.Overrides) != 1 || fmt.Sprint(weekly.Overrides[0]) != fmt.Sprint(expectedOverride) {
+		t.Errorf("overrides: expected: %v, actual: %v", expectedOverride, weekly.Overrides)
+	}
+
+	var biweekly lib.Meeting
+	json.Unmarshal(getMeeting(result.Imported[1].MeetingId).Body.Bytes(), &biweekly)
+	if biweekly.CreatorId != john.Id || biweekly.Repeat != lib.Custom || biweekly.Rule == nil || biweekly.Rule.String() != "FREQ=WEEKLY;INTERVAL=2" {
+		t.Errorf("meeting: %+v", biweekly)
+	}
+
+	// the organizer must match a user if the creator is not given
+	response = importCalendar(0, strings.Replace(ics, "ORGANIZER;CN=Jane Doe:mailto:jane@example.com", "ORGANIZER:mailto:bob@example.com", 1))
+	json.Unmarshal(response.Body.Bytes(), &result)
+	if len(result.Imported) != 0 {
+		t.Errorf("imported without creator: %+v", result.Imported)
+	}
+
+	response = importCalendar(john.Id, "BEGIN:VEVENT\r\nEND:VEVENT\r\n")
+	expectProblem(t, response, "invalid_parameter", "")
+	response = importCalendar(100, ics)
+	expectProblem(t, response, "not_found", "")
+	// the import command checks the creator the same way
+	if _, err := lib.NewService(lib.NewMemoryStorage()).ImportCalendar(strings.NewReader(ics), john.Id); !errors.Is(err, lib.ErrNotExist) {
+		t.Errorf("unknown creator: expected: %v, actual: %v", lib.ErrNotExist, err)
+	}
+}
+
+func TestApiRoutes(t *testing.T) {
+	resetStorage()
+	send := func(method, target, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return executeRequest(req)
+	}
+
+	response := send("POST", "/api/v1/users", "name=John")
+	if expected := http.StatusCreated; response.Code != expected {
+		t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+	var john, jane idResult
+	json.Unmarshal(response.Body.Bytes(), &john)
+	if expected := fmt.Sprintf("/api/v1/users/%d", john.Id); response.Header().Get("Location") != expected {
+		t.Errorf("location: expected: %q, actual: %q", expected, response.Header().Get("Location"))
+	}
+	json.Unmarshal(sendJson("POST", "/api/v1/users", `{"Name": "Jane"}`).Body.Bytes(), &jane)
+
+	response = send("PATCH", fmt.Sprintf("/api/v1/users/%d", jane.Id), "email=jane@example.com")
+	var user lib.User
+	json.Unmarshal(response.Body.Bytes(), &user)
+	if response.Code != http.StatusOK || user.Id != jane.Id || user.Email != "jane@example.com" {
+		t.Errorf("changed user: %d %+v", response.Code, user)
+	}
+	response = send("GET", fmt.Sprintf("/api/v1/users/%d", john.Id), "")
+	json.Unmarshal(response.Body.Bytes(), &user)
+	if response.Code != http.StatusOK || user.Name != "John" {
+		t.Errorf("user: %d %+v", response.Code, user)
+	}
+
+	response = send("POST", "/api/v1/meetings", fmt.Sprintf("creator_id=%d&member_ids=%d,%d&start_at=2023-09-11T10:00:00Z&duration=1h", john.Id, john.Id, jane.Id))
+	if expected := http.StatusCreated; response.Code != expected {
+		t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+	var meeting meetingIdResult
+	json.Unmarshal(response.Body.Bytes(), &meeting)
+	meetingPath := fmt.Sprintf("/api/v1/meetings/%d", meeting.Id)
+	if response.Header().Get("Location") != meetingPath {
+		t.Errorf("location: expected: %q, actual: %q", meetingPath, response.Header().Get("Location"))
+	}
+
+	response = send("PATCH", meetingPath, fmt.Sprintf("user_id=%d&duration=2h", john.Id))
+	var m lib.Meeting
+	json.Unmarshal(response.Body.Bytes(), &m)
+	if response.Code != http.StatusOK || m.Duration.Duration != 2*time.Hour {
+		t.Errorf("changed meeting: %d %+v", response.Code, m)
+	}
+	response = send("PUT", fmt.Sprintf("%s/participants/%d/response", meetingPath, jane.Id), "presence=Accepted")
+	if expected := http.StatusNoContent; response.Code != expected {
+		t.Errorf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+	response = send("GET", meetingPath, "")
+	json.Unmarshal(response.Body.Bytes(), &m)
+	if response.Code != http.StatusOK || m.Id != meeting.Id || m.Members[1].Status != lib.Accepted {
+		t.Errorf("meeting: %d %+v", response.Code, m)
+	}
+	response = send("GET", fmt.Sprintf("/api/v1/users/%d/meetings?start_at=2023-09-11T00:00:00Z&duration=24h", jane.Id), "")
+	var meetings []lib.MeetingId
+	json.Unmarshal(response.Body.Bytes(), &meetings)
+	if response.Code != http.StatusOK || len(meetings) != 1 || meetings[0] != meeting.Id {
+		t.Errorf("user meetings: %d %s", response.Code, response.Body.String())
+	}
+
+	response = send("DELETE", meetingPath+fmt.Sprintf("?user_id=%d", john.Id), "")
+	if expected := http.StatusNoContent; response.Code != expected {
+		t.Errorf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+	expectProblem(t, send("GET", meetingPath, ""), "not_found", "")
+	response = send("DELETE", fmt.Sprintf("/api/v1/users/%d", jane.Id), "")
+	if expected := http.StatusNoContent; response.Code != expected {
+		t.Errorf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+
+	response = send("PUT", fmt.Sprintf("/api/v1/users/%d", john.Id), "name=Jack")
+	expectProblem(t, response, "method_not_allowed", "")
+	if expected := "DELETE, GET, PATCH"; response.Header().Get("Allow") != expected {
+		t.Errorf("allow: expected: %q, actual: %q", expected, response.Header().Get("Allow"))
+	}
+	expectProblem(t, send("GET", "/api/v1/rooms", ""), "not_found", "")
+	expectProblem(t, send("GET", "/api/v1/users/1/meetings/2", ""), "not_found", "")
+
+	// the old paths keep working
+	response = getUser(john.Id)
+	if response.Code != http.StatusOK || response.Header().Get("Deprecation") != "true" {
+		t.Errorf("deprecated path: %d %v", response.Code, response.Header())
+	}
+	if expected := `</api/v1/users>; rel="successor-version"`; response.Header().Get("Link") != expected {
+		t.Errorf("link: expected: %q, actual: %q", expected, response.Header().Get("Link"))
+	}
+	if response := createUser("Richard"); response.Code != http.StatusOK || response.Header().Get("Location") != "" {
+		t.Errorf("deprecated path: %d %v", response.Code, response.Header())
+	}
+}
+
+func TestListPages(t *testing.T) {
+	resetStorage()
+	get := func(target string) ([]byte, string) {
+		req, _ := http.NewRequest("GET", target, nil)
+		response := executeRequest(req)
+		if response.Code != http.StatusOK {
+			t.Fatalf("%s: response code: expected: %d, actual: %d\n%s", target, http.StatusOK, response.Code, response.Body.String())
+		}
+		next := ""
+		for _, link := range response.Header().Values("Link") {
+			if strings.HasSuffix(link, `>; rel="next"`) {
+				next = strings.TrimSuffix(strings.TrimPrefix(link, "<"), `>; rel="next"`)
+			}
+		}
+		return response.Body.Bytes(), next
+	}
+
+	ids := make(map[string]lib.UID)
+	for _, name := range []string{"Richard Roe", "jane Doe", "John Doe", "Jane Roe", "Baby Doe"} {
+		var user idResult
+		json.Unmarshal(createUser(name).Body.Bytes(), &user)
+		ids[name] = user.Id
+	}
+	deleteUser(ids["Richard Roe"], "mode=deactivate")
+
+	// the pages of the users sorted by name
+	names := make([]string, 0)
+	pages := 0
+	for target := "/user?sort=name&limit=2"; target != ""; pages++ {
+		var body []byte
+		var users []lib.User
+		body, target = get(target)
+		json.Unmarshal(body, &users)
+		for _, u := range users {
+			names = append(names, u.Name)
+		}
+	}
+	if expected := []string{"Baby Doe", "jane Doe", "Jane Roe", "John Doe", "Richard Roe"}; fmt.Sprint(names) != fmt.Sprint(expected) || pages != 3 {
+		t.Errorf("users by name: expected: %q in 3 pages, actual: %q in %d pages", expected, names, pages)
+	}
+
+	var users []lib.User
+	body, next := get("/api/v1/users?name=doe&deactivated=false")
+	json.Unmarshal(body, &users)
+	if len(users) != 3 || users[0].Id != ids["jane Doe"] || users[2].Id != ids["Baby Doe"] || next != "" {
+		t.Errorf("filtered users: %+v, next: %q", users, next)
+	}
+
+	john, jane, baby := ids["John Doe"], ids["jane Doe"], ids["Baby Doe"]
+	meetings := []meetingParams{
+		{creator: john, members: []lib.UID{john, jane}, start: getTime("2023-03-22T10:00:00Z"), duration: time.Hour, period: lib.Once},
+		{creator: jane, members: []lib.UID{jane}, start: getTime("2023-03-20T09:00:00Z"), duration: time.Hour, period: lib.EveryWeek},
+		{creator: john, members: []lib.UID{john, baby}, start: getTime("2023-03-21T10:00:00Z"), duration: time.Hour, period: lib.EveryDay},
+		{creator: baby, members: []lib.UID{baby}, start: getTime("2023-03-21T10:00:00Z"), duration: time.Hour, period: lib.Once},
+	}
+	meetingIds := make([]lib.MeetingId, 0, len(meetings))
+	for _, p := range meetings {
+		var m meetingIdResult
+		json.Unmarshal(createMeeting(p).Body.Bytes(), &m)
+		meetingIds = append(meetingIds, m.Id)
+	}
+
+	for _, test := range []struct {
+		query    string
+		expected []int
+	}{
+		{"", []int{0, 1, 2, 3}},
+		{"sort=start_at", []int{1, 2, 3, 0}},
+		{fmt.Sprintf("creator_id=%d", john), []int{0, 2}},
+		{fmt.Sprintf("member_id=%d", baby), []int{2, 3}},
+		{"period=Once", []int{0, 3}},
+		{"start_at=2023-03-22T10:30:00Z&end_at=2023-03-22T12:00:00Z", []int{0, 2}},
+		{"end_at=2023-03-21T00:00:00Z", []int{1}},
+		{"start_at=2023-03-23T00:00:00Z&sort=start_at", []int{1, 2}},
+	} {
+		result := make([]lib.MeetingId, 0)
+		for target := "/meeting?limit=1&" + test.query; target != ""; {
+			var body []byte
+			var page []lib.Meeting
+			body, target = get(target)
+			json.Unmarshal(body, &page)
+			for _, m := range page {
+				result = append(result, m.Id)
+			}
+		}
+		expected := make([]lib.MeetingId, 0, len(test.expected))
+		for _, i := range test.expected {
+			expected = append(expected, meetingIds[i])
+		}
+		if fmt.Sprint(result) != fmt.Sprint(expected) {
+			t.Errorf("%q: expected: %v, actual: %v", test.query, expected, result)
+		}
+	}
+
+	_, next = get("/meeting?limit=2")
+	for _, test := range []struct{ target, param string }{
+		{"/meeting?limit=0", "limit"},
+		{"/meeting?sort=name", "sort"},
+		{"/meeting?cursor=xyz", "cursor"},
+		{strings.Replace(next, "limit=2", "sort=start_at", 1), "cursor"},
+		{"/meeting?start_at=2023-03-22T00:00:00Z&end_at=2023-03-21T00:00:00Z", "end_at"},
+		{"/user?deactivated=maybe", "deactivated"},
+	} {
+		req, _ := http.NewRequest("GET", test.target, nil)
+		expectProblem(t, executeRequest(req), "invalid_parameter", test.param)
+	}
+}
+
+func TestDeleteMeeting(t *testing.T) {
+	resetStorage()
+
+	//create users
+	const (
+		johnDoe     = "John Doe"
+		vincentVega = "Vincent Vega"
+		johnMcClane = "John McClane"
+	)
+	names := []string{johnDoe, vincentVega, johnMcClane}
+	ids := make(map[string]lib.UID, len(names))
+	for _, name := range names {
+		response := createUser(name)
+		if expected := http.StatusOK; response.Code != expected {
+			t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+		}
+		var id idResult
+		if err := json.Unmarshal(response.Body.Bytes(), &id); err != nil {
+			t.Fatalf("parse response body: %v", err)
+		}
+		ids[name] = id.Id
+	}
+
+	// create meeting
+	params := meetingParams{creator: ids[vincentVega], members: []lib.UID{ids[vincentVega], ids[johnMcClane]}, start: getTime("2022-11-20T8:00:00Z"), duration: getDuration("1h"), period: lib.EveryWeek}
+	response := createMeeting(params)
+	if expected := http.StatusOK; response.Code != expected {
+		t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+	var id meetingIdResult
+	if err := json.Unmarshal(response.Body.Bytes(), &id); err != nil {
+		t.Fatalf("parse response body: %v", err)
+	}
+	expectMeeting(t, id.Id, ids[johnMcClane], getTime("2022-11-27T7:00:00Z"), getDuration("2h"))
+
+	// only the creator may delete the meeting
+	response = deleteMeeting(id.Id, ids[johnMcClane])
+	if expected := http.StatusForbidden; response.Code != expected {
+		t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+	response = deleteMeeting(id.Id, ids[vincentVega])
+	if expected := http.StatusOK; response.Code != expected {
+		t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+	response = getMeeting(id.Id)
+	if expected := http.StatusNotFound; response.Code != expected {
+		t.Errorf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+	response = deleteMeeting(id.Id, ids[vincentVega])
+	if expected := http.StatusNotFound; response.Code != expected {
+		t.Errorf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+	expectNoMeeting(t, id.Id, ids[johnMcClane], getTime("2022-11-27T7:00:00Z"), getDuration("2h"))
+
+	// members are notified
+	for _, userId := range []lib.UID{ids[vincentVega], ids[johnMcClane]} {
+		cancellations := getCancellations(t, userId, getTime("2022-11-27T7:00:00Z"), getDuration("2h"))
+		if expected := 1; len(cancellations) != expected {
+			t.Fatalf("cancellations: expected: %d, actual: %d\n", expected, len(cancellations))
+		}
+		if c := cancellations[0]; c.Id != id.Id || c.CancelledBy != ids[vincentVega] {
+			t.Errorf("cancellation: expected: meeting %d by %d, actual: meeting %d by %d\n", id.Id, ids[vincentVega], c.Id, c.CancelledBy)
+		}
+		if cancellations := getCancellations(t, userId, getTime("2022-11-28T7:00:00Z"), getDuration("2h")); len(cancellations) != 0 {
+			t.Errorf("cancellations out of the period: expected: 0, actual: %d\n", len(cancellations))
+		}
+	}
+	if cancellations := getCancellations(t, ids[johnDoe], getTime("2022-11-27T7:00:00Z"), getDuration("2h")); len(cancellations) != 0 {
+		t.Errorf("cancellations of not a member: expected: 0, actual: %d\n", len(cancellations))
+	}
+}
+
+func TestPatchMeeting(t *testing.T) {
+	resetStorage()
+
+	//create users
+	const (
+		johnDoe     = "John Doe"
+		vincentVega = "Vincent Vega"
+		johnMcClane = "John McClane"
+	)
+	names := []string{johnDoe, vincentVega, johnMcClane}
+	ids := make(map[string]lib.UID, len(names))
+	for _, name := range names {
+		response := createUser(name)
+		if expected := http.StatusOK; response.Code != expected {
+			t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+		}
+		var id idResult
+		if err := json.Unmarshal(response.Body.Bytes(), &id); err != nil {
+			t.Fatalf("parse response body: %v", err)
+		}
+		ids[name] = id.Id
+	}
+
+	// create meeting
+	params := meetingParams{creator: ids[johnDoe], members: []lib.UID{ids[johnDoe], ids[vincentVega]}, start: getTime("2022-11-20T8:00:00Z"), duration: getDuration("1h"), period: lib.Once}
+	response := createMeeting(params)
+	if expected := http.StatusOK; response.Code != expected {
+		t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+	var id meetingIdResult
+	if err := json.Unmarshal(response.Body.Bytes(), &id); err != nil {
+		t.Fatalf("parse response body: %v", err)
+	}
+	sendPresence(ids[johnDoe], id.Id, lib.Accepted)
+
+	// only the creator may change the meeting
+	response = patchMeeting(id.Id, ids[vincentVega], "duration=2h")
+	if expected := http.StatusForbidden; response.Code != expected {
+		t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+	response = patchMeeting(id.Id, ids[johnDoe], fmt.Sprintf("remove_member_ids=%d", ids[johnMcClane]))
+	if expected := http.StatusNotFound; response.Code != expected {
+		t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+	response = patchMeeting(id.Id, ids[johnDoe], fmt.Sprintf("remove_member_ids=%d,%d", ids[johnDoe], ids[vincentVega]))
+	if expected := http.StatusBadRequest; response.Code != expected {
+		t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+
+	// change members
+	response = patchMeeting(id.Id, ids[johnDoe], fmt.Sprintf("add_member_ids=%d&remove_member_ids=%d", ids[johnMcClane], ids[vincentVega]))
+	if expected := http.StatusOK; response.Code != expected {
+		t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+	var meeting lib.Meeting
+	if err := json.Unmarshal(response.Body.Bytes(), &meeting); err != nil {
+		t.Fatalf("parse response body: %v", err)
+	}
+	expectedStatus := map[lib.UID]lib.Presence{ids[johnDoe]: lib.Accepted, ids[johnMcClane]: lib.Unknown}
+	if len(meeting.Members) != len(expectedStatus) {
+		t.Fatalf("members: expected: %d, actual: %d\n", len(expectedStatus), len(meeting.Members))
+	}
+	for _, member := range meeting.Members {
+		if expected, ok := expectedStatus[member.UserId]; !ok || member.Status != expected {
+			t.Errorf("member %d presence: expected: %v, actual: %v\n", member.UserId, expected, member.Status)
+		}
+	}
+	expectNoMeeting(t, id.Id, ids[vincentVega], getTime("2022-11-20T7:00:00Z"), getDuration("2h"))
+	expectMeeting(t, id.Id, ids[johnMcClane], getTime("2022-11-20T7:00:00Z"), getDuration("2h"))
+
+	// change time
+	response = patchMeeting(id.Id, ids[johnDoe], "start_at=2022-11-21T10:00:00Z&duration=30m&period=EveryDay")
+	if expected := http.StatusOK; response.Code != expected {
+		t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+	response = getMeeting(id.Id)
+	if err := json.Unmarshal(response.Body.Bytes(), &meeting); err != nil {
+		t.Fatalf("parse response body: %v", err)
+	}
+	if !meeting.FirstOccurence.Equal(getTime("2022-11-21T10:00:00Z")) || meeting.Duration.Duration != getDuration("30m") || meeting.Repeat != lib.EveryDay {
+		t.Errorf("meeting time: expected: %v %v %v, actual: %v %v %v\n", getTime("2022-11-21T10:00:00Z"), getDuration("30m"), lib.EveryDay, meeting.FirstOccurence, meeting.Duration, meeting.Repeat)
+	}
+	for _, member := range meeting.Members {
+		if expected := lib.Unknown; member.Status != expected {
+			t.Errorf("member %d presence: expected: %v, actual: %v\n", member.UserId, expected, member.Status)
+		}
+	}
+	expectNoMeeting(t, id.Id, ids[johnMcClane], getTime("2022-11-20T7:00:00Z"), getDuration("2h"))
+	expectMeeting(t, id.Id, ids[johnMcClane], getTime("2022-11-25T10:00:00Z"), getDuration("1h"))
+}
+
+func TestUpdateUser(t *testing.T) {
+	resetStorage()
+
+	response := createUser("John Doe")
+	var id idResult
+	if err := json.Unmarshal(response.Body.Bytes(), &id); err != nil {
+		t.Fatalf("parse response body: %v", err)
+	}
+
+	response = updateUser(id.Id, "name=Vincent Vega&email=vincent@example.com")
+	if expected := http.StatusOK; response.Code != expected {
+		t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+	response = getUser(id.Id)
+	var user lib.User
+	if err := json.Unmarshal(response.Body.Bytes(), &user); err != nil {
+		t.Fatalf("parse response body: %v", err)
+	}
+	if user.Name != "Vincent Vega" || user.Email != "vincent@example.com" {
+		t.Errorf("user: expected: %q <%s>, actual: %q <%s>\n", "Vincent Vega", "vincent@example.com", user.Name, user.Email)
+	}
+
+	response = updateUser(id.Id+1, "name=Nobody")
+	if expected := http.StatusNotFound; response.Code != expected {
+		t.Errorf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+}
+
+func TestDeleteUser(t *testing.T) {
+	resetStorage()
+
+	//create users
+	const (
+		johnDoe     = "John Doe"
+		vincentVega = "Vincent Vega"
+		johnMcClane = "John McClane"
+	)
+	names := []string{johnDoe, vincentVega, johnMcClane}
+	ids := make(map[string]lib.UID, len(names))
+	for _, name := range names {
+		response := createUser(name)
+		if expected := http.StatusOK; response.Code != expected {
+			t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+		}
+		var id idResult
+		if err := json.Unmarshal(response.Body.Bytes(), &id); err != nil {
+			t.Fatalf("parse response body: %v", err)
+		}
+		ids[name] = id.Id
+	}
+
+	// create meetings
+	paramList := []meetingParams{
+		{creator: ids[johnDoe], members: []lib.UID{ids[johnDoe], ids[vincentVega]}, start: getTime("2022-11-20T8:00:00Z"), duration: getDuration("1h"), period: lib.EveryDay},
+		{creator: ids[johnDoe], members: []lib.UID{ids[johnDoe]}, start: getTime("2022-11-20T10:00:00Z"), duration: getDuration("1h"), period: lib.EveryDay},
+		{creator: ids[vincentVega], members: []lib.UID{ids[johnDoe], ids[johnMcClane]}, start: getTime("2022-11-20T12:00:00Z"), duration: getDuration("1h"), period: lib.EveryDay},
+	}
+	meetingIds := make([]lib.MeetingId, 0)
+	for _, params := range paramList {
+		response := createMeeting(params)
+		if expected := http.StatusOK; response.Code != expected {
+			t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+		}
+		var id meetingIdResult
+		if err := json.Unmarshal(response.Body.Bytes(), &id); err != nil {
+			t.Fatalf("parse response body: %v", err)
+		}
+		meetingIds = append(meetingIds, id.Id)
+	}
+
+	response := deleteUser(ids[johnDoe], "")
+	if expected := http.StatusOK; response.Code != expected {
+		t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+	response = getUser(ids[johnDoe])
+	if expected := http.StatusNotFound; response.Code != expected {
+		t.Errorf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+	response = findFreeTime([]lib.UID{ids[johnDoe]}, getTime("2022-11-20T8:00:00Z"), getDuration("1h"))
+	if expected := http.StatusNotFound; response.Code != expected {
+		t.Errorf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+
+	// 1st meeting is passed to the remaining member
+	var meeting lib.Meeting
+	response = getMeeting(meetingIds[0])
+	if err := json.Unmarshal(response.Body.Bytes(), &meeting); err != nil {
+		t.Fatalf("parse response body: %v", err)
+	}
+	if meeting.CreatorId != ids[vincentVega] || len(meeting.Members) != 1 || meeting.Members[0].UserId != ids[vincentVega] {
+		t.Errorf("meeting %d: expected creator and the only member %d, actual: %+v\n", meetingIds[0], ids[vincentVega], meeting)
+	}
+	// 2nd meeting has no members left
+	response = getMeeting(meetingIds[1])
+	if expected := http.StatusNotFound; response.Code != expected {
+		t.Errorf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+	// 3rd meeting loses the member
+	response = getMeeting(meetingIds[2])
+	if err := json.Unmarshal(response.Body.Bytes(), &meeting); err != nil {
+		t.Fatalf("parse response body: %v", err)
+	}
+	if meeting.CreatorId != ids[vincentVega] || len(meeting.Members) != 1 || meeting.Members[0].UserId != ids[johnMcClane] {
+		t.Errorf("meeting %d: expected the only member %d, actual: %+v\n", meetingIds[2], ids[johnMcClane], meeting)
+	}
+
+	// deactivated user is kept but can't be invited
+	response = deleteUser(ids[vincentVega], "mode=deactivate&created_meetings=cancel")
+	if expected := http.StatusOK; response.Code != expected {
+		t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+	response = getUser(ids[vincentVega])
+	var user lib.User
+	if err := json.Unmarshal(response.Body.Bytes(), &user); err != nil {
+		t.Fatalf("parse response body: %v", err)
+	}
+	if !user.Deactivated {
+		t.Errorf("user %d should be deactivated\n", user.Id)
+	}
+	response = getMeeting(meetingIds[0])
+	if expected := http.StatusNotFound; response.Code != expected {
+		t.Errorf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+	response = getMeeting(meetingIds[2])
+	if expected := http.StatusNotFound; response.Code != expected {
+		t.Errorf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+	if cancellations := getCancellations(t, ids[johnMcClane], getTime("2022-11-21T12:00:00Z"), getDuration("1h")); len(cancellations) != 1 {
+		t.Errorf("cancellations: expected: 1, actual: %d\n", len(cancellations))
+	}
+	response = createMeeting(meetingParams{creator: ids[johnMcClane], members: []lib.UID{ids[vincentVega], ids[johnMcClane]}, start: getTime("2022-11-20T8:00:00Z"), duration: getDuration("1h"), period: lib.Once})
+	if expected := http.StatusNotFound; response.Code != expected {
+		t.Errorf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+}
+
+func TestIsolatedServices(t *testing.T) {
+	for i := 0; i < 2; i++ {
+		users := i + 1
+		t.Run(fmt.Sprintf("service-%d", i), func(t *testing.T) {
+			t.Parallel()
+			mux := http.NewServeMux()
+			initRouter(mux, lib.NewService(lib.NewMemoryStorage()))
+			for j := 0; j < users; j++ {
+				var payload bytes.Buffer
+				fmt.Fprintf(&payload, "name=User %d", j)
+				req, _ := http.NewRequest("POST", "/user", &payload)
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+				rr := httptest.NewRecorder()
+				mux.ServeHTTP(rr, req)
+				if expected := http.StatusOK; rr.Code != expected {
+					t.Fatalf("response code: expected: %d, actual: %d\n", expected, rr.Code)
+				}
+			}
+
+			req, _ := http.NewRequest("GET", "/user", nil)
+			rr := httptest.NewRecorder()
+			mux.ServeHTTP(rr, req)
+			var userList []lib.User
+			if err := json.Unmarshal(rr.Body.Bytes(), &userList); err != nil {
+				t.Fatalf("parse response body: %v", err)
+			}
+			if expected := users; len(userList) != expected {
+				t.Errorf("users in list: expected: %v, actual: %v\n", expected, len(userList))
+			}
+		})
+	}
+}
+
+func TestAuth(t *testing.T) {
+	const adminToken = "secret"
+	service := lib.NewService(lib.NewMemoryStorage())
+	service.EnableAuth(adminToken)
+	mux := http.NewServeMux()
+	initRouter(mux, service)
+	send := func(token, method, target, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, req)
+		return rr
+	}
+	issueToken := func(token string, id lib.UID) string {
+		rr := send(token, "POST", "/token", fmt.Sprintf("user_id=%d", id))
+		if expected := http.StatusOK; rr.Code != expected {
+			t.Fatalf("issue token: response code: expected: %d, actual: %d\n", expected, rr.Code)
+		}
+		var result struct{ Token string }
+		if err := json.Unmarshal(rr.Body.Bytes(), &result); err != nil {
+			t.Fatalf("parse response body: %v", err)
+		}
+		return result.Token
+	}
+
+	rr := send("", "GET", "/user", "")
+	if expected := http.StatusUnauthorized; rr.Code != expected {
+		t.Fatalf("response code: expected: %d, actual: %d\n", expected, rr.Code)
+	}
+	if expected := "Bearer"; rr.Header().Get("WWW-Authenticate") != expected {
+		t.Errorf("WWW-Authenticate: expected: %q, actual: %q\n", expected, rr.Header().Get("WWW-Authenticate"))
+	}
+	expectProblem(t, rr, "unauthorized", "")
+	if rr := send("wrong", "GET", "/user", ""); rr.Code != http.StatusUnauthorized {
+		t.Errorf("wrong token: response code: expected: %d, actual: %d\n", http.StatusUnauthorized, rr.Code)
+	}
+
+	ids := make([]lib.UID, 0, 2)
+	for _, name := range []string{"John", "Jane"} {
+		rr := send(adminToken, "POST", "/user", "name="+name)
+		var id idResult
+		if err := json.Unmarshal(rr.Body.Bytes(), &id); err != nil {
+			t.Fatalf("parse response body: %v", err)
+		}
+		ids = append(ids, id.Id)
+	}
+	john, jane := ids[0], ids[1]
+	johnToken := issueToken(adminToken, john)
+	janeToken := issueToken(adminToken, jane)
+
+	meeting := fmt.Sprintf("member_ids=%d,%d&start_at=2023-09-11T10:00:00Z&duration=1h", john, jane)
+	rr = send(johnToken, "POST", "/meeting", fmt.Sprintf("creator_id=%d&", john)+meeting)
+	if expected := http.StatusOK; rr.Code != expected {
+		t.Fatalf("response code: expected: %d, actual: %d\n", expected, rr.Code)
+	}
+	var created meetingIdResult
+	if err := json.Unmarshal(rr.Body.Bytes(), &created); err != nil {
+		t.Fatalf("parse response body: %v", err)
+	}
+
+	for _, test := range []struct {
+		token, method, target, body string
+		expected                    int
+	}{
+		{johnToken, "GET", "/user", "", http.StatusOK},
+		{johnToken, "POST", "/user", "name=Jack", http.StatusForbidden},
+		{johnToken, "POST", "/resource", "name=Room", http.StatusForbidden},
+		{adminToken, "POST", "/resource", "name=Room", http.StatusOK},
+		{janeToken, "POST", "/meeting", fmt.Sprintf("creator_id=%d&", john) + meeting, http.StatusForbidden},
+		{janeToken, "PATCH", "/meeting", fmt.Sprintf("id=%d&user_id=%d&duration=2h", created.Id, john), http.StatusForbidden},
+		// the user acting on own behalf is still not the creator
+		{janeToken, "PATCH", "/meeting", fmt.Sprintf("id=%d&user_id=%d&duration=2h", created.Id, jane), http.StatusForbidden},
+		{johnToken, "PATCH", "/meeting", fmt.Sprintf("id=%d&user_id=%d&duration=2h", created.Id, john), http.StatusOK},
+		{adminToken, "PATCH", "/meeting", fmt.Sprintf("id=%d&user_id=%d&duration=90m", created.Id, john), http.StatusOK},
+		{johnToken, "PUT", "/response", fmt.Sprintf("user_id=%d&meeting_id=%d&presence=Accepted", jane, created.Id), http.StatusForbidden},
+		{janeToken, "PUT", "/response", fmt.Sprintf("user_id=%d&meeting_id=%d&presence=Accepted", jane, created.Id), http.StatusOK},
+		{janeToken, "PUT", "/working_hours", fmt.Sprintf("id=%d&weekly=%s", john, url.QueryEscape("MO 09:00-17:00")), http.StatusForbidden},
+		{janeToken, "PUT", "/user", fmt.Sprintf("id=%d&name=Janet", jane), http.StatusOK},
+		{janeToken, "POST", "/token", fmt.Sprintf("user_id=%d", john), http.StatusForbidden},
+		// calendar apps pass the token in the feed URL
+		{"", "GET", fmt.Sprintf("/calendar.ics?user_id=%d&access_token=%s", jane, janeToken), "", http.StatusOK},
+		{"", "GET", fmt.Sprintf("/calendar.ics?user_id=%d&access_token=%s", john, janeToken), "", http.StatusForbidden},
+		{janeToken, "DELETE", fmt.Sprintf("/api/v1/users/%d", john), "", http.StatusForbidden},
+		{janeToken, "DELETE", fmt.Sprintf("/meeting?id=%d&user_id=%d", created.Id, jane), "", http.StatusForbidden},
+		{johnToken, "DELETE", fmt.Sprintf("/meeting?id=%d&user_id=%d", created.Id, john), "", http.StatusOK},
+	} {
+		rr := send(test.token, test.method, test.target, test.body)
+		if rr.Code != test.expected {
+			t.Errorf("%s %s %q: response code: expected: %d, actual: %d\n", test.method, test.target, test.body, test.expected, rr.Code)
+		}
+	}
+
+	// the new token replaces the old one, the revoked token is rejected
+	newToken := issueToken(johnToken, john)
+	if rr := send(johnToken, "GET", "/user", ""); rr.Code != http.StatusUnauthorized {
+		t.Errorf("replaced token: response code: expected: %d, actual: %d\n", http.StatusUnauthorized, rr.Code)
+	}
+	if rr := send(newToken, "DELETE", fmt.Sprintf("/token?user_id=%d", john), ""); rr.Code != http.StatusOK {
+		t.Errorf("revoke token: response code: expected: %d, actual: %d\n", http.StatusOK, rr.Code)
+	}
+	if rr := send(newToken, "GET", "/user", ""); rr.Code != http.StatusUnauthorized {
+		t.Errorf("revoked token: response code: expected: %d, actual: %d\n", http.StatusUnauthorized, rr.Code)
+	}
+}
+
+func TestFileStorage(t *testing.T) {
+	dir := t.TempDir()
+	storage, err := lib.NewFileStorage(dir)
+	if err != nil {
+		t.Fatalf("open storage: %v", err)
+	}
+
+	// enough records to compact the journal at least once
+	const userCount = 150
+	for i := 0; i < userCount; i++ {
+		if _, err := storage.UserAdd(lib.UserInfo{Name: fmt.Sprintf("User %d", i)}); err != nil {
+			t.Fatalf("add user: %v", err)
+		}
+	}
+	resourceId, _ := storage.ResourceAdd(lib.ResourceInfo{Name: "Room", Capacity: 4})
+	removedId, _ := storage.ResourceAdd(lib.ResourceInfo{Name: "Projector"})
+	if err := storage.ResourceDelete(removedId); err != nil {
+		t.Fatalf("delete resource: %v", err)
+	}
+	members := []lib.Participant{{UserId: 1, Status: lib.Unknown}, {UserId: 2, Status: lib.Unknown}}
+	meetingId, err := storage.MeetingAdd(lib.MeetingInfo{Members: members, CreatorId: 1, FirstOccurence: getTime("2022-11-20T08:00:00Z"), Duration: lib.Duration{Duration: getDuration("1h")}, Repeat: lib.EveryWeek, Resources: []lib.ResourceId{resourceId}})
+	if err != nil {
+		t.Fatalf("add meeting: %v", err)
+	}
+	meeting, _ := storage.MeetingFindById(meetingId)
+	meeting.Members[1].Status = lib.Accepted
+	if err := storage.MeetingUpdate(meeting); err != nil {
+		t.Fatalf("update meeting: %v", err)
+	}
+	cancelledId, _ := storage.MeetingAdd(lib.MeetingInfo{Members: members, CreatorId: 2, FirstOccurence: getTime("2022-11-21T08:00:00Z"), Duration: lib.Duration{Duration: getDuration("1h")}})
+	if err := storage.MeetingDelete(cancelledId, 2); err != nil {
+		t.Fatalf("delete meeting: %v", err)
+	}
+	storage.UserSetToken(1, "hash-1")
+	storage.UserSetToken(3, "hash-3")
+	storage.UserSetToken(3, "")
+	storage.(io.Closer).Close()
+
+	// journal only: the snapshot is written on close
+	storage, err = lib.NewFileStorage(dir)
+	if err != nil {
+		t.Fatalf("reopen storage: %v", err)
+	}
+	if _, err := storage.UserAdd(lib.UserInfo{Name: "Last"}); err != nil {
+		t.Fatalf("add user: %v", err)
+	}
+	if err := storage.UserSetToken(2, "hash-2"); err != nil {
+		t.Fatalf("set token: %v", err)
+	}
+
+	storage, err = lib.NewFileStorage(dir)
+	if err != nil {
+		t.Fatalf("reopen storage: %v", err)
+	}
+	users, _ := storage.UserList()
+	if expected := userCount + 1; len(users) != expected {
+		t.Errorf("users: expected: %d, actual: %d", expected, len(users))
+	}
+	usr, err := storage.UserFindById(userCount + 1)
+	if err != nil || usr.Name != "Last" {
+		t.Errorf("user %d: expected: %q, actual: %q (%v)", userCount+1, "Last", usr.Name, err)
+	}
+	restored, err := storage.MeetingFindById(meetingId)
+	if err != nil {
+		t.Fatalf("find meeting: %v", err)
+	}
+	if restored.Repeat != lib.EveryWeek || restored.Members[1].Status != lib.Accepted || !restored.FirstOccurence.Equal(meeting.FirstOccurence) {
+		t.Errorf("meeting: expected: %+v, actual: %+v", meeting, restored)
+	}
+	meets, err := storage.UserMeetings(2)
+	if err != nil || len(meets) != 1 || meets[0].Id != meetingId {
+		t.Errorf("user meetings: expected: [%d], actual: %v (%v)", meetingId, meets, err)
+	}
+	cancellations, err := storage.UserCancellations(1)
+	if err != nil || len(cancellations) != 1 || cancellations[0].Id != cancelledId || cancellations[0].CancelledBy != 2 {
+		t.Errorf("user cancellations: expected: [%d], actual: %v (%v)", cancelledId, cancellations, err)
+	}
+	id, _ := storage.UserAdd(lib.UserInfo{Name: "Next"})
+	if expected := lib.UID(userCount + 2); id != expected {
+		t.Errorf("next user id: expected: %d, actual: %d", expected, id)
+	}
+	if _, err := storage.ResourceFindById(removedId); !errors.Is(err, lib.ErrNotExist) {
+		t.Errorf("removed resource: expected: %v, actual: %v", lib.ErrNotExist, err)
+	}
+	// the bookings are restored together with the meetings
+	_, err = storage.MeetingAdd(lib.MeetingInfo{Members: members[:1], CreatorId: 1, FirstOccurence: getTime("2022-12-04T08:30:00Z"), Duration: lib.Duration{Duration: getDuration("1h")}, Resources: []lib.ResourceId{resourceId}})
+	if !errors.Is(err, lib.ErrConflict) {
+		t.Errorf("double booking: expected: %v, actual: %v", lib.ErrConflict, err)
+	}
+	if resId, _ := storage.ResourceAdd(lib.ResourceInfo{Name: "Next"}); resId != removedId+1 {
+		t.Errorf("next resource id: expected: %d, actual: %d", removedId+1, resId)
+	}
+	for hash, expected := range map[string]lib.UID{"hash-1": 1, "hash-2": 2} {
+		if usr, err := storage.UserFindByToken(hash); err != nil || usr.Id != expected {
+			t.Errorf("token %q: expected: %d, actual: %d (%v)", hash, expected, usr.Id, err)
+		}
+	}
+	if _, err := storage.UserFindByToken("hash-3"); !errors.Is(err, lib.ErrNotExist) {
+		t.Errorf("revoked token: expected: %v, actual: %v", lib.ErrNotExist, err)
+	}
+}
+
+func TestFileStorageUserRemove(t *testing.T) {
+	dir := t.TempDir()
+	storage, err := lib.NewFileStorage(dir)
+	if err != nil {
+		t.Fatalf("open storage: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		storage.UserAdd(lib.UserInfo{Name: fmt.Sprintf("User %d", i)})
+	}
+	members := []lib.Participant{{UserId: 1, Status: lib.Unknown}, {UserId: 2, Status: lib.Unknown}}
+	passedId, _ := storage.MeetingAdd(lib.MeetingInfo{Members: members, CreatorId: 1, FirstOccurence: getTime("2022-11-20T08:00:00Z"), Duration: lib.Duration{Duration: getDuration("1h")}})
+	cancelledId, _ := storage.MeetingAdd(lib.MeetingInfo{Members: members[:1], CreatorId: 3, FirstOccurence: getTime("2022-11-20T10:00:00Z"), Duration: lib.Duration{Duration: getDuration("1h")}})
+	if err := storage.UserRemove(1, false, false, 3); err != nil {
+		t.Fatalf("remove user: %v", err)
+	}
+	if err := storage.UserRemove(1, false, false, 0); !errors.Is(err, lib.ErrNotExist) {
+		t.Errorf("removed user: expected: %v, actual: %v", lib.ErrNotExist, err)
+	}
+
+	// the whole cascade is restored from the journal
+	storage, err = lib.NewFileStorage(dir)
+	if err != nil {
+		t.Fatalf("reopen storage: %v", err)
+	}
+	if _, err := storage.UserFindById(1); !errors.Is(err, lib.ErrNotExist) {
+		t.Errorf("removed user: expected: %v, actual: %v", lib.ErrNotExist, err)
+	}
+	passed, err := storage.MeetingFindById(passedId)
+	if err != nil || passed.CreatorId != 3 || len(passed.Members) != 1 || passed.Members[0].UserId != 2 {
+		t.Errorf("meeting %d: expected creator 3 and the only member 2, actual: %+v (%v)", passedId, passed, err)
+	}
+	if _, err := storage.MeetingFindById(cancelledId); !errors.Is(err, lib.ErrNotExist) {
+		t.Errorf("meeting %d: expected: %v, actual: %v", cancelledId, lib.ErrNotExist, err)
+	}
+	meets, err := storage.UserMeetings(2)
+	if err != nil || len(meets) != 1 || meets[0].Id != passedId {
+		t.Errorf("user meetings: expected: [%d], actual: %v (%v)", passedId, meets, err)
+	}
+}
+
+//
+// helper functions
+//
+
+func expectMeeting(t *testing.T, meetingId lib.MeetingId, userId lib.UID, start time.Time, duration time.Duration) {
+	response := getUserMeetings(userId, start, duration)
+	if expected := http.StatusOK; response.Code != expected {
+		t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+	var meets []lib.MeetingId
+	if err := json.Unmarshal(response.Body.Bytes(), &meets); err != nil {
+		t.Fatalf("parse response body: %v", err)
+	}
+	found := false
+	for _, meet := range meets {
+		if meet == meetingId {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("meeting %d should be listed in period %v - %v", meetingId, start, start.Add(duration))
+	}
+}
+
+func expectNoMeeting(t *testing.T, meetingId lib.MeetingId, userId lib.UID, start time.Time, duration time.Duration) {
+	response := getUserMeetings(userId, start, duration)
+	if expected := http.StatusOK; response.Code != expected {
+		t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+	var meets []lib.MeetingId
+	if err := json.Unmarshal(response.Body.Bytes(), &meets); err != nil {
+		t.Fatalf("parse response body: %v", err)
+	}
+	for _, meet := range meets {
+		if meet == meetingId {
+			t.Errorf("meeting %d shouldn't be listed in period %v - %v", meetingId, start, start.Add(duration))
+		}
+	}
+}
+
+func expectProblem(t *testing.T, response *httptest.ResponseRecorder, code, param string) lib.Problem {
+	if expected := "application/problem+json"; response.Header().Get("Content-Type") != expected {
+		t.Errorf("content type: expected: %q, actual: %q\n", expected, response.Header().Get("Content-Type"))
+	}
+	var problem lib.Problem
+	if err := json.Unmarshal(response.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("parse response body: %v", err)
+	}
+	if problem.Status != response.Code {
+		t.Errorf("problem status: expected: %d, actual: %d\n", response.Code, problem.Status)
+	}
+	if problem.Code != code {
+		t.Errorf("problem code: expected: %q, actual: %q\n", code, problem.Code)
+	}
+	if problem.Param != param {
+		t.Errorf("problem param: expected: %q, actual: %q\n", param, problem.Param)
+	}
+	return problem
+}
+
+func createUser(name string) *httptest.ResponseRecorder {
+	var payload bytes.Buffer
+	fmt.Fprintf(&payload, "name=%s", name)
+	req, _ := http.NewRequest("POST", "/user", &payload)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return executeRequest(req)
+}
+
+func sendJson(method, target, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	return executeRequest(req)
+}
+
+func getUser(id lib.UID) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/user?id=%d", id), nil)
+	return executeRequest(req)
+}
+
+func getUserList() *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/user", nil)
+	return executeRequest(req)
+}
+
+func updateUser(id lib.UID, params string) *httptest.ResponseRecorder {
+	payload := bytes.NewBufferString(fmt.Sprintf("id=%d&%s", id, params))
+	req, _ := http.NewRequest("PUT", "/user", payload)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return executeRequest(req)
+}
+
+func deleteUser(id lib.UID, params string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("/user?id=%d&%s", id, params), nil)
+	return executeRequest(req)
+}
+
+type meetingParams struct {
+	creator   lib.UID
+	members   []lib.UID
+	optional  []lib.UID
+	start     time.Time
+	duration  time.Duration
+	period    lib.Period
+	rule      string
+	timeZone  string
+	resources []lib.ResourceId
+	policy    string
+}
+
+func putWorkingHours(id lib.UID, params string) *httptest.ResponseRecorder {
+	payload := bytes.NewBufferString(fmt.Sprintf("id=%d&%s", id, params))
+	req, _ := http.NewRequest("PUT", "/working_hours", payload)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return executeRequest(req)
+}
+
+func deleteWorkingHours(id lib.UID) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("/working_hours?id=%d", id), nil)
+	return executeRequest(req)
+}
+
+func createMeeting(p meetingParams) *httptest.ResponseRecorder {
+	var payload bytes.Buffer
+	fmt.Fprintf(&payload, "creator_id=%d&member_ids=", p.creator)
+	for i, id := range p.members {
+		if i > 0 {
+			fmt.Fprintf(&payload, ",")
+		}
+		fmt.Fprintf(&payload, "%d", id)
+	}
+	if len(p.optional) != 0 {
+		fmt.Fprintf(&payload, "&optional_member_ids=")
+		for i, id := range p.optional {
+			if i > 0 {
+				fmt.Fprintf(&payload, ",")
+			}
+			fmt.Fprintf(&payload, "%d", id)
+		}
+	}
+	fmt.Fprintf(&payload, "&start_at=%s", p.start.Format(time.RFC3339))
+	fmt.Fprintf(&payload, "&duration=%v", p.duration)
+	fmt.Fprintf(&payload, "&period=%v", p.period)
+	if p.rule != "" {
+		fmt.Fprintf(&payload, "&rrule=%s", url.QueryEscape(p.rule))
+	}
+	if p.timeZone != "" {
+		fmt.Fprintf(&payload, "&time_zone=%s", url.QueryEscape(p.timeZone))
+	}
+	for _, id := range p.resources {
+		fmt.Fprintf(&payload, "&resource_ids=%d", id)
+	}
+	if p.policy != "" {
+		fmt.Fprintf(&payload, "&conflict_policy=%s", p.policy)
+	}
+
+	req, _ := http.NewRequest("POST", "/meeting", &payload)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return executeRequest(req)
+}
+
+func createResource(params string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", "/resource", bytes.NewBufferString(params))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return executeRequest(req)
+}
+
+func getResources(params string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/resource?"+params, nil)
+	return executeRequest(req)
+}
+
+func putResource(params string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("PUT", "/resource", bytes.NewBufferString(params))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return executeRequest(req)
+}
+
+func deleteResource(id lib.ResourceId) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("/resource?id=%d", id), nil)
+	return executeRequest(req)
+}
+
+func getMeeting(id lib.MeetingId) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/meeting?id=%d", id), nil)
+	return executeRequest(req)
+}
+
+func getMeetingList() *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/meeting", nil)
 	return executeRequest(req)
 }
 
@@ -621,11 +3083,76 @@ func sendPresence(user lib.UID, meeting lib.MeetingId, status lib.Presence) *htt
 	return executeRequest(req)
 }
 
+func patchMeeting(id lib.MeetingId, user lib.UID, params string) *httptest.ResponseRecorder {
+	payload := bytes.NewBufferString(fmt.Sprintf("id=%d&user_id=%d&%s", id, user, params))
+	req, _ := http.NewRequest("PATCH", "/meeting", payload)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return executeRequest(req)
+}
+
+func deleteMeeting(id lib.MeetingId, user lib.UID) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("/meeting?id=%d&user_id=%d", id, user), nil)
+	return executeRequest(req)
+}
+
+func putException(id lib.MeetingId, user lib.UID, params string) *httptest.ResponseRecorder {
+	payload := bytes.NewBufferString(fmt.Sprintf("id=%d&user_id=%d&%s", id, user, params))
+	req, _ := http.NewRequest("PUT", "/meeting_exception", payload)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return executeRequest(req)
+}
+
+func deleteException(id lib.MeetingId, user lib.UID, occurrence string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("/meeting_exception?id=%d&user_id=%d&occurrence=%s", id, user, occurrence), nil)
+	return executeRequest(req)
+}
+
+func getCancellations(t *testing.T, id lib.UID, start time.Time, duration time.Duration) []lib.Cancellation {
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/user_meetings?id=%d&start_at=%s&duration=%v&cancelled=true", id, start.Format(time.RFC3339), duration), nil)
+	response := executeRequest(req)
+	if expected := http.StatusOK; response.Code != expected {
+		t.Fatalf("response code: expected: %d, actual: %d\n", expected, response.Code)
+	}
+	var cancellations []lib.Cancellation
+	if err := json.Unmarshal(response.Body.Bytes(), &cancellations); err != nil {
+		t.Fatalf("parse response body: %v", err)
+	}
+	return cancellations
+}
+
 func getUserMeetings(id lib.UID, start time.Time, duration time.Duration) *httptest.ResponseRecorder {
 	req, _ := http.NewRequest("GET", fmt.Sprintf("/user_meetings?id=%d&start_at=%s&duration=%v", id, start.Format(time.RFC3339), duration), nil)
 	return executeRequest(req)
 }
 
+func getOccurrences(id lib.UID, start, end string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/occurrences?user_id=%d&start_at=%s&end_at=%s", id, start, end), nil)
+	return executeRequest(req)
+}
+
+func getFreeBusy(ids, start, end, params string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/freebusy?id=%s&start_at=%s&end_at=%s&%s", ids, start, end, params), nil)
+	return executeRequest(req)
+}
+
+func getCalendar(id lib.UID, etag string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/calendar.ics?user_id=%d", id), nil)
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	return executeRequest(req)
+}
+
+func importCalendar(creator lib.UID, body string) *httptest.ResponseRecorder {
+	target := "/calendar.ics"
+	if creator != 0 {
+		target += fmt.Sprintf("?creator_id=%d", creator)
+	}
+	req, _ := http.NewRequest("POST", target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "text/calendar")
+	return executeRequest(req)
+}
+
 func findFreeTime(users []lib.UID, start time.Time, duration time.Duration) *httptest.ResponseRecorder {
 	var url strings.Builder
 	fmt.Fprintf(&url, "/find_free_time?id=")
@@ -642,14 +3169,27 @@ func findFreeTime(users []lib.UID, start time.Time, duration time.Duration) *htt
 	return executeRequest(req)
 }
 
+func findFreeSlots(id lib.UID, start, duration, params string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/find_free_time?id=%d&start_at=%s&duration=%s&%s", id, start, duration, params), nil)
+	return executeRequest(req)
+}
+
 type idResult struct {
 	Id lib.UID
 }
 
+type resourceIdResult struct {
+	Id lib.ResourceId
+}
+
 type meetingIdResult struct {
 	Id lib.MeetingId
 }
 
+func resetStorage() {
+	service.Storage().Reset()
+}
+
 func executeRequest(req *http.Request) *httptest.ResponseRecorder {
 	rr := httptest.NewRecorder()
 	http.DefaultServeMux.ServeHTTP(rr, req)
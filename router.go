@@ -8,14 +8,22 @@ import (
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
-func initRouter() {
-	http.HandleFunc("/user", schedule.UserHandler)
-	http.HandleFunc("/meeting", schedule.MeetingHandler)
-	http.HandleFunc("/response", schedule.ResponseHandler)
-	http.HandleFunc("/user_meetings", schedule.UserMeetingsHandler)
-	http.HandleFunc("/find_free_time", schedule.FindFreeTimeHandler)
+func initRouter(mux *http.ServeMux, service *schedule.Service) {
+	mux.HandleFunc(schedule.ApiPrefix, service.Authenticate(service.ApiHandler))
+	mux.HandleFunc("/user", schedule.Deprecated(schedule.ApiPrefix+"users", service.Authenticate(service.UserHandler)))
+	mux.HandleFunc("/token", service.Authenticate(service.TokenHandler))
+	mux.HandleFunc("/working_hours", service.Authenticate(service.WorkingHoursHandler))
+	mux.HandleFunc("/resource", service.Authenticate(service.ResourceHandler))
+	mux.HandleFunc("/meeting", schedule.Deprecated(schedule.ApiPrefix+"meetings", service.Authenticate(service.MeetingHandler)))
+	mux.HandleFunc("/meeting_exception", service.Authenticate(service.MeetingExceptionHandler))
+	mux.HandleFunc("/response", schedule.Deprecated(schedule.ApiPrefix+"meetings", service.Authenticate(service.ResponseHandler)))
+	mux.HandleFunc("/user_meetings", schedule.Deprecated(schedule.ApiPrefix+"users", service.Authenticate(service.UserMeetingsHandler)))
+	mux.HandleFunc("/occurrences", service.Authenticate(service.OccurrencesHandler))
+	mux.HandleFunc("/find_free_time", service.Authenticate(service.FindFreeTimeHandler))
+	mux.HandleFunc("/freebusy", service.Authenticate(service.FreeBusyHandler))
+	mux.HandleFunc("/calendar.ics", service.Authenticate(service.CalendarHandler))
 
-	http.Handle("/swagger/", httpSwagger.Handler(
+	mux.Handle("/swagger/", httpSwagger.Handler(
 		httpSwagger.URL("/swagger/doc.json"),
 	))
 }